@@ -3,8 +3,8 @@
 import { useState, useEffect } from "react";
 import TicketCatalog from "@/components/TicketCatalog";
 import BookingForm from "@/components/BookingForm";
-import { getAvailability } from "@/lib/api";
-import { TierInfo, Tier, getAvailableCount } from "@/types";
+import { getAvailability, subscribeAvailability } from "@/lib/api";
+import { TierInfo, Tier, applySeatEvent, getAvailableCount } from "@/types";
 
 export default function Home() {
   const [tiers, setTiers] = useState<TierInfo[]>([]);
@@ -39,6 +39,16 @@ export default function Home() {
     fetchAvailability();
   }, []);
 
+  // Keep the seat map live; snapshots replace it, seat events patch it
+  useEffect(() => {
+    return subscribeAvailability(
+      (availability) => {
+        if (availability.tiers) setTiers(availability.tiers);
+      },
+      (event) => setTiers((current) => applySeatEvent(current, event))
+    );
+  }, []);
+
   // Update selectedTierInfo when tiers or selectedTier changes
   useEffect(() => {
     if (selectedTier && tiers.length > 0) {
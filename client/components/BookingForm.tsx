@@ -1,6 +1,6 @@
 "use client";
 
-import { useState, useEffect } from "react";
+import { useState, useEffect, useRef } from "react";
 import {
   Tier,
   getSeatRangeForTier,
@@ -13,6 +13,7 @@ import {
   getIdempotencyKey,
   clearIdempotencyKey,
   getAvailability,
+  openSeatMap,
 } from "@/lib/api";
 
 // Generate UUID v4
@@ -55,6 +56,27 @@ export default function BookingForm({
   const [error, setError] = useState<string | null>(null);
   const [success, setSuccess] = useState(false);
   const [bookingId, setBookingId] = useState<string | null>(null);
+  const [heldSeat, setHeldSeat] = useState<number | null>(null);
+  const seatMap = useRef<ReturnType<typeof openSeatMap> | null>(null);
+
+  // Hold the selected seat so nobody else can book it during checkout
+  useEffect(() => {
+    const socket = openSeatMap(userId, {
+      onHeld: (hold) => setHeldSeat(hold.seatNo),
+      onReleased: () => setHeldSeat(null),
+      onError: (code, detail, seatNo) => {
+        setError(detail || code);
+        if (seatNo) {
+          setHeldSeat((prev) => (prev === seatNo ? null : prev));
+          setFormData((prev) =>
+            prev.seatNo === String(seatNo) ? { ...prev, seatNo: "" } : prev
+          );
+        }
+      },
+    });
+    seatMap.current = socket;
+    return () => socket.close();
+  }, [userId]);
 
   // Use availableList from tierInfo when tier is selected
   useEffect(() => {
@@ -64,8 +86,18 @@ export default function BookingForm({
       return;
     }
 
-    // Use availableList directly from server
-    const available = tierInfo.availableList || [];
+    // Use availableList from server; our own held seat is reserved for everyone else
+    const available = [...(tierInfo.availableList || [])];
+    const seatRange = getSeatRangeForTier(selectedTier);
+    if (
+      heldSeat !== null &&
+      heldSeat >= seatRange.min &&
+      heldSeat <= seatRange.max &&
+      !available.includes(heldSeat)
+    ) {
+      available.push(heldSeat);
+      available.sort((a, b) => a - b);
+    }
     setAvailableSeats(available);
 
     // Reset seat selection if current seat is no longer available
@@ -76,7 +108,7 @@ export default function BookingForm({
       }
       return prev;
     });
-  }, [selectedTier, tierInfo]);
+  }, [selectedTier, tierInfo, heldSeat]);
 
   if (!selectedTier) {
     return (
@@ -125,6 +157,7 @@ export default function BookingForm({
       if (response.success && response.booking) {
         setSuccess(true);
         setBookingId(response.booking.id);
+        setHeldSeat(null); // the booking used up the hold
         clearIdempotencyKey(bookingKey);
         onBookingSuccess();
 
@@ -159,6 +192,11 @@ export default function BookingForm({
   const handleChange = (
     e: React.ChangeEvent<HTMLInputElement | HTMLSelectElement>
   ) => {
+    if (e.target.name === "seatNo") {
+      if (heldSeat !== null) seatMap.current?.release(heldSeat);
+      const seatNo = parseInt(e.target.value, 10);
+      if (!isNaN(seatNo)) seatMap.current?.hold(seatNo);
+    }
     setFormData({
       ...formData,
       [e.target.name]: e.target.value,
@@ -287,7 +325,8 @@ export default function BookingForm({
             onChange={handleChange}
             required
             className="w-full px-3 py-2 border text-gray-800 text-medium border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
-            placeholder="e.g., USA, UK, Canada"
+            placeholder="e.g., US, GB, CA"
+            maxLength={2}
           />
         </div>
 
@@ -1,7 +1,44 @@
-import { AvailabilityResponse, BookingOrder, BookingResponse } from "@/types";
+import {
+  AvailabilityResponse,
+  BookingOrder,
+  BookingResponse,
+  FieldError,
+  Problem,
+  QuoteRequest,
+  QuoteResponse,
+  SeatEvent,
+  SeatHold,
+  SeatMapCommand,
+  SeatMapMessage,
+  SeatMapTicketResponse,
+  UserBookingsResponse,
+} from "@/types";
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080";
 
+// pinned to v1; the unversioned paths are deprecated
+const API_URL = `${API_BASE_URL}/v1`;
+
+// ApiError carries the problem details of a failed request
+export class ApiError extends Error {
+  readonly status: number;
+  readonly code: string;
+  readonly errors: FieldError[];
+
+  constructor(status: number, problem: Partial<Problem>, fallback: string) {
+    super(problem.detail || problem.message || fallback);
+    this.name = "ApiError";
+    this.status = status;
+    this.code = problem.code || "INTERNAL";
+    this.errors = problem.errors || [];
+  }
+}
+
+async function apiError(response: Response, fallback: string): Promise<ApiError> {
+  const problem: Partial<Problem> = await response.json().catch(() => ({}));
+  return new ApiError(response.status, problem, `${fallback}: ${response.statusText}`);
+}
+
 // Generate idempotency key (client-side UUID)
 function generateIdempotencyKey(): string {
   return `${Date.now()}-${Math.random().toString(36).substring(2, 15)}`;
@@ -32,7 +69,7 @@ export function clearIdempotencyKey(bookingKey: string): void {
 }
 
 export async function getAvailability(): Promise<AvailabilityResponse> {
-  const response = await fetch(`${API_BASE_URL}/booking/availability`, {
+  const response = await fetch(`${API_URL}/booking/availability`, {
     method: "GET",
     headers: {
       "Content-Type": "application/json",
@@ -40,13 +77,127 @@ export async function getAvailability(): Promise<AvailabilityResponse> {
   });
 
   if (!response.ok) {
-    throw new Error(`Failed to fetch availability: ${response.statusText}`);
+    throw await apiError(response, "Failed to fetch availability");
   }
   const data = await response.json();
   console.log("GET availability response", data);
   return data;
 }
 
+// Streams availability: onSnapshot gets the full availability on connect (and
+// when a reconnect can't resume), onSeat every seat change after it. EventSource
+// reconnects by itself and resumes from the last event it saw.
+export function subscribeAvailability(
+  onSnapshot: (availability: AvailabilityResponse) => void,
+  onSeat: (event: SeatEvent) => void
+): () => void {
+  const source = new EventSource(`${API_URL}/booking/availability/stream`);
+  source.addEventListener("snapshot", (message) => {
+    onSnapshot(JSON.parse((message as MessageEvent).data));
+  });
+  source.addEventListener("seat", (message) => {
+    onSeat(JSON.parse((message as MessageEvent).data));
+  });
+  return () => source.close();
+}
+
+export interface SeatMapHandlers {
+  onHeld?: (hold: SeatHold) => void;
+  onReleased?: (seatNo: number) => void;
+  // a hold or release failed, or a hold expired (HOLD_EXPIRED)
+  onError?: (code: string, detail: string, seatNo?: number) => void;
+}
+
+// Trades an access token for a one-time ticket to open the seat map WebSocket
+// with, since browsers can't send an Authorization header on it.
+export async function getSeatMapTicket(accessToken: string): Promise<string> {
+  const response = await fetch(`${API_URL}/booking/seatmap/ticket`, {
+    method: "POST",
+    headers: {
+      Authorization: `Bearer ${accessToken}`,
+    },
+  });
+
+  if (!response.ok) {
+    throw await apiError(response, "Failed to open the seat map");
+  }
+
+  const data: SeatMapTicketResponse = await response.json();
+  return data.ticket;
+}
+
+// Opens the seat map WebSocket to hold seats while the shopper checks out.
+// Signed-in shoppers pass their access token and hold seats as themselves;
+// userId only names the shopper when the API runs without authentication.
+// Holds end when the socket closes.
+export function openSeatMap(
+  userId: string,
+  handlers: SeatMapHandlers,
+  accessToken?: string
+) {
+  let socket: WebSocket | null = null;
+  let closed = false;
+
+  const connect = (query: string) => {
+    if (closed) return;
+    socket = new WebSocket(`${API_URL.replace(/^http/, "ws")}/booking/seatmap?${query}`);
+    socket.onmessage = (message) => {
+      const data: SeatMapMessage = JSON.parse(message.data);
+      switch (data.type) {
+        case "held":
+          if (data.hold) handlers.onHeld?.(data.hold);
+          break;
+        case "released":
+          if (data.seatNo) handlers.onReleased?.(data.seatNo);
+          break;
+        case "error":
+          handlers.onError?.(data.code ?? "", data.detail ?? "", data.seatNo);
+          break;
+      }
+    };
+  };
+
+  if (accessToken) {
+    getSeatMapTicket(accessToken)
+      .then((ticket) => connect(`ticket=${encodeURIComponent(ticket)}`))
+      .catch((err) =>
+        handlers.onError?.(err instanceof ApiError ? err.code : "UNAVAILABLE", err.message)
+      );
+  } else {
+    connect(`userId=${encodeURIComponent(userId)}`);
+  }
+
+  const send = (command: SeatMapCommand) => {
+    if (socket?.readyState === WebSocket.OPEN) {
+      socket.send(JSON.stringify(command));
+    }
+  };
+  return {
+    hold: (seatNo: number) => send({ type: "hold", seatNo }),
+    release: (seatNo: number) => send({ type: "release", seatNo }),
+    close: () => {
+      closed = true;
+      socket?.close();
+    },
+  };
+}
+
+export async function getQuote(quote: QuoteRequest): Promise<QuoteResponse> {
+  const response = await fetch(`${API_URL}/booking/quote`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify(quote),
+  });
+
+  if (!response.ok) {
+    throw await apiError(response, "Failed to fetch quote");
+  }
+
+  return response.json();
+}
+
 export async function bookTicket(
   order: Omit<
     BookingOrder,
@@ -75,7 +226,7 @@ export async function bookTicket(
 
   console.log("POST booking order data: ", bookingOrder);
 
-  const response = await fetch(`${API_BASE_URL}/booking/ticket`, {
+  const response = await fetch(`${API_URL}/booking/ticket`, {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -84,10 +235,41 @@ export async function bookTicket(
   });
 
   if (!response.ok) {
-    const errorData = await response.json().catch(() => ({}));
-    throw new Error(
-      errorData.message || `Booking failed: ${response.statusText}`
-    );
+    throw await apiError(response, "Booking failed");
+  }
+
+  return response.json();
+}
+
+export async function getBooking(bookingId: string): Promise<BookingResponse> {
+  const response = await fetch(
+    `${API_URL}/booking/${encodeURIComponent(bookingId)}`,
+    { method: "GET", headers: { "Content-Type": "application/json" } }
+  );
+
+  if (!response.ok) {
+    throw await apiError(response, "Failed to fetch booking");
+  }
+
+  return response.json();
+}
+
+export async function getUserBookings(
+  userId: string,
+  options: { status?: string; cursor?: string; limit?: number } = {}
+): Promise<UserBookingsResponse> {
+  const params = new URLSearchParams();
+  if (options.status) params.set("status", options.status);
+  if (options.cursor) params.set("cursor", options.cursor);
+  if (options.limit) params.set("limit", String(options.limit));
+
+  const response = await fetch(
+    `${API_URL}/users/${encodeURIComponent(userId)}/bookings?${params}`,
+    { method: "GET", headers: { "Content-Type": "application/json" } }
+  );
+
+  if (!response.ok) {
+    throw await apiError(response, "Failed to fetch bookings");
   }
 
   return response.json();
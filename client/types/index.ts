@@ -1,9 +1,11 @@
-// Types matching the Go backend models
+// Types matching the Go backend models; GET /openapi.json is the source of truth
 
 export type Tier = "VIP" | "FRONT_ROW" | "GA";
 
 export type BookingStatus = "PENDING" | "CONFIRMED" | "FAILED" | "CANCELED";
 
+export type SaleState = "NOT_ON_SALE" | "PRESALE" | "ON_SALE" | "ENDED";
+
 export type PaymentStatus = "PENDING" | "CONFIRMED" | "FAILED" | "CANCELED";
 
 export interface Booking {
@@ -16,6 +18,12 @@ export interface Booking {
   country: string;
   zipCode: string;
   currency: string;
+  complimentary?: boolean; // issued by an admin at zero cost
+  channel?: string; // partner reseller that sold the ticket, absent for direct sales
+  promoCode?: string;
+  discountInUSCent?: number;
+  feesInUSCent?: number;
+  taxInUSCent?: number;
   totalAmtInUSCent: number;
   paymentID: string;
   paymentStatus: PaymentStatus;
@@ -32,23 +40,90 @@ export interface BookingOrder {
   zipCode: string;
   currency: string;
   seatNo: number;
+  promoCode?: string;
+  quoteId?: string; // quoteId from availability or /booking/quote, to pay the quoted price
+  accessCode?: string; // presale access code
   // totalAmtInUSCent: number; this will be calculated on the server
   paymentID: string;
   paymentStatus: PaymentStatus;
 }
 
+export interface UserBookingsResponse {
+  success: boolean;
+  message?: string;
+  bookings: Booking[];
+  nextCursor?: string; // absent on the last page
+}
+
 export interface BookingResponse {
   success: boolean;
   message?: string;
   booking?: Booking;
 }
 
+// RFC 9457 problem details returned by every failed request
+export interface FieldError {
+  field: string;
+  code: string;
+  message: string;
+}
+
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  code: string; // stable error code, e.g. SEAT_TAKEN
+  errors?: FieldError[];
+  success: false;
+  message: string;
+}
+
 export interface TierInfo {
   tier: Tier;
   price: number; // in US cents
   totalSeats: number; // total seats for this tier (from server)
   reservedCount: number; // number of seats reserved for this tier
   availableList?: number[]; // AvailableList from server
+  saleState: SaleState;
+  saleChangesAt?: string; // when saleState next changes
+  quoteId?: string; // signed price quote, present when dynamic pricing is enabled
+  quoteExpiresAt?: string;
+}
+
+export interface QuoteRequest {
+  tier: Tier;
+  seatNo: number;
+  promoCode?: string;
+  country: string;
+  currency: string;
+}
+
+export interface PriceBreakdown {
+  baseInUSCent: number;
+  discountInUSCent: number;
+  feesInUSCent: number;
+  taxInUSCent: number;
+  totalInUSCent: number;
+  currency: string;
+  exchangeRate: number;
+  totalInCurrency: number;
+}
+
+export interface PriceQuote {
+  quoteId: string;
+  expiresAt: string;
+  tier: Tier;
+  seatNo: number;
+  promoCode?: string;
+  breakdown: PriceBreakdown;
+}
+
+export interface QuoteResponse {
+  success: boolean;
+  message?: string;
+  quote?: PriceQuote;
 }
 
 // Helper to calculate available count from totalSeats and reservedCount
@@ -62,10 +137,78 @@ export function getAvailableCount(
 export interface AvailabilityResponse {
   success: boolean;
   message?: string;
-  reservedSeats?: Record<string, number[]>;
   tiers?: TierInfo[];
 }
 
+export type SeatState = "BOOKED" | "HELD" | "RELEASED";
+
+// One seat change from the availability stream
+export interface SeatEvent {
+  id: string;
+  seatNo: number;
+  tier: Tier;
+  state: SeatState;
+  at: string;
+}
+
+// A seat kept for one shopper while they check out
+export interface SeatHold {
+  seatNo: number;
+  tier: Tier;
+  holderId: string;
+  expiresAt: string;
+}
+
+export interface SeatMapCommand {
+  type: "hold" | "release";
+  seatNo: number;
+  accessCode?: string;
+}
+
+// One-time ticket to open the seat map WebSocket with
+export interface SeatMapTicketResponse {
+  success: boolean;
+  ticket: string;
+  expiresAt: string;
+}
+
+// Message of the seat map WebSocket
+export interface SeatMapMessage {
+  type: "snapshot" | "seat" | "held" | "released" | "error";
+  id?: string;
+  availability?: AvailabilityResponse;
+  event?: SeatEvent;
+  hold?: SeatHold;
+  seatNo?: number;
+  code?: string;
+  detail?: string;
+}
+
+// Helper to apply a streamed seat change to the tiers of an availability snapshot
+export function applySeatEvent(tiers: TierInfo[], event: SeatEvent): TierInfo[] {
+  return tiers.map((tierInfo) => {
+    if (tierInfo.tier !== event.tier) return tierInfo;
+
+    const available = tierInfo.availableList ?? [];
+    const isAvailable = available.includes(event.seatNo);
+    if (event.state === "RELEASED" && !isAvailable) {
+      return {
+        ...tierInfo,
+        reservedCount: tierInfo.reservedCount - 1,
+        availableList: [...available, event.seatNo].sort((a, b) => a - b),
+      };
+    }
+    if (event.state !== "RELEASED" && isAvailable) {
+      return {
+        ...tierInfo,
+        reservedCount: tierInfo.reservedCount + 1,
+        availableList: available.filter((seat) => seat !== event.seatNo),
+      };
+    }
+    return tierInfo;
+  });
+}
+
 // Helper to get tier display name
 export function getTierDisplayNameAndColor(tier: Tier): {
   name: string;
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// APIKeyHeader carries a partner's raw API key.
+const APIKeyHeader = "X-API-Key"
+
+var ErrAPIKeyInvalid = model.NewError(model.CodeUnauthorized, "invalid API key")
+
+// KeyStore resolves raw API keys to their server-side definition.
+type KeyStore interface {
+	Authenticate(rawKey string) (model.APIKey, error)
+}
+
+// Authenticate accepts a ticket on WebSocket upgrades, a partner API key in
+// X-API-Key or, without either, falls back to the bearer JWT middleware. A nil
+// verifier lets requests without an API key through unauthenticated.
+func Authenticate(verifier *Verifier, keys KeyStore, tickets *Tickets) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		jwtHandler := next
+		if verifier != nil {
+			jwtHandler = Middleware(verifier)(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// tickets only open sockets, so a leaked one can't book
+			if ticket := r.URL.Query().Get(TicketParam); ticket != "" && tickets != nil && isWebSocketUpgrade(r) {
+				principal, ok := tickets.Redeem(ticket)
+				if !ok {
+					slog.WarnContext(r.Context(), "rejected ticket", "path", r.URL.Path)
+					respondUnauthorized(w, r, ErrTicketInvalid)
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+				return
+			}
+
+			rawKey := r.Header.Get(APIKeyHeader)
+			if rawKey == "" || keys == nil {
+				jwtHandler.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := keys.Authenticate(rawKey)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rejected API key", "path", r.URL.Path, "err", err)
+				utils.RespondError(w, r, ErrAPIKeyInvalid)
+				return
+			}
+
+			ctx := WithPrincipal(r.Context(), Principal{
+				APIKeyID: key.ID,
+				Channel:  key.Channel,
+				Scopes:   key.Scopes,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func isWebSocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// RequireScope rejects API keys that weren't granted the scope.
+func RequireScope(scope model.APIScope, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if principal, ok := PrincipalFromContext(r.Context()); ok && !principal.HasScope(scope) {
+			utils.RespondError(w, r, model.NewError(model.CodeForbidden, "forbidden: "+string(scope)+" scope required"))
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// jwk is a parsed verification key from a JWKS file.
+type jwk struct {
+	kid       string
+	alg       string // AlgRS256 or AlgEdDSA
+	publicKey crypto.PublicKey
+}
+
+type jwksFile struct {
+	Keys []struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Alg string `json:"alg"`
+		Use string `json:"use"`
+
+		// RSA
+		N string `json:"n"`
+		E string `json:"e"`
+
+		// OKP
+		Crv string `json:"crv"`
+		X   string `json:"x"`
+	} `json:"keys"`
+}
+
+// LoadJWKS reads the RSA and Ed25519 public keys of a local JWKS file.
+func LoadJWKS(path string) ([]jwk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file jwksFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make([]jwk, 0, len(file.Keys))
+	for i, key := range file.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		switch key.Kty {
+		case "RSA":
+			if key.Alg != "" && key.Alg != AlgRS256 {
+				return nil, fmt.Errorf("jwks key %d: unsupported RSA alg %q", i, key.Alg)
+			}
+			n, err := base64.RawURLEncoding.DecodeString(key.N)
+			if err != nil {
+				return nil, fmt.Errorf("jwks key %d: invalid n: %w", i, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(key.E)
+			if err != nil || len(e) == 0 || len(e) > 4 {
+				return nil, fmt.Errorf("jwks key %d: invalid e", i)
+			}
+			pub := &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+			if pub.N.BitLen() < 2048 {
+				return nil, fmt.Errorf("jwks key %d: RSA keys must be at least 2048 bits", i)
+			}
+			keys = append(keys, jwk{kid: key.Kid, alg: AlgRS256, publicKey: pub})
+
+		case "OKP":
+			if key.Crv != "Ed25519" {
+				return nil, fmt.Errorf("jwks key %d: unsupported curve %q", i, key.Crv)
+			}
+			x, err := base64.RawURLEncoding.DecodeString(key.X)
+			if err != nil || len(x) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("jwks key %d: invalid x", i)
+			}
+			keys = append(keys, jwk{kid: key.Kid, alg: AlgEdDSA, publicKey: ed25519.PublicKey(x)})
+
+		default:
+			return nil, fmt.Errorf("jwks key %d: unsupported kty %q", i, key.Kty)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks %s has no signing keys", path)
+	}
+	return keys, nil
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	ErrTokenMissing      = model.NewError(model.CodeUnauthorized, "missing bearer token")
+	ErrTokenMalformed    = model.NewError(model.CodeUnauthorized, "malformed token")
+	ErrTokenAlgorithm    = model.NewError(model.CodeUnauthorized, "unsupported token algorithm")
+	ErrTokenKeyNotFound  = model.NewError(model.CodeUnauthorized, "token signing key not found")
+	ErrTokenSignature    = model.NewError(model.CodeUnauthorized, "invalid token signature")
+	ErrTokenExpired      = model.NewError(model.CodeUnauthorized, "token expired")
+	ErrTokenNotYetValid  = model.NewError(model.CodeUnauthorized, "token not yet valid")
+	ErrTokenClaims       = model.NewError(model.CodeUnauthorized, "invalid token claims")
+	ErrTokenMissingClaim = model.NewError(model.CodeUnauthorized, "token subject is required")
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+type Config struct {
+	HS256Secret []byte // enables HS256 when set
+	JWKSPath    string // enables RS256 / EdDSA with the keys in this JWKS file
+
+	Issuer   string // checked against "iss" when set
+	Audience string // must be in "aud" when set
+
+	Leeway time.Duration // clock skew allowed on exp / nbf
+}
+
+// Enabled reports whether any verification key is configured.
+func (c Config) Enabled() bool {
+	return len(c.HS256Secret) > 0 || c.JWKSPath != ""
+}
+
+// Claims are the registered claims the server uses, plus roles.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// audience accepts both the string and the array form of "aud".
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// Verifier checks bearer JWTs against the configured keys.
+type Verifier struct {
+	cfg  Config
+	keys []jwk
+
+	now func() time.Time
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("no JWT verification key configured")
+	}
+
+	v := &Verifier{cfg: cfg, now: time.Now}
+	if cfg.JWKSPath != "" {
+		keys, err := LoadJWKS(cfg.JWKSPath)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	}
+	return v, nil
+}
+
+// Verify checks the token's signature and claims and returns its claims.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrTokenMalformed
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return Claims{}, ErrTokenMalformed
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrTokenMalformed
+	}
+
+	if err := v.verifySignature(hdr, parts[0]+"."+parts[1], signature); err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, ErrTokenMalformed
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+func (v *Verifier) verifySignature(hdr header, signingInput string, signature []byte) error {
+	switch hdr.Alg {
+	case AlgHS256:
+		if len(v.cfg.HS256Secret) == 0 {
+			return ErrTokenAlgorithm
+		}
+		mac := hmac.New(sha256.New, v.cfg.HS256Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return ErrTokenSignature
+		}
+		return nil
+
+	case AlgRS256:
+		key, err := v.findKey(hdr)
+		if err != nil {
+			return err
+		}
+		pub, ok := key.publicKey.(*rsa.PublicKey)
+		if !ok {
+			return ErrTokenKeyNotFound
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) != nil {
+			return ErrTokenSignature
+		}
+		return nil
+
+	case AlgEdDSA:
+		key, err := v.findKey(hdr)
+		if err != nil {
+			return err
+		}
+		pub, ok := key.publicKey.(ed25519.PublicKey)
+		if !ok {
+			return ErrTokenKeyNotFound
+		}
+		if !ed25519.Verify(pub, []byte(signingInput), signature) {
+			return ErrTokenSignature
+		}
+		return nil
+
+	default:
+		// includes "none"
+		return ErrTokenAlgorithm
+	}
+}
+
+// findKey picks the JWKS key for the token: by kid, or the only key for the algorithm.
+func (v *Verifier) findKey(hdr header) (jwk, error) {
+	var candidates []jwk
+	for _, key := range v.keys {
+		if key.alg != hdr.Alg {
+			continue
+		}
+		if hdr.Kid != "" && key.kid == hdr.Kid {
+			return key, nil
+		}
+		candidates = append(candidates, key)
+	}
+	if hdr.Kid == "" && len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	return jwk{}, ErrTokenKeyNotFound
+}
+
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := v.now()
+
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: exp is required", ErrTokenClaims)
+	}
+	if !now.Before(time.Unix(claims.ExpiresAt, 0).Add(v.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(v.cfg.Leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+	if v.cfg.Issuer != "" && claims.Issuer != v.cfg.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrTokenClaims)
+	}
+	if v.cfg.Audience != "" && !slices.Contains(claims.Audience, v.cfg.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrTokenClaims)
+	}
+	if claims.Subject == "" {
+		return ErrTokenMissingClaim
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var testNow = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type testKeys struct {
+	hsSecret []byte
+	rsaKey   *rsa.PrivateKey
+	edKey    ed25519.PrivateKey
+	jwksPath string
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks := map[string]any{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "alg": "RS256", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "OKP", "kid": "ed-1", "crv": "Ed25519", "x": b64(edPub)},
+		},
+	}
+	data, _ := json.Marshal(jwks)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksPath, data, 0o600); err != nil {
+		t.Fatalf("Failed to write JWKS: %v", err)
+	}
+
+	return testKeys{hsSecret: []byte("test-secret"), rsaKey: rsaKey, edKey: edKey, jwksPath: jwksPath}
+}
+
+func signToken(t *testing.T, keys testKeys, alg, kid string, claims map[string]any) string {
+	t.Helper()
+
+	hdr := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		hdr["kid"] = kid
+	}
+	h, _ := json.Marshal(hdr)
+	c, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+
+	var signature []byte
+	switch alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, keys.hsSecret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, keys.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+	case AlgEdDSA:
+		signature = ed25519.Sign(keys.edKey, []byte(signingInput))
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":   "user-123",
+		"iss":   "https://issuer.example",
+		"aud":   []string{"booking-api"},
+		"exp":   testNow.Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+	}
+}
+
+func withClaim(key string, value any) map[string]any {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier, err := NewVerifier(Config{
+		HS256Secret: keys.hsSecret,
+		JWKSPath:    keys.jwksPath,
+		Issuer:      "https://issuer.example",
+		Audience:    "booking-api",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	verifier.now = func() time.Time { return testNow }
+
+	tests := []struct {
+		name          string
+		token         string
+		expectedError error
+	}{
+		{name: "HS256", token: signToken(t, keys, AlgHS256, "", validClaims())},
+		{name: "RS256 by kid", token: signToken(t, keys, AlgRS256, "rsa-1", validClaims())},
+		{name: "RS256 without kid", token: signToken(t, keys, AlgRS256, "", validClaims())},
+		{name: "EdDSA", token: signToken(t, keys, AlgEdDSA, "ed-1", validClaims())},
+		{name: "audience as string", token: signToken(t, keys, AlgHS256, "", withClaim("aud", "booking-api"))},
+		{name: "alg none", token: signToken(t, keys, "none", "", validClaims()), expectedError: ErrTokenAlgorithm},
+		{name: "unknown kid", token: signToken(t, keys, AlgRS256, "rsa-2", validClaims()), expectedError: ErrTokenKeyNotFound},
+		{name: "malformed", token: "not-a-jwt", expectedError: ErrTokenMalformed},
+		{name: "expired", token: signToken(t, keys, AlgHS256, "", withClaim("exp", testNow.Add(-time.Minute).Unix())), expectedError: ErrTokenExpired},
+		{name: "missing exp", token: signToken(t, keys, AlgHS256, "", withClaim("exp", nil)), expectedError: ErrTokenClaims},
+		{name: "not yet valid", token: signToken(t, keys, AlgHS256, "", withClaim("nbf", testNow.Add(time.Minute).Unix())), expectedError: ErrTokenNotYetValid},
+		{name: "wrong issuer", token: signToken(t, keys, AlgHS256, "", withClaim("iss", "https://evil.example")), expectedError: ErrTokenClaims},
+		{name: "wrong audience", token: signToken(t, keys, AlgHS256, "", withClaim("aud", "other-api")), expectedError: ErrTokenClaims},
+		{name: "missing subject", token: signToken(t, keys, AlgHS256, "", withClaim("sub", nil)), expectedError: ErrTokenMissingClaim},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("Expected error '%v', got '%v'", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected valid token, got %v", err)
+			}
+			if claims.Subject != "user-123" {
+				t.Errorf("Expected subject 'user-123', got '%s'", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestVerify_TamperedToken(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier, _ := NewVerifier(Config{HS256Secret: keys.hsSecret})
+	verifier.now = func() time.Time { return testNow }
+
+	token := signToken(t, keys, AlgHS256, "", validClaims())
+	forged := signToken(t, keys, AlgHS256, "", withClaim("sub", "someone-else"))
+
+	// someone else's payload with the original signature
+	original := strings.Split(token, ".")
+	payload := strings.Split(forged, ".")[1]
+
+	if _, err := verifier.Verify(original[0] + "." + payload + "." + original[2]); !errors.Is(err, ErrTokenSignature) {
+		t.Errorf("Expected ErrTokenSignature, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier, _ := NewVerifier(Config{HS256Secret: keys.hsSecret})
+	verifier.now = func() time.Time { return testNow }
+
+	var gotSubject string
+	handler := Middleware(verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSubject, _ = Subject(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name            string
+		authorization   string
+		expectedStatus  int
+		expectedSubject string
+	}{
+		{name: "valid token", authorization: "Bearer " + signToken(t, keys, AlgHS256, "", validClaims()), expectedStatus: http.StatusOK, expectedSubject: "user-123"},
+		{name: "missing header", expectedStatus: http.StatusUnauthorized},
+		{name: "wrong scheme", authorization: "Basic dXNlcjpwYXNz", expectedStatus: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer abc.def.ghi", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSubject = ""
+			req := httptest.NewRequest(http.MethodGet, "/availability", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if gotSubject != tt.expectedSubject {
+				t.Errorf("Expected subject '%s', got '%s'", tt.expectedSubject, gotSubject)
+			}
+			if tt.expectedStatus == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	handler := RequireRole(RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name           string
+		principal      *Principal
+		expectedStatus int
+	}{
+		{name: "admin", principal: &Principal{Subject: "user-1", Roles: []string{"admin"}}, expectedStatus: http.StatusOK},
+		{name: "missing role", principal: &Principal{Subject: "user-1", Roles: []string{"customer"}}, expectedStatus: http.StatusForbidden},
+		{name: "unauthenticated", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/audit", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), *tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+type testKeyStore map[string]model.APIKey
+
+func (s testKeyStore) Authenticate(rawKey string) (model.APIKey, error) {
+	key, ok := s[rawKey]
+	if !ok {
+		return model.APIKey{}, errors.New("invalid API key")
+	}
+	return key, nil
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier, _ := NewVerifier(Config{HS256Secret: keys.hsSecret})
+	verifier.now = func() time.Time { return testNow }
+
+	apiKeys := testKeyStore{
+		"partner-secret": {ID: "boxoffice-a", Channel: "BOX_OFFICE_A", Scopes: []model.APIScope{model.ScopeAvailabilityRead}},
+	}
+
+	var got Principal
+	handler := Authenticate(verifier, apiKeys, nil)(RequireScope(model.ScopeBookingWrite, func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+	readHandler := Authenticate(verifier, apiKeys, nil)(RequireScope(model.ScopeAvailabilityRead, func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		apiKey         string
+		authorization  string
+		expectedStatus int
+		expectedKeyID  string
+	}{
+		{name: "api key with scope", handler: readHandler, apiKey: "partner-secret", expectedStatus: http.StatusOK, expectedKeyID: "boxoffice-a"},
+		{name: "api key without scope", handler: handler, apiKey: "partner-secret", expectedStatus: http.StatusForbidden},
+		{name: "unknown api key", handler: readHandler, apiKey: "nope", expectedStatus: http.StatusUnauthorized},
+		{name: "jwt user has every scope", handler: handler, authorization: "Bearer " + signToken(t, keys, AlgHS256, "", validClaims()), expectedStatus: http.StatusOK},
+		{name: "no credentials", handler: handler, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Principal{}
+			req := httptest.NewRequest(http.MethodGet, "/availability", nil)
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got.APIKeyID != tt.expectedKeyID {
+				t.Errorf("Expected API key '%s', got '%s'", tt.expectedKeyID, got.APIKeyID)
+			}
+		})
+	}
+}
+
+func TestAuthenticate_Ticket(t *testing.T) {
+	keys := newTestKeys(t)
+	verifier, _ := NewVerifier(Config{HS256Secret: keys.hsSecret})
+
+	tickets := NewTickets(30 * time.Second)
+	tickets.now = func() time.Time { return testNow }
+	valid, _ := tickets.Issue(Principal{Subject: "user-1"})
+	used, _ := tickets.Issue(Principal{Subject: "user-1"})
+	tickets.Redeem(used)
+	expired, _ := tickets.Issue(Principal{Subject: "user-1"})
+	tickets.now = func() time.Time { return testNow.Add(time.Minute) }
+	fresh, _ := tickets.Issue(Principal{Subject: "user-2"})
+
+	var got Principal
+	handler := Authenticate(verifier, testKeyStore{}, tickets)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name            string
+		ticket          string
+		upgrade         bool
+		expectedStatus  int
+		expectedSubject string
+	}{
+		{name: "expired ticket", ticket: expired, upgrade: true, expectedStatus: http.StatusUnauthorized},
+		{name: "ticket on a plain request", ticket: fresh, expectedStatus: http.StatusUnauthorized},
+		{name: "ticket on a websocket upgrade", ticket: fresh, upgrade: true, expectedStatus: http.StatusOK, expectedSubject: "user-2"},
+		{name: "ticket used twice", ticket: fresh, upgrade: true, expectedStatus: http.StatusUnauthorized},
+		{name: "ticket already redeemed", ticket: used, upgrade: true, expectedStatus: http.StatusUnauthorized},
+		{name: "unknown ticket", ticket: "nope", upgrade: true, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Principal{}
+			req := httptest.NewRequest(http.MethodGet, "/seatmap?"+TicketParam+"="+tt.ticket, nil)
+			if tt.upgrade {
+				req.Header.Set("Upgrade", "websocket")
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got.Subject != tt.expectedSubject {
+				t.Errorf("Expected subject '%s', got '%s'", tt.expectedSubject, got.Subject)
+			}
+		})
+	}
+
+	if _, ok := tickets.issued[valid]; ok {
+		t.Errorf("Expected expired tickets to be dropped when a new one is issued")
+	}
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// RoleAdmin grants access to the /admin API.
+const RoleAdmin = "admin"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Roles   []string
+
+	// Set instead of Subject when the caller is a partner API key
+	APIKeyID string
+	Channel  string
+	Scopes   []model.APIScope
+}
+
+// HasRole reports whether the principal was granted the role.
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// HasScope reports whether the principal may use a scoped route. Scopes only
+// restrict API keys; users authenticated with a JWT may use every scoped route.
+func (p Principal) HasScope(scope model.APIScope) bool {
+	if p.APIKeyID == "" {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the authenticated principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set by the middleware, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Subject returns the authenticated subject (user id) of the request, if any.
+func Subject(ctx context.Context) (string, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Subject == "" {
+		return "", false
+	}
+	return principal.Subject, true
+}
+
+// Middleware rejects requests without a valid bearer JWT and
+// injects the token's subject and roles into the request context.
+func Middleware(verifier *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, found := bearerToken(r)
+			if !found {
+				respondUnauthorized(w, r, ErrTokenMissing)
+				return
+			}
+
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rejected bearer token", "path", r.URL.Path, "err", err)
+				respondUnauthorized(w, r, err)
+				return
+			}
+
+			ctx := WithPrincipal(r.Context(), Principal{
+				Subject: claims.Subject,
+				Roles:   claims.Roles,
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRole rejects requests whose principal lacks the role. It must run after Middleware.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok {
+				respondUnauthorized(w, r, ErrTokenMissing)
+				return
+			}
+			if !principal.HasRole(role) {
+				slog.WarnContext(r.Context(), "forbidden: missing role", "path", r.URL.Path, "subject", principal.Subject, "role", role)
+				utils.RespondError(w, r, model.NewError(model.CodeForbidden, "forbidden: "+role+" role required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func respondUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	utils.RespondError(w, r, err)
+}
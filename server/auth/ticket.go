@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// TicketParam carries a ticket in the query string of a WebSocket upgrade.
+const TicketParam = "ticket"
+
+var ErrTicketInvalid = model.NewError(model.CodeUnauthorized, "invalid or expired ticket")
+
+/*
+* Tickets stand in for a principal's credentials on WebSocket upgrades.
+  - browsers can't send an Authorization header when opening a WebSocket, so
+    clients trade their credentials for a ticket and pass it as ?ticket=
+  - a ticket is redeemed once and expires after the store's TTL, so one
+    leaked through a URL log can't open a socket later
+*/
+type Tickets struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	issued map[string]issuedTicket
+	now    func() time.Time
+}
+
+type issuedTicket struct {
+	principal Principal
+	expiresAt time.Time
+}
+
+func NewTickets(ttl time.Duration) *Tickets {
+	return &Tickets{
+		ttl:    ttl,
+		issued: make(map[string]issuedTicket),
+		now:    time.Now,
+	}
+}
+
+// Issue returns a new ticket for the principal and when it expires.
+func (t *Tickets) Issue(principal Principal) (string, time.Time) {
+	var raw [24]byte
+	rand.Read(raw[:])
+	ticket := base64.RawURLEncoding.EncodeToString(raw[:])
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	// expired tickets go when new ones come, so the map stays as small as the traffic of one TTL
+	for key, issued := range t.issued {
+		if !now.Before(issued.expiresAt) {
+			delete(t.issued, key)
+		}
+	}
+	expiresAt := now.Add(t.ttl)
+	t.issued[ticket] = issuedTicket{principal: principal, expiresAt: expiresAt}
+	return ticket, expiresAt
+}
+
+// Redeem returns the principal a ticket was issued to, and forgets the ticket.
+func (t *Tickets) Redeem(ticket string) (Principal, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	issued, ok := t.issued[ticket]
+	if !ok {
+		return Principal{}, false
+	}
+	delete(t.issued, ticket)
+	if !t.now().Before(issued.expiresAt) {
+		return Principal{}, false
+	}
+	return issued.principal, true
+}
@@ -44,7 +44,7 @@ func BenchmarkEndToEndBooking(b *testing.B) {
 		UserID:         "e2e-user",
 		Tier:           model.TierVIP,
 		SeatNo:         1,
-		Country:        "USA",
+		Country:        "US",
 		ZipCode:        "10001",
 		Currency:       "USD",
 		IdempotencyKey: "e2e-key",
@@ -55,6 +55,7 @@ func BenchmarkEndToEndBooking(b *testing.B) {
 	for i := 0; b.Loop(); i++ {
 		order := bookingOrder
 		order.SeatNo = uint32(i%100 + 1)
+		order.Tier, _ = model.TierForSeat(order.SeatNo)
 		order.IdempotencyKey = "e2e-key-" + string(rune(i))
 
 		body, _ := json.Marshal(order)
@@ -102,11 +103,13 @@ func BenchmarkP95Latency(b *testing.B) {
 		go func(userID int) {
 			defer wg.Done()
 			for i := 0; i < iterations/concurrentUsers; i++ {
+				seatNo := uint32((userID*iterations/concurrentUsers+i)%100 + 1)
+				tier, _ := model.TierForSeat(seatNo)
 				order := model.BookingOrder{
 					UserID:         fmt.Sprintf("user-%d", userID),
-					Tier:           model.TierVIP,
-					SeatNo:         uint32((userID*iterations/concurrentUsers+i)%100 + 1),
-					Country:        "USA",
+					Tier:           tier,
+					SeatNo:         seatNo,
+					Country:        "US",
 					ZipCode:        "10001",
 					Currency:       "USD",
 					IdempotencyKey: fmt.Sprintf("key-%d-%d", userID, i),
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+)
+
+const auditUsage = `usage:
+  server audit verify [-file path]
+  server audit history [-file path] <booking-id>
+
+verify checks the booking audit log's hash chain; history exports a booking's
+entries with the chain's verification. The file defaults to AUDIT_LOG_FILE.
+Exit status: 0 intact, 1 broken, 2 bad usage or unreadable file.
+`
+
+// bookingExport is a booking's history as the audit command exports it.
+type bookingExport struct {
+	BookingID    uuid.UUID                 `json:"bookingId"`
+	Verification model.AuditVerification   `json:"verification"`
+	Entries      []model.BookingAuditEntry `json:"entries"`
+}
+
+/*
+* runAudit is the audit command, run as `server audit ...` instead of serving.
+  - it reads the audit log file, so it checks a stopped server's log or a copy
+    of it; a running server's log is checked with GET /admin/audit/bookings/verify
+  - a booking's history is only as trustworthy as the whole chain, so history
+    verifies the whole log too
+*/
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, auditUsage)
+		return 2
+	}
+	command := args[0]
+
+	fs := flag.NewFlagSet("audit "+command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, auditUsage) }
+	path := fs.String("file", os.Getenv("AUDIT_LOG_FILE"), "booking audit log file")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *path == "" {
+		fmt.Fprintln(stderr, "audit: no audit log file, set -file or AUDIT_LOG_FILE")
+		return 2
+	}
+
+	var bookingID uuid.UUID
+	switch {
+	case command == "verify" && fs.NArg() == 0:
+	case command == "history" && fs.NArg() == 1:
+		id, err := uuid.Parse(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "audit: invalid booking id %q\n", fs.Arg(0))
+			return 2
+		}
+		bookingID = id
+	default:
+		fmt.Fprint(stderr, auditUsage)
+		return 2
+	}
+
+	entries, err := store.ReadBookingAuditFile(*path)
+	if err != nil {
+		fmt.Fprintln(stderr, "audit:", err)
+		return 2
+	}
+	verification := store.VerifyBookingAudit(entries)
+
+	var out any = verification
+	if command == "history" {
+		export := bookingExport{BookingID: bookingID, Verification: verification, Entries: make([]model.BookingAuditEntry, 0)}
+		for _, entry := range entries {
+			if entry.BookingID == bookingID {
+				export.Entries = append(export.Entries, entry)
+			}
+		}
+		out = export
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		fmt.Fprintln(stderr, "audit:", err)
+		return 2
+	}
+	if !verification.Valid {
+		fmt.Fprintf(stderr, "audit: chain broken at entry %d: %s\n", verification.BrokenAt, verification.Problem)
+		return 1
+	}
+	return 0
+}
@@ -1,39 +1,303 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/config"
+	"github.com/ignius299792458/techkraft-ch-svr/handlers"
+	"github.com/ignius299792458/techkraft-ch-svr/logging"
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/pricing"
+	"github.com/ignius299792458/techkraft-ch-svr/router"
+	"github.com/ignius299792458/techkraft-ch-svr/sales"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
 	"github.com/ignius299792458/techkraft-ch-svr/utils"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		os.Exit(runAudit(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		out, err := cfg.Print()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(out)
+		return
+	}
+
+	// every record carries its request's ID, once the middleware set one
+	slog.SetDefault(slog.New(logging.NewHandler(os.Stderr, cfg.Log.Format, cfg.LogLevel())))
+
+	// seat ranges per tier (optional), before anything is stored
+	if path := cfg.Files.SeatLayout; path != "" {
+		layout, err := config.LoadSeatLayout(path)
+		if err == nil {
+			err = handlers.SetSeatLayout(layout)
+		}
+		if err != nil {
+			slog.Error("failed to load seat layout", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("seat layout loaded", "path", path, "seats", model.TotalSeats())
+	}
+
+	// storage backend
+	var fileStorage *store.FileStorage
+	switch cfg.Storage.Backend {
+	case config.StorageFile:
+		fileStorage, err = handlers.UseFileStorage(cfg.Storage.Path)
+		if err != nil {
+			slog.Error("failed to open file storage", "path", cfg.Storage.Path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("file storage opened", "path", cfg.Storage.Path)
+	default:
+		slog.Warn("in-memory storage, bookings are lost on restart")
+	}
+
+	// booking audit log, after the snapshot so restoring it isn't recorded again
+	var bookingAudit store.BookingAudit
+	if path := cfg.Storage.AuditLog; path != "" {
+		bookingAudit, err = handlers.UseBookingAuditFile(path)
+		if err != nil {
+			slog.Error("failed to open booking audit log", "path", path, "err", err)
+			os.Exit(1)
+		}
+		verification, err := bookingAudit.Verify()
+		if err != nil {
+			slog.Error("failed to read booking audit log", "path", path, "err", err)
+			os.Exit(1)
+		}
+		if !verification.Valid {
+			slog.Error("booking audit log broken, appending anyway", "path", path, "entry", verification.BrokenAt, "problem", verification.Problem)
+		}
+		slog.Info("booking audit log opened", "path", path, "entries", verification.Entries)
+	}
+	handlers.MarkReady(handlers.ReadyStore)
+
+	// promo codes (optional)
+	if path := cfg.Files.PromoCodes; path != "" {
+		if err := handlers.LoadPromoCodes(path); err != nil {
+			slog.Error("failed to load promo codes", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("promo codes loaded", "path", path)
+	}
+
+	// presale / general sale windows (optional)
+	if path := cfg.Files.SaleSchedule; path != "" {
+		schedule, err := sales.LoadSchedule(path)
+		if err == nil {
+			err = handlers.SetSaleSchedule(schedule)
+		}
+		if err != nil {
+			slog.Error("failed to load sale schedule", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("sale schedule loaded", "path", path)
+	}
+
+	// quote signing secret
+	if secret := cfg.Auth.QuoteSecret; secret != "" {
+		handlers.SetQuoteSecret([]byte(secret))
+	} else {
+		// quotes won't survive a restart, which is fine for short-lived quotes
+		slog.Warn("PRICING_QUOTE_SECRET not set, using a random quote secret")
+	}
+
+	// fees, tax and exchange rates (optional)
+	if path := cfg.Files.PricingCharges; path != "" {
+		c, err := pricing.LoadCharges(path)
+		if err == nil {
+			err = handlers.SetCharges(c)
+		}
+		if err != nil {
+			slog.Error("failed to load pricing charges", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("pricing charges loaded", "path", path)
+	}
+
+	// dynamic pricing (optional)
+	if path := cfg.Files.DynamicPricing; path != "" {
+		pricingCfg, err := pricing.LoadConfig(path)
+		if err != nil {
+			slog.Error("failed to load pricing config", "path", path, "err", err)
+			os.Exit(1)
+		}
+		if err := handlers.EnableDynamicPricing(pricingCfg); err != nil {
+			slog.Error("invalid pricing config", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("dynamic pricing enabled", "path", path)
+	}
+
+	// partner API keys (optional)
+	if path := cfg.Files.APIKeys; path != "" {
+		if err := handlers.LoadAPIKeys(path); err != nil {
+			slog.Error("failed to load API keys", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("API keys loaded", "path", path)
+	}
+
+	// JWT authentication
+	authCfg := auth.Config{
+		HS256Secret: []byte(cfg.Auth.JWTSecret),
+		JWKSPath:    cfg.Auth.JWKSFile,
+		Issuer:      cfg.Auth.JWTIssuer,
+		Audience:    cfg.Auth.JWTAudience,
+		Leeway:      time.Duration(cfg.Auth.JWTLeeway),
+	}
+	var verifier *auth.Verifier
+	if authCfg.Enabled() {
+		verifier, err = auth.NewVerifier(authCfg)
+		if err != nil {
+			slog.Error("failed to set up JWT authentication", "err", err)
+			os.Exit(1)
+		}
+		slog.Info("JWT authentication enabled")
+	} else {
+		slog.Warn("JWT_HS256_SECRET / JWT_JWKS_FILE not set, booking API is unauthenticated")
+	}
+
+	// rate limiting, per authenticated user or client IP
+	var rateLimitCfg utils.RateLimitConfig
+	if path := cfg.Files.RateLimits; path != "" {
+		rateLimitCfg, err = utils.LoadRateLimitConfig(path)
+		if err != nil {
+			slog.Error("failed to load rate limits", "path", path, "err", err)
+			os.Exit(1)
+		}
+		slog.Info("rate limits loaded", "path", path)
+	}
+	limiter, err := utils.NewRateLimiter(rateLimitCfg, rateLimitIdentity)
+	if err != nil {
+		slog.Error("invalid rate limits", "err", err)
+		os.Exit(1)
+	}
+
+	// every file loaded: ready once serving
+	handlers.MarkReady(handlers.ReadyConfig)
+
+	// optional routes
+	if !cfg.Features.AvailabilityStream {
+		router.DisableFeature(router.FeatureAvailabilityStream)
+	}
+	if !cfg.Features.SeatMap {
+		router.DisableFeature(router.FeatureSeatMap)
+	}
+	// browsers send no CORS preflight before a WebSocket, the socket checks the origin itself
+	handlers.SetSocketOrigins(cfg.CORS.AllowedOrigins)
+
 	mux := http.NewServeMux()
 
 	// pass to resolver
-	resolver(mux)
+	resolver(mux, verifier, limiter, cfg.Features)
+
+	// tracing (optional)
+	tracer, err := newTracer(cfg.Tracing)
+	if err != nil {
+		slog.Error("failed to start tracing", "exporter", cfg.Tracing.Exporter, "err", err)
+		os.Exit(1)
+	}
+
+	var accessLog *slog.Logger
+	if cfg.Log.Access {
+		accessLog = slog.Default()
+	}
 
-	// Wrap with CORS middleware
-	handler := utils.CORS(mux)
+	// Wrap with CORS middleware, count every request, give it an ID and log it,
+	// and trace the sampled ones; the trace goes around the log, so the access
+	// log has the trace ID
+	handler := tracing.Middleware(tracer)(logging.Middleware(accessLog)(metrics.Middleware(utils.CORS(cfg.CORS.AllowedOrigins)(mux))))
 
 	// server setup
-	const addr string = ":8080"
 	srv := &http.Server{
-		Addr:              addr,
+		Addr:              cfg.Server.Addr,
 		Handler:           handler,
-		ReadTimeout:       10 * time.Second,
-		ReadHeaderTimeout: 5 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
 	}
 
+	// stop on SIGINT / SIGTERM; a second signal kills at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Start server
-	slog.Info("server starting", "addr", addr)
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server starting", "addr", srv.Addr)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
 		slog.Error("server error", "err", err)
 		os.Exit(1)
+	case <-ctx.Done():
+		stop()
+	}
+
+	if err := shutdown(srv, fileStorage, bookingAudit, tracer, time.Duration(cfg.Server.DrainDelay), time.Duration(cfg.Server.ShutdownTimeout)); err != nil {
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// newTracer starts the configured trace exporter, or returns nil without one.
+func newTracer(cfg config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case config.TracingOTLP:
+		exporter, err = tracing.NewOTLPExporter(context.Background(), cfg.Endpoint)
+	case config.TracingFile:
+		exporter, err = tracing.OpenFileExporter(cfg.File)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("tracing", "exporter", cfg.Exporter, "sample_ratio", cfg.SampleRatio)
+	provider := tracing.NewProvider(exporter, cfg.SampleRatio, cfg.ServiceName)
+	tracing.Install(provider)
+	return provider, nil
+}
+
+// rateLimitIdentity counts authenticated requests against the user or API key rather than the IP.
+func rateLimitIdentity(r *http.Request) (string, bool) {
+	if principal, ok := auth.PrincipalFromContext(r.Context()); ok && principal.APIKeyID != "" {
+		return "key:" + principal.APIKeyID, true
 	}
+	subject, ok := auth.Subject(r.Context())
+	return "user:" + subject, ok
 }
@@ -1,15 +1,82 @@
 package main
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/config"
+	"github.com/ignius299792458/techkraft-ch-svr/handlers"
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
 	"github.com/ignius299792458/techkraft-ch-svr/router"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
 )
 
-func resolver(mux *http.ServeMux) {
+// resolver mounts every module on mux under each API version prefix (/v1, /v2).
+// The original unversioned paths stay as deprecated aliases of v1, unless switched off.
+// A nil verifier leaves the API unauthenticated (local development).
+func resolver(mux *http.ServeMux, verifier *auth.Verifier, limiter *utils.RateLimiter, features config.FeaturesConfig) {
 
-	// booking module
+	// health, readiness and status for load balancers and operators, unversioned and unauthenticated
+	router.HealthRouter(mux)
+
+	// Prometheus metrics, unauthenticated: keep /metrics off the public listener or switch it off
+	if features.Metrics {
+		mux.Handle("GET /metrics", utils.Route("GET /metrics", metrics.Default.Handler()))
+	}
+
+	// API description, generated from the route tables
+	if features.OpenAPI {
+		router.OpenAPIRouter(mux)
+	}
+
+	for _, version := range utils.APIVersions {
+		mountModules(mux, "/"+version.String(), utils.WithAPIVersion(version), verifier, limiter)
+	}
+
+	if features.LegacyRoutes {
+		since, sunset := features.LegacyDeprecation(time.Now())
+		if features.LegacyDeprecatedAt.IsZero() {
+			slog.Warn("no deprecation date set for the unversioned paths, announcing the server's start; set LEGACY_API_DEPRECATED_AT", "deprecated_at", since, "sunset", sunset)
+		}
+		legacy := utils.Deprecation{
+			Since:     since,
+			Sunset:    sunset,
+			Successor: "/" + utils.APIVersion1.String(),
+		}
+		mountModules(mux, "", func(next http.Handler) http.Handler {
+			return utils.Deprecated(legacy)(utils.WithAPIVersion(utils.APIVersion1)(next))
+		}, verifier, limiter)
+	}
+
+	if verifier == nil {
+		slog.Warn("JWT authentication disabled, admin API not mounted")
+	}
+}
+
+// mountModules mounts the booking, users and admin modules under prefix, each wrapped in version.
+// Requests refused before a route matches (401, 429, 404) are named after the module, e.g. /booking/.
+func mountModules(mux *http.ServeMux, prefix string, version func(http.Handler) http.Handler, verifier *auth.Verifier, limiter *utils.RateLimiter) {
+
+	// booking module - rate limited after authentication (JWT, partner API key or seat map ticket), so limits follow the caller
 	bookingMux := http.NewServeMux()
 	router.BookingRouter(bookingMux)
-	mux.Handle("/booking/", http.StripPrefix("/booking", bookingMux))
+	bookingHandler := auth.Authenticate(verifier, handlers.APIKeys(), handlers.SeatMapTickets())(limiter.Middleware(bookingMux))
+	mux.Handle(prefix+"/booking/", utils.Route("/booking/", version(http.StripPrefix(prefix+"/booking", bookingHandler))))
+
+	// users module - same authentication and limits as booking
+	usersMux := http.NewServeMux()
+	router.UsersRouter(usersMux)
+	usersHandler := auth.Authenticate(verifier, handlers.APIKeys(), nil)(limiter.Middleware(usersMux))
+	mux.Handle(prefix+"/users/", utils.Route("/users/", version(http.StripPrefix(prefix+"/users", usersHandler))))
+
+	// admin module - never served without authentication
+	if verifier == nil {
+		return
+	}
+	adminMux := http.NewServeMux()
+	router.AdminRouter(adminMux)
+	adminHandler := auth.Middleware(verifier)(auth.RequireRole(auth.RoleAdmin)(adminMux))
+	mux.Handle(prefix+"/admin/", utils.Route("/admin/", version(http.StripPrefix(prefix+"/admin", adminHandler))))
 }
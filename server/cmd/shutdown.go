@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/handlers"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// how long the spans left get to export on shutdown
+const traceShutdownTimeout = 5 * time.Second
+
+/*
+* shutdown drains the server once a stop signal arrives:
+  - readiness turns false and streams end, clients resuming them elsewhere
+  - after drainDelay, long enough for load balancers to see it, new
+    connections are refused
+  - in-flight requests get until timeout to finish, so a booking is never cut
+    between its seat and its idempotency record; past it connections are closed
+  - the file storage saves a last time, the booking audit log is synced, and
+    the spans of the last requests are exported
+*/
+func shutdown(srv *http.Server, storage *store.FileStorage, bookingAudit store.BookingAudit, tracer *sdktrace.TracerProvider, drainDelay, timeout time.Duration) error {
+	handlers.Drain()
+	slog.Info("draining", "drain_delay", drainDelay, "timeout", timeout)
+	time.Sleep(drainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("in-flight requests did not finish in time, closing connections", "err", err)
+		srv.Close()
+		errs = append(errs, err)
+	}
+	if err := handlers.WaitStreams(ctx); err != nil {
+		slog.Warn("seat map sockets did not close in time", "err", err)
+		errs = append(errs, err)
+	}
+
+	// flushed last, after every request that could change a seat
+	if storage != nil {
+		if err := storage.Close(); err != nil {
+			slog.Error("failed to save snapshot", "err", err)
+			errs = append(errs, err)
+		}
+	}
+	if bookingAudit != nil {
+		if err := bookingAudit.Close(); err != nil {
+			slog.Error("failed to close booking audit log", "err", err)
+			errs = append(errs, err)
+		}
+	}
+
+	// with its own timeout: requests may have used all of theirs
+	if tracer != nil {
+		traceCtx, cancel := context.WithTimeout(context.Background(), traceShutdownTimeout)
+		defer cancel()
+		if err := tracer.Shutdown(traceCtx); err != nil {
+			slog.Warn("failed to export the last spans", "err", err)
+		}
+	}
+	return errors.Join(errs...)
+}
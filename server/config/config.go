@@ -0,0 +1,355 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+/*
+* Config is the server's runtime configuration.
+  - every setting has a flag and an environment variable, and a field of the
+    JSON config file (-config / CONFIG_FILE)
+  - precedence: flags > environment > config file > defaults
+  - optional data files (promo codes, sale schedule, ...) are given by path;
+    an empty path leaves the feature off
+*/
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	CORS     CORSConfig     `json:"cors"`
+	Storage  StorageConfig  `json:"storage"`
+	Files    FilesConfig    `json:"files"`
+	Auth     AuthConfig     `json:"auth"`
+	Log      LogConfig      `json:"log"`
+	Tracing  TracingConfig  `json:"tracing"`
+	Features FeaturesConfig `json:"features"`
+
+	// set by -print-config: print the configuration and exit
+	PrintConfig bool `json:"-"`
+}
+
+type ServerConfig struct {
+	Addr              string   `json:"addr"`
+	ReadTimeout       Duration `json:"readTimeout"`
+	ReadHeaderTimeout Duration `json:"readHeaderTimeout"`
+	WriteTimeout      Duration `json:"writeTimeout"`
+	IdleTimeout       Duration `json:"idleTimeout"`
+
+	// on SIGINT/SIGTERM: report not ready for DrainDelay while still serving,
+	// then give in-flight requests ShutdownTimeout to finish
+	DrainDelay      Duration `json:"drainDelay"`
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+}
+
+type CORSConfig struct {
+	// origins browsers may call the API from, or "*" for any
+	AllowedOrigins []string `json:"allowedOrigins"`
+}
+
+// Storage backends
+const (
+	StorageMemory = "memory" // lost on restart
+	StorageFile   = "file"   // snapshot file at Path, restored on start
+)
+
+type StorageConfig struct {
+	Backend string `json:"backend"`
+	Path    string `json:"path,omitempty"`
+
+	// booking audit log file, appended to; in memory when empty
+	AuditLog string `json:"auditLog,omitempty"`
+}
+
+// FilesConfig holds the paths of the optional JSON data files.
+type FilesConfig struct {
+	SeatLayout     string `json:"seatLayout,omitempty"`
+	PromoCodes     string `json:"promoCodes,omitempty"`
+	SaleSchedule   string `json:"saleSchedule,omitempty"`
+	PricingCharges string `json:"pricingCharges,omitempty"`
+	DynamicPricing string `json:"dynamicPricing,omitempty"`
+	APIKeys        string `json:"apiKeys,omitempty"`
+	RateLimits     string `json:"rateLimits,omitempty"`
+}
+
+type AuthConfig struct {
+	// JWT verification; no secret and no JWKS leaves the booking API unauthenticated
+	JWTSecret   Secret   `json:"jwtSecret,omitempty"`
+	JWKSFile    string   `json:"jwksFile,omitempty"`
+	JWTIssuer   string   `json:"jwtIssuer,omitempty"`
+	JWTAudience string   `json:"jwtAudience,omitempty"`
+	JWTLeeway   Duration `json:"jwtLeeway"`
+
+	// signs price quotes; random when empty, so quotes don't survive a restart
+	QuoteSecret Secret `json:"quoteSecret,omitempty"`
+}
+
+type LogConfig struct {
+	Level  string `json:"level"`  // debug, info, warn or error
+	Format string `json:"format"` // text or json
+	Access bool   `json:"access"` // a line per request
+}
+
+// Trace exporters
+const (
+	TracingNone = "none" // no tracing
+	TracingOTLP = "otlp" // OTLP/HTTP to a collector at Endpoint
+	TracingFile = "file" // JSON lines to File, "-" for stdout
+)
+
+type TracingConfig struct {
+	Exporter    string  `json:"exporter"`
+	Endpoint    string  `json:"endpoint,omitempty"`
+	File        string  `json:"file,omitempty"`
+	ServiceName string  `json:"serviceName"`
+	SampleRatio float64 `json:"sampleRatio"` // of traces started here; callers' decisions are followed
+}
+
+// FeaturesConfig switches optional parts of the API.
+type FeaturesConfig struct {
+	AvailabilityStream bool `json:"availabilityStream"`
+	SeatMap            bool `json:"seatMap"`
+	OpenAPI            bool `json:"openapi"`
+	Metrics            bool `json:"metrics"`
+
+	// unversioned paths, deprecated aliases of /v1: deprecated since
+	// LegacyDeprecatedAt, gone after LegacySunset; see LegacyDeprecation
+	LegacyRoutes       bool      `json:"legacyRoutes"`
+	LegacyDeprecatedAt time.Time `json:"legacyDeprecatedAt,omitzero"`
+	LegacySunset       time.Time `json:"legacySunset,omitzero"`
+}
+
+// LegacyDeprecation returns when the unversioned paths were deprecated and when
+// they go away. Unset, the deprecation is start, the time the server started,
+// and the sunset six months after the deprecation.
+func (f FeaturesConfig) LegacyDeprecation(start time.Time) (since, sunset time.Time) {
+	since, sunset = f.LegacyDeprecatedAt, f.LegacySunset
+	if since.IsZero() {
+		since = start.UTC().Truncate(time.Second)
+	}
+	if sunset.IsZero() {
+		sunset = since.AddDate(0, 6, 0)
+	}
+	return since, sunset
+}
+
+// Default returns the configuration used when nothing is set.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(10 * time.Second),
+			ReadHeaderTimeout: Duration(5 * time.Second),
+			WriteTimeout:      Duration(15 * time.Second),
+			IdleTimeout:       Duration(60 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+		},
+		CORS:    CORSConfig{AllowedOrigins: []string{"*"}},
+		Storage: StorageConfig{Backend: StorageMemory},
+		Auth:    AuthConfig{JWTLeeway: Duration(30 * time.Second)},
+		Log:     LogConfig{Level: "info", Format: "text", Access: true},
+		Tracing: TracingConfig{
+			Exporter:    TracingNone,
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "techkraft-ch-svr",
+			SampleRatio: 1,
+		},
+		Features: FeaturesConfig{
+			AvailabilityStream: true,
+			SeatMap:            true,
+			OpenAPI:            true,
+			Metrics:            true,
+			LegacyRoutes:       true,
+		},
+	}
+}
+
+// LoadFile reads a JSON config file over cfg; settings it leaves out keep their value.
+func LoadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr: %v", err)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value Duration
+	}{
+		{"readTimeout", c.Server.ReadTimeout},
+		{"readHeaderTimeout", c.Server.ReadHeaderTimeout},
+		{"writeTimeout", c.Server.WriteTimeout},
+		{"idleTimeout", c.Server.IdleTimeout},
+		{"drainDelay", c.Server.DrainDelay},
+		{"shutdownTimeout", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value < 0 {
+			invalid("server.%s: must not be negative", timeout.name)
+		}
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		invalid("cors.allowedOrigins: want at least one origin, or \"*\"")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if err := validateOrigin(origin); err != nil {
+			invalid("cors.allowedOrigins: %q: %v", origin, err)
+		}
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StorageFile:
+		if c.Storage.Path == "" {
+			invalid("storage.path: required by the %q backend", StorageFile)
+		}
+	default:
+		invalid("storage.backend: want %q or %q, got %q", StorageMemory, StorageFile, c.Storage.Backend)
+	}
+
+	if c.Auth.JWTLeeway < 0 {
+		invalid("auth.jwtLeeway: must not be negative")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		invalid("log.level: want debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		invalid("log.format: want text or json, got %q", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case TracingNone:
+	case TracingOTLP:
+		if u, err := url.Parse(c.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("tracing.endpoint: want http(s)://host[:port], got %q", c.Tracing.Endpoint)
+		}
+	case TracingFile:
+		if c.Tracing.File == "" {
+			invalid("tracing.file: required by the %q exporter", TracingFile)
+		}
+	default:
+		invalid("tracing.exporter: want %q, %q or %q, got %q", TracingNone, TracingOTLP, TracingFile, c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sampleRatio: want 0 to 1, got %v", c.Tracing.SampleRatio)
+	}
+
+	deprecatedAt, sunset := c.Features.LegacyDeprecatedAt, c.Features.LegacySunset
+	if !deprecatedAt.IsZero() && !sunset.IsZero() && !sunset.After(deprecatedAt) {
+		invalid("features.legacySunset: must be after legacyDeprecatedAt (%s), got %s", deprecatedAt.Format(time.RFC3339), sunset.Format(time.RFC3339))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOrigin accepts "*" or a bare origin, e.g. https://tickets.example.com:8443.
+func validateOrigin(origin string) error {
+	if origin == "*" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("want scheme://host[:port]")
+	}
+	if u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return errors.New("want an origin, without path or query")
+	}
+	return nil
+}
+
+// LogLevel returns the configured log level; call it on a validated config.
+func (c Config) LogLevel() slog.Level {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.Log.Level))
+	return level
+}
+
+// LoadSeatLayout reads a seat layout, {"VIP": {"min": 1, "max": 30}, ...}, from a JSON file.
+func LoadSeatLayout(path string) (model.SeatLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var layout model.SeatLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, fmt.Errorf("parse seat layout: %w", err)
+	}
+	return layout, layout.Validate()
+}
+
+// Duration is a time.Duration written as "10s" in the config file.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Secret is a setting -print-config must not show.
+type Secret string
+
+func (s Secret) MarshalText() ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return []byte("REDACTED"), nil
+}
+
+// Print writes the configuration as indented JSON, secrets redacted.
+func (c Config) Print() ([]byte, error) {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// splitList parses a comma-separated list setting.
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
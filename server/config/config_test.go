@@ -0,0 +1,354 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(nil, env(nil), io.Discard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Server.Addr != ":8080" {
+		t.Errorf("Expected addr :8080, got %s", cfg.Server.Addr)
+	}
+	if time.Duration(cfg.Server.WriteTimeout) != 15*time.Second {
+		t.Errorf("Expected write timeout 15s, got %v", time.Duration(cfg.Server.WriteTimeout))
+	}
+	if len(cfg.CORS.AllowedOrigins) != 1 || cfg.CORS.AllowedOrigins[0] != "*" {
+		t.Errorf("Expected CORS origins [*], got %v", cfg.CORS.AllowedOrigins)
+	}
+	if cfg.Storage.Backend != StorageMemory {
+		t.Errorf("Expected storage %s, got %s", StorageMemory, cfg.Storage.Backend)
+	}
+	if cfg.Log.Format != "text" || !cfg.Log.Access {
+		t.Errorf("Expected text logs with an access log, got %+v", cfg.Log)
+	}
+	if !cfg.Features.SeatMap || !cfg.Features.LegacyRoutes {
+		t.Errorf("Expected optional features on by default, got %+v", cfg.Features)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	configFile := writeFile(t, "config.json", `{
+		"server": {"addr": ":7000", "readTimeout": "3s", "idleTimeout": "2m"},
+		"cors": {"allowedOrigins": ["https://file.example"]},
+		"log": {"level": "warn"},
+		"tracing": {"exporter": "file", "file": "-"}
+	}`)
+
+	cfg, err := Load(
+		[]string{"-config", configFile, "-addr", ":9000", "-seat-map=false"},
+		env(map[string]string{
+			"LISTEN_ADDR":          ":8000",
+			"READ_TIMEOUT":         "4s",
+			"CORS_ALLOWED_ORIGINS": "https://env.example, http://localhost:3000",
+			"FEATURE_SEAT_MAP":     "true",
+			"TRACING_SAMPLE_RATIO": "0.25",
+		}),
+		io.Discard,
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"flag over env over file", cfg.Server.Addr, ":9000"},
+		{"env over file", time.Duration(cfg.Server.ReadTimeout), 4 * time.Second},
+		{"file over default", time.Duration(cfg.Server.IdleTimeout), 2 * time.Minute},
+		{"default", time.Duration(cfg.Server.WriteTimeout), 15 * time.Second},
+		{"file only", cfg.Log.Level, "warn"},
+		{"env list", strings.Join(cfg.CORS.AllowedOrigins, ","), "https://env.example,http://localhost:3000"},
+		{"bool flag over env", cfg.Features.SeatMap, false},
+		{"file section", cfg.Tracing.Exporter, TracingFile},
+		{"env float", cfg.Tracing.SampleRatio, 0.25},
+		{"default in file section", cfg.Tracing.ServiceName, "techkraft-ch-svr"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, tt.got)
+		}
+	}
+}
+
+func TestLoad_ConfigFileFromEnv(t *testing.T) {
+	configFile := writeFile(t, "config.json", `{"storage": {"backend": "file", "path": "/tmp/bookings.json"}}`)
+
+	cfg, err := Load(nil, env(map[string]string{"CONFIG_FILE": configFile}), io.Discard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Storage.Backend != StorageFile || cfg.Storage.Path != "/tmp/bookings.json" {
+		t.Errorf("Expected file storage at /tmp/bookings.json, got %+v", cfg.Storage)
+	}
+}
+
+func TestLoad_BoolFlagWithoutValue(t *testing.T) {
+	cfg, err := Load([]string{"-seat-map", "-print-config"}, env(map[string]string{"FEATURE_SEAT_MAP": "false"}), io.Discard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !cfg.Features.SeatMap {
+		t.Errorf("Expected -seat-map to switch the seat map on")
+	}
+	if !cfg.PrintConfig {
+		t.Errorf("Expected -print-config to be set")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		file    string
+		wantErr []string
+	}{
+		{
+			name:    "unknown flag",
+			args:    []string{"-port", "80"},
+			wantErr: []string{"flag provided but not defined"},
+		},
+		{
+			name:    "bad env duration",
+			env:     map[string]string{"WRITE_TIMEOUT": "soon"},
+			wantErr: []string{"WRITE_TIMEOUT"},
+		},
+		{
+			name:    "bad flag bool",
+			args:    []string{"-openapi=maybe"},
+			wantErr: []string{"-openapi"},
+		},
+		{
+			name:    "unknown file field",
+			file:    `{"server": {"port": 80}}`,
+			wantErr: []string{"unknown field"},
+		},
+		{
+			name:    "bad sunset",
+			env:     map[string]string{"LEGACY_API_SUNSET": "next year"},
+			wantErr: []string{"RFC 3339"},
+		},
+		{
+			name:    "sunset before deprecation",
+			args:    []string{"-legacy-deprecated-at", "2027-01-01T00:00:00Z", "-legacy-sunset", "2026-12-01T00:00:00Z"},
+			wantErr: []string{"features.legacySunset"},
+		},
+		{
+			name: "every invalid setting reported",
+			args: []string{"-addr", "8080", "-storage", "file", "-log-level", "loud", "-cors-origins", "tickets.example", "-idle-timeout", "-1s"},
+			wantErr: []string{
+				"server.addr", "server.idleTimeout", "storage.path", "log.level", "cors.allowedOrigins",
+			},
+		},
+		{
+			name:    "unknown storage backend",
+			args:    []string{"-storage", "postgres"},
+			wantErr: []string{"storage.backend"},
+		},
+		{
+			name:    "unknown log format",
+			env:     map[string]string{"LOG_FORMAT": "logfmt"},
+			wantErr: []string{"log.format"},
+		},
+		{
+			name:    "unknown trace exporter",
+			args:    []string{"-tracing", "jaeger"},
+			wantErr: []string{"tracing.exporter"},
+		},
+		{
+			name:    "trace file required",
+			env:     map[string]string{"TRACING_EXPORTER": "file"},
+			wantErr: []string{"tracing.file"},
+		},
+		{
+			name:    "bad OTLP endpoint",
+			args:    []string{"-tracing", "otlp", "-otlp-endpoint", "collector:4318"},
+			wantErr: []string{"tracing.endpoint"},
+		},
+		{
+			name:    "sample ratio out of range",
+			env:     map[string]string{"TRACING_SAMPLE_RATIO": "1.5"},
+			wantErr: []string{"tracing.sampleRatio"},
+		},
+		{
+			name:    "bad sample ratio",
+			args:    []string{"-tracing-sample-ratio", "half"},
+			wantErr: []string{"-tracing-sample-ratio", "want a number"},
+		},
+		{
+			name:    "no CORS origin",
+			args:    []string{"-cors-origins", " , "},
+			wantErr: []string{"want at least one origin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, "config.json", tt.file)}, args...)
+			}
+
+			_, err := Load(args, env(tt.env), io.Discard)
+			if err == nil {
+				t.Fatalf("Expected an error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error mentioning %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_Help(t *testing.T) {
+	var usage strings.Builder
+	_, err := Load([]string{"-h"}, env(nil), &usage)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("Expected flag.ErrHelp, got %v", err)
+	}
+	if !strings.Contains(usage.String(), "env LISTEN_ADDR") {
+		t.Errorf("Expected usage to name environment variables, got %s", usage.String())
+	}
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	cfg, err := Load(nil, env(map[string]string{
+		"JWT_HS256_SECRET":     "jwt-secret-value",
+		"PRICING_QUOTE_SECRET": "quote-secret-value",
+	}), io.Discard)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	out, err := cfg.Print()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if strings.Contains(string(out), "secret-value") {
+		t.Errorf("Expected secrets redacted, got %s", out)
+	}
+	if !strings.Contains(string(out), `"jwtSecret": "REDACTED"`) {
+		t.Errorf("Expected a redacted jwtSecret, got %s", out)
+	}
+
+	// the printed config loads back to the same settings, secrets aside
+	reloaded := Default()
+	if err := LoadFile(&reloaded, writeFile(t, "printed.json", string(out))); err != nil {
+		t.Fatalf("Expected printed config to load, got %v", err)
+	}
+	if reloaded.Server != cfg.Server || reloaded.Features != cfg.Features {
+		t.Errorf("Expected printed config to round-trip, got %+v", reloaded)
+	}
+}
+
+func TestLoadSeatLayout(t *testing.T) {
+	tests := []struct {
+		name    string
+		layout  string
+		wantErr string
+		want    uint32
+	}{
+		{
+			name:   "valid",
+			layout: `{"VIP": {"min": 1, "max": 10}, "FRONT_ROW": {"min": 11, "max": 50}, "GA": {"min": 51, "max": 500}}`,
+			want:   500,
+		},
+		{
+			name:    "missing tier",
+			layout:  `{"VIP": {"min": 1, "max": 10}, "GA": {"min": 11, "max": 50}}`,
+			wantErr: "FRONT_ROW: missing",
+		},
+		{
+			name:    "unknown tier",
+			layout:  `{"VIP": {"min": 1, "max": 10}, "FRONT_ROW": {"min": 11, "max": 20}, "GA": {"min": 21, "max": 30}, "BOX": {"min": 31, "max": 40}}`,
+			wantErr: "invalid tier",
+		},
+		{
+			name:    "overlap",
+			layout:  `{"VIP": {"min": 1, "max": 10}, "FRONT_ROW": {"min": 10, "max": 20}, "GA": {"min": 21, "max": 30}}`,
+			wantErr: "overlap",
+		},
+		{
+			name:    "seat zero",
+			layout:  `{"VIP": {"min": 0, "max": 10}, "FRONT_ROW": {"min": 11, "max": 20}, "GA": {"min": 21, "max": 30}}`,
+			wantErr: "want 1 <= min <= max",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := LoadSeatLayout(writeFile(t, "layout.json", tt.layout))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if layout.TotalSeats() != tt.want {
+				t.Errorf("Expected %d seats, got %d", tt.want, layout.TotalSeats())
+			}
+			if layout[model.TierGA].Min != 51 {
+				t.Errorf("Expected GA from seat 51, got %d", layout[model.TierGA].Min)
+			}
+		})
+	}
+}
+
+func TestFeaturesConfig_LegacyDeprecation(t *testing.T) {
+	start := time.Date(2027, time.March, 2, 9, 30, 0, 0, time.UTC)
+	deprecatedAt := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		features       FeaturesConfig
+		expectedSince  time.Time
+		expectedSunset time.Time
+	}{
+		{name: "unset", expectedSince: start, expectedSunset: start.AddDate(0, 6, 0)},
+		{name: "deprecation set", features: FeaturesConfig{LegacyDeprecatedAt: deprecatedAt}, expectedSince: deprecatedAt, expectedSunset: deprecatedAt.AddDate(0, 6, 0)},
+		{name: "both set", features: FeaturesConfig{LegacyDeprecatedAt: deprecatedAt, LegacySunset: sunset}, expectedSince: deprecatedAt, expectedSunset: sunset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, sunset := tt.features.LegacyDeprecation(start)
+			if !since.Equal(tt.expectedSince) || !sunset.Equal(tt.expectedSunset) {
+				t.Errorf("Expected %v to %v, got %v to %v", tt.expectedSince, tt.expectedSunset, since, sunset)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// setting is one configurable value: its flag, its environment variable, and
+// how to set it on a Config from text.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, value string) error
+	bool  bool // takes no value on the command line: -flag means -flag=true
+}
+
+func stringSetting(flag, env, usage string, field func(c *Config) *string) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}}
+}
+
+func secretSetting(flag, env, usage string, field func(c *Config) *Secret) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = Secret(value)
+		return nil
+	}}
+}
+
+func durationSetting(flag, env, usage string, field func(c *Config) *Duration) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		return field(c).UnmarshalText([]byte(value))
+	}}
+}
+
+func boolSetting(flag, env, usage string, field func(c *Config) *bool) setting {
+	return setting{flag: flag, env: env, usage: usage, bool: true, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = parsed
+		return nil
+	}}
+}
+
+func floatSetting(flag, env, usage string, field func(c *Config) *float64) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.New("want a number")
+		}
+		*field(c) = parsed
+		return nil
+	}}
+}
+
+func listSetting(flag, env, usage string, field func(c *Config) *[]string) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		*field(c) = splitList(value)
+		return nil
+	}}
+}
+
+func timeSetting(flag, env, usage string, field func(c *Config) *time.Time) setting {
+	return setting{flag: flag, env: env, usage: usage, set: func(c *Config, value string) error {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("want an RFC 3339 time")
+		}
+		*field(c) = parsed
+		return nil
+	}}
+}
+
+// settings lists every setting. Environment variables the server read before
+// it had a config file keep their names.
+var settings = []setting{
+	stringSetting("addr", "LISTEN_ADDR", "listen address", func(c *Config) *string { return &c.Server.Addr }),
+	durationSetting("read-timeout", "READ_TIMEOUT", "max duration of reading a request", func(c *Config) *Duration { return &c.Server.ReadTimeout }),
+	durationSetting("read-header-timeout", "READ_HEADER_TIMEOUT", "max duration of reading request headers", func(c *Config) *Duration { return &c.Server.ReadHeaderTimeout }),
+	durationSetting("write-timeout", "WRITE_TIMEOUT", "max duration of writing a response (streams excepted)", func(c *Config) *Duration { return &c.Server.WriteTimeout }),
+	durationSetting("idle-timeout", "IDLE_TIMEOUT", "how long idle keep-alive connections stay open", func(c *Config) *Duration { return &c.Server.IdleTimeout }),
+	durationSetting("drain-delay", "DRAIN_DELAY", "how long to report not ready before refusing connections on shutdown", func(c *Config) *Duration { return &c.Server.DrainDelay }),
+	durationSetting("shutdown-timeout", "SHUTDOWN_TIMEOUT", "how long in-flight requests get to finish on shutdown", func(c *Config) *Duration { return &c.Server.ShutdownTimeout }),
+
+	listSetting("cors-origins", "CORS_ALLOWED_ORIGINS", "comma-separated origins allowed to call the API, or *", func(c *Config) *[]string { return &c.CORS.AllowedOrigins }),
+
+	stringSetting("storage", "STORAGE_BACKEND", "storage backend: memory or file", func(c *Config) *string { return &c.Storage.Backend }),
+	stringSetting("storage-path", "STORAGE_PATH", "snapshot file of the file storage backend", func(c *Config) *string { return &c.Storage.Path }),
+	stringSetting("audit-log", "AUDIT_LOG_FILE", "booking audit log file (JSON lines), kept in memory when unset", func(c *Config) *string { return &c.Storage.AuditLog }),
+
+	stringSetting("seat-layout", "SEAT_LAYOUT_FILE", "seat ranges per tier (JSON)", func(c *Config) *string { return &c.Files.SeatLayout }),
+	stringSetting("promo-codes", "PROMO_CODES_FILE", "promo codes (JSON)", func(c *Config) *string { return &c.Files.PromoCodes }),
+	stringSetting("sale-schedule", "SALE_SCHEDULE_FILE", "presale / general sale windows (JSON)", func(c *Config) *string { return &c.Files.SaleSchedule }),
+	stringSetting("pricing-charges", "PRICING_CHARGES_FILE", "fees, tax and exchange rates (JSON)", func(c *Config) *string { return &c.Files.PricingCharges }),
+	stringSetting("dynamic-pricing", "DYNAMIC_PRICING_FILE", "dynamic pricing config (JSON), enables dynamic pricing", func(c *Config) *string { return &c.Files.DynamicPricing }),
+	stringSetting("api-keys", "API_KEYS_FILE", "partner API keys (JSON)", func(c *Config) *string { return &c.Files.APIKeys }),
+	stringSetting("rate-limits", "RATE_LIMIT_FILE", "rate limits (JSON)", func(c *Config) *string { return &c.Files.RateLimits }),
+
+	secretSetting("jwt-secret", "JWT_HS256_SECRET", "HS256 secret of JWTs (prefer the environment, flags show in ps)", func(c *Config) *Secret { return &c.Auth.JWTSecret }),
+	stringSetting("jwks", "JWT_JWKS_FILE", "JWKS file of JWT public keys", func(c *Config) *string { return &c.Auth.JWKSFile }),
+	stringSetting("jwt-issuer", "JWT_ISSUER", "required JWT issuer", func(c *Config) *string { return &c.Auth.JWTIssuer }),
+	stringSetting("jwt-audience", "JWT_AUDIENCE", "required JWT audience", func(c *Config) *string { return &c.Auth.JWTAudience }),
+	durationSetting("jwt-leeway", "JWT_LEEWAY", "clock skew allowed on JWT times", func(c *Config) *Duration { return &c.Auth.JWTLeeway }),
+	secretSetting("quote-secret", "PRICING_QUOTE_SECRET", "secret signing price quotes (prefer the environment)", func(c *Config) *Secret { return &c.Auth.QuoteSecret }),
+
+	stringSetting("log-level", "LOG_LEVEL", "log level: debug, info, warn or error", func(c *Config) *string { return &c.Log.Level }),
+	stringSetting("log-format", "LOG_FORMAT", "log format: text or json", func(c *Config) *string { return &c.Log.Format }),
+	boolSetting("access-log", "LOG_ACCESS", "log a line per request", func(c *Config) *bool { return &c.Log.Access }),
+
+	stringSetting("tracing", "TRACING_EXPORTER", "trace exporter: none, otlp or file", func(c *Config) *string { return &c.Tracing.Exporter }),
+	stringSetting("otlp-endpoint", "OTEL_EXPORTER_OTLP_ENDPOINT", "OTLP/HTTP collector of the otlp trace exporter", func(c *Config) *string { return &c.Tracing.Endpoint }),
+	stringSetting("tracing-file", "TRACING_FILE", "file of the file trace exporter, - for stdout", func(c *Config) *string { return &c.Tracing.File }),
+	stringSetting("service-name", "OTEL_SERVICE_NAME", "service name traces are reported under", func(c *Config) *string { return &c.Tracing.ServiceName }),
+	floatSetting("tracing-sample-ratio", "TRACING_SAMPLE_RATIO", "share of requests traced, 0 to 1, unless the caller decided", func(c *Config) *float64 { return &c.Tracing.SampleRatio }),
+
+	boolSetting("availability-stream", "FEATURE_AVAILABILITY_STREAM", "serve the availability event stream", func(c *Config) *bool { return &c.Features.AvailabilityStream }),
+	boolSetting("seat-map", "FEATURE_SEAT_MAP", "serve the seat map WebSocket", func(c *Config) *bool { return &c.Features.SeatMap }),
+	boolSetting("openapi", "FEATURE_OPENAPI", "serve the OpenAPI documents", func(c *Config) *bool { return &c.Features.OpenAPI }),
+	boolSetting("metrics", "FEATURE_METRICS", "serve Prometheus metrics at /metrics", func(c *Config) *bool { return &c.Features.Metrics }),
+	boolSetting("legacy-routes", "FEATURE_LEGACY_ROUTES", "serve the unversioned paths as deprecated aliases of /v1", func(c *Config) *bool { return &c.Features.LegacyRoutes }),
+	timeSetting("legacy-deprecated-at", "LEGACY_API_DEPRECATED_AT", "when the unversioned paths were deprecated (RFC 3339), the server's start when unset", func(c *Config) *time.Time { return &c.Features.LegacyDeprecatedAt }),
+	timeSetting("legacy-sunset", "LEGACY_API_SUNSET", "sunset of the unversioned paths (RFC 3339), six months after their deprecation when unset", func(c *Config) *time.Time { return &c.Features.LegacySunset }),
+}
+
+/*
+  - Load builds the configuration from defaults, the config file, the
+    environment (getenv) and the command line (args, without the program name),
+    each overriding the ones before, and validates it.
+  - the config file is -config, else CONFIG_FILE
+  - flags are parsed first, so a bad flag fails before any file is read
+  - flag.ErrHelp is returned for -h, after usage was written to output
+*/
+func Load(args []string, getenv func(string) string, output io.Writer) (Config, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	var configFile string
+	cfg := Default()
+	fs.StringVar(&configFile, "config", "", "JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", false, "print the configuration, secrets redacted, and exit")
+
+	flags := make(map[string]string)
+	for _, s := range settings {
+		fs.Var(flagValue{name: s.flag, flags: flags, bool: s.bool}, s.flag, fmt.Sprintf("%s (env %s)", s.usage, s.env))
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if configFile == "" {
+		configFile = getenv("CONFIG_FILE")
+	}
+	if configFile != "" {
+		if err := LoadFile(&cfg, configFile); err != nil {
+			return Config{}, fmt.Errorf("config file %s: %w", configFile, err)
+		}
+	}
+
+	for _, s := range settings {
+		if value := getenv(s.env); value != "" {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, fmt.Errorf("%s=%q: %w", s.env, value, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, set := flags[s.flag]; set {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, fmt.Errorf("-%s=%q: %w", s.flag, value, err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// flagValue records a setting's flag, applied once the file and environment are.
+type flagValue struct {
+	name  string
+	flags map[string]string
+	bool  bool
+}
+
+func (v flagValue) String() string { return "" }
+
+func (v flagValue) Set(value string) error {
+	v.flags[v.name] = value
+	return nil
+}
+
+// IsBoolFlag lets -flag stand for -flag=true.
+func (v flagValue) IsBoolFlag() bool { return v.bool }
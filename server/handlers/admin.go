@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// every admin action, allowed or failed, lands here
+var adminAudit store.AdminAudit = store.NewAdminAuditBucket()
+
+var ErrBookingAuditUnavailable = model.NewError(model.CodeUnavailable, "booking audit log can't be read")
+
+// audit records an admin action taken by the request's principal.
+func audit(r *http.Request, action model.AdminAction, target, reason string, err error) {
+	entry := model.AdminAuditEntry{
+		At:      clock.Now(),
+		Actor:   "anonymous",
+		Action:  action,
+		Target:  target,
+		Reason:  reason,
+		Success: err == nil,
+	}
+	if subject, ok := auth.Subject(r.Context()); ok {
+		entry.Actor = subject
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	adminAudit.Record(r.Context(), entry)
+}
+
+// decodeReason reads the optional {"reason": "..."} body of an admin action.
+func decodeReason(w http.ResponseWriter, r *http.Request) (string, error) {
+	var req model.AdminReasonRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil && !errors.Is(err, utils.ErrEmptyBody) {
+		return "", err
+	}
+	return strings.TrimSpace(req.Reason), nil
+}
+
+func parseSeatNo(value string) (uint32, error) {
+	seatNo, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || seatNo == 0 {
+		return 0, store.ErrInvalidSeatNumber
+	}
+	return uint32(seatNo), nil
+}
+
+// HandleAdminListBookings searches bookings by ?userId=, ?status=, ?tier= and ?channel=.
+func HandleAdminListBookings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	filter := model.BookingFilter{
+		UserID:  strings.TrimSpace(query.Get("userId")),
+		Status:  model.BookingStatus(strings.ToUpper(query.Get("status"))),
+		Tier:    model.Tier(strings.ToUpper(query.Get("tier"))),
+		Channel: strings.TrimSpace(query.Get("channel")),
+	}
+	target := r.URL.RawQuery
+
+	if filter.Status != "" && !filter.Status.IsValidBookingStatus() {
+		err := ErrInvalidBookingStatus
+		audit(r, model.AdminActionSearchBookings, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+	if filter.Tier != "" && !filter.Tier.IsValidTier() {
+		err := ErrInvalidTier
+		audit(r, model.AdminActionSearchBookings, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	bookings := bookingStore.ListBookings(filter)
+	audit(r, model.AdminActionSearchBookings, target, "", nil)
+
+	utils.WriteJSON(w, r, http.StatusOK, model.AdminBookingsResponse{
+		Success:  true,
+		Bookings: bookings,
+	})
+}
+
+// HandleAdminCancelBooking force-cancels a booking and frees its seat.
+func HandleAdminCancelBooking(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	target := r.PathValue("id")
+	reason, err := decodeReason(w, r)
+	if err != nil {
+		audit(r, model.AdminActionCancelBooking, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	bookingID, err := uuid.Parse(target)
+	if err != nil {
+		err = ErrInvalidBookingID
+		audit(r, model.AdminActionCancelBooking, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	booking, err := bookingStore.CancelBooking(bookingID)
+	if err != nil {
+		audit(r, model.AdminActionCancelBooking, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// the promo code use goes back to the customer, the ticket to the partner's quota
+	if booking.PromoCode != "" {
+		promoStore.ReleasePromo(booking.PromoCode, booking.UserID)
+	}
+	if booking.APIKeyID != "" {
+		apiKeyStore.ReleaseBooking(booking.APIKeyID)
+	}
+
+	// a retry with the same idempotency key must not resurrect the booking
+	if order, exists := idempotencyStore.GetIdempotencyRecord(booking.IdempotencyKey); exists {
+		order.Status = model.BookingStatusCanceled
+		idempotencyStore.HandleIdempotency(order)
+	}
+
+	audit(r, model.AdminActionCancelBooking, target, reason, nil)
+	utils.RespondSuccess(w, r, "booking canceled", &booking)
+}
+
+// HandleAdminBlockSeat takes a free seat off sale.
+func HandleAdminBlockSeat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	target := r.PathValue("seatNo")
+	reason, err := decodeReason(w, r)
+	if err != nil {
+		audit(r, model.AdminActionBlockSeat, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	seatNo, err := parseSeatNo(target)
+	if err != nil {
+		audit(r, model.AdminActionBlockSeat, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	blockedBy, _ := auth.Subject(r.Context())
+	block, err := bookingStore.BlockSeat(model.SeatBlock{
+		SeatNo:    seatNo,
+		Reason:    reason,
+		BlockedBy: blockedBy,
+	})
+	if err != nil {
+		audit(r, model.AdminActionBlockSeat, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	audit(r, model.AdminActionBlockSeat, target, reason, nil)
+	utils.WriteJSON(w, r, http.StatusOK, model.SeatBlocksResponse{
+		Success: true,
+		Message: "seat blocked",
+		Blocks:  []model.SeatBlock{block},
+	})
+}
+
+// HandleAdminUnblockSeat puts a blocked seat back on sale.
+func HandleAdminUnblockSeat(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	target := r.PathValue("seatNo")
+	seatNo, err := parseSeatNo(target)
+	if err != nil {
+		audit(r, model.AdminActionUnblockSeat, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	if err := bookingStore.UnblockSeat(seatNo); err != nil {
+		audit(r, model.AdminActionUnblockSeat, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	audit(r, model.AdminActionUnblockSeat, target, "", nil)
+	utils.WriteJSON(w, r, http.StatusOK, model.SeatBlocksResponse{
+		Success: true,
+		Message: "seat unblocked",
+		Blocks:  []model.SeatBlock{},
+	})
+}
+
+// HandleAdminBlockedSeats lists every blocked seat.
+func HandleAdminBlockedSeats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	utils.WriteJSON(w, r, http.StatusOK, model.SeatBlocksResponse{
+		Success: true,
+		Blocks:  bookingStore.GetBlockedSeats(),
+	})
+}
+
+// HandleAdminCompTicket issues a complimentary, zero-cost ticket. It may take a blocked seat.
+func HandleAdminCompTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req model.CompTicketRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		audit(r, model.AdminActionCompTicket, "", "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+	target := strconv.FormatUint(uint64(req.SeatNo), 10)
+	reason := strings.TrimSpace(req.Reason)
+
+	if err := validateCompTicket(req); err != nil {
+		audit(r, model.AdminActionCompTicket, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	compID := "comp-" + uuid.NewString()
+	newBooking, err := bookingStore.RegisterBooking(model.BookingOrder{
+		UserID:         req.UserID,
+		Tier:           req.Tier,
+		Status:         model.BookingStatusPending,
+		IdempotencyKey: compID,
+		SeatNo:         req.SeatNo,
+		Currency:       "USD",
+		Complimentary:  true,
+		PaymentID:      compID,
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	})
+	if err != nil {
+		audit(r, model.AdminActionCompTicket, target, reason, err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	audit(r, model.AdminActionCompTicket, target, reason, nil)
+	utils.RespondSuccess(w, r, "complimentary ticket issued", &newBooking)
+}
+
+func validateCompTicket(req model.CompTicketRequest) error {
+	if strings.TrimSpace(req.UserID) == "" {
+		return utils.NewValidationError("userId", model.CodeInvalidRequest, "user ID is required")
+	}
+	if !req.Tier.IsValidTier() {
+		return utils.NewValidationError("tier", model.CodeInvalidTier, "invalid tier")
+	}
+	if tier, valid := model.TierForSeat(req.SeatNo); !valid || tier != req.Tier {
+		return utils.NewValidationError("seatNo", model.CodeInvalidSeat, "seat number is not in the tier")
+	}
+	if req.Reason == "" {
+		return utils.NewValidationError("reason", model.CodeInvalidRequest, "reason is required")
+	}
+	return nil
+}
+
+// HandleAdminIdempotencyRecord returns the order stored under an idempotency key.
+func HandleAdminIdempotencyRecord(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	key := r.PathValue("key")
+	order, exists := idempotencyStore.GetIdempotencyRecord(key)
+	if !exists {
+		err := ErrIdempotencyNotFound
+		audit(r, model.AdminActionViewIdempotency, key, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	audit(r, model.AdminActionViewIdempotency, key, "", nil)
+	utils.WriteJSON(w, r, http.StatusOK, model.IdempotencyRecordResponse{
+		Success: true,
+		Record:  &order,
+	})
+}
+
+// HandleAdminAudit lists the admin audit log, oldest first.
+func HandleAdminAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	utils.WriteJSON(w, r, http.StatusOK, model.AdminAuditResponse{
+		Success: true,
+		Entries: adminAudit.List(),
+	})
+}
+
+// HandleAdminBookingHistory returns a booking's transitions, oldest first: its
+// creation, payment outcome and cancellation, the only ones the API makes.
+func HandleAdminBookingHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	target := r.PathValue("id")
+	bookingID, err := uuid.Parse(target)
+	if err != nil {
+		err = ErrInvalidBookingID
+		audit(r, model.AdminActionViewHistory, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	// bookings restored from a snapshot older than the audit log have no entries
+	entries, err := bookingAudit.History(bookingID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to read booking audit log", "err", err)
+		err = ErrBookingAuditUnavailable
+		audit(r, model.AdminActionViewHistory, target, "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+	if len(entries) == 0 {
+		if _, err := bookingStore.GetBookingByID(bookingID); err != nil {
+			audit(r, model.AdminActionViewHistory, target, "", err)
+			utils.RespondError(w, r, err)
+			return
+		}
+	}
+
+	audit(r, model.AdminActionViewHistory, target, "", nil)
+	utils.WriteJSON(w, r, http.StatusOK, model.BookingHistoryResponse{
+		Success:   true,
+		BookingID: bookingID,
+		Entries:   entries,
+	})
+}
+
+// HandleAdminVerifyBookingAudit checks the booking audit log's hash chain. A
+// broken chain is still a 200: the check itself succeeded.
+func HandleAdminVerifyBookingAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	verification, err := bookingAudit.Verify()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to read booking audit log", "err", err)
+		err = ErrBookingAuditUnavailable
+		audit(r, model.AdminActionVerifyAudit, "", "", err)
+		utils.RespondError(w, r, err)
+		return
+	}
+	message := "audit log intact"
+	if !verification.Valid {
+		message = fmt.Sprintf("audit log broken at entry %d", verification.BrokenAt)
+		slog.ErrorContext(r.Context(), "booking audit log broken", "entry", verification.BrokenAt, "problem", verification.Problem)
+	}
+
+	audit(r, model.AdminActionVerifyAudit, "", "", nil)
+	utils.WriteJSON(w, r, http.StatusOK, model.AuditVerificationResponse{
+		Success:      true,
+		Message:      message,
+		Verification: verification,
+	})
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/logging"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// adminRequest builds a request made by an admin, with the given path values set.
+func adminRequest(method, target string, body any, pathValues map[string]string) *http.Request {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	for name, value := range pathValues {
+		req.SetPathValue(name, value)
+	}
+	return req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "admin-1", Roles: []string{auth.RoleAdmin}}))
+}
+
+func bookTicket(t *testing.T, order model.BookingOrder) model.BookingResponse {
+	t.Helper()
+	body, _ := json.Marshal(order)
+	w := httptest.NewRecorder()
+	HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+
+	var response model.BookingResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected booking to succeed, got %d: %s", w.Code, response.Message)
+	}
+	return response
+}
+
+func TestHandleAdminCancelBooking(t *testing.T) {
+	setupTestHandlers()
+
+	order := model.BookingOrder{
+		UserID:         "user-1",
+		Tier:           model.TierVIP,
+		SeatNo:         5,
+		Country:        "US",
+		ZipCode:        "10001",
+		Currency:       "USD",
+		IdempotencyKey: "cancel-key",
+		PaymentID:      "pay-1",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+	booking := bookTicket(t, order).Booking
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		expectedError  string
+	}{
+		{name: "cancel booking", id: booking.ID.String(), expectedStatus: http.StatusOK},
+		{name: "already canceled", id: booking.ID.String(), expectedStatus: http.StatusConflict, expectedError: "booking already canceled"},
+		{name: "unknown booking", id: "7f1c1a52-3c8e-4c55-9b8c-0c6f0c1f6f00", expectedStatus: http.StatusNotFound, expectedError: "booking not found"},
+		{name: "invalid id", id: "nope", expectedStatus: http.StatusBadRequest, expectedError: "invalid booking id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleAdminCancelBooking(w, adminRequest(http.MethodPost, "/admin/bookings/"+tt.id+"/cancel",
+				model.AdminReasonRequest{Reason: "chargeback"}, map[string]string{"id": tt.id}))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if tt.expectedError != "" && response.Message != tt.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tt.expectedError, response.Message)
+			}
+		})
+	}
+
+	// a retry of the canceled order doesn't book the seat again
+	body, _ := json.Marshal(order)
+	w := httptest.NewRecorder()
+	HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected replay of canceled booking to return %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	// every attempt is audited, failures included
+	entries := adminAudit.List()
+	if len(entries) != len(tests) {
+		t.Fatalf("Expected %d audit entries, got %d", len(tests), len(entries))
+	}
+	if entries[0].Actor != "admin-1" || entries[0].Action != model.AdminActionCancelBooking || !entries[0].Success || entries[0].Reason != "chargeback" {
+		t.Errorf("Unexpected audit entry %+v", entries[0])
+	}
+	if entries[1].Success || entries[1].Error == "" {
+		t.Errorf("Expected failed audit entry, got %+v", entries[1])
+	}
+}
+
+func TestHandleAdminSeatBlocks(t *testing.T) {
+	setupTestHandlers()
+
+	w := httptest.NewRecorder()
+	HandleAdminBlockSeat(w, adminRequest(http.MethodPost, "/admin/seats/3/block",
+		model.AdminReasonRequest{Reason: "camera position"}, map[string]string{"seatNo": "3"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	// customers can't book a blocked seat
+	body, _ := json.Marshal(model.BookingOrder{
+		UserID: "user-1", Tier: model.TierVIP, SeatNo: 3, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "blocked-key", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+	w = httptest.NewRecorder()
+	HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected booking a blocked seat to return %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminBlockedSeats(w, adminRequest(http.MethodGet, "/admin/seats/blocked", nil, nil))
+	var blocks model.SeatBlocksResponse
+	json.NewDecoder(w.Body).Decode(&blocks)
+	if len(blocks.Blocks) != 1 || blocks.Blocks[0].SeatNo != 3 || blocks.Blocks[0].BlockedBy != "admin-1" {
+		t.Errorf("Expected seat 3 blocked by admin-1, got %+v", blocks.Blocks)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminUnblockSeat(w, adminRequest(http.MethodDelete, "/admin/seats/3/block", nil, map[string]string{"seatNo": "3"}))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminUnblockSeat(w, adminRequest(http.MethodDelete, "/admin/seats/3/block", nil, map[string]string{"seatNo": "3"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestHandleAdminCompTicket(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        model.CompTicketRequest
+		setupFunc      func()
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "comp ticket",
+			request:        model.CompTicketRequest{UserID: "guest-1", Tier: model.TierVIP, SeatNo: 10, Reason: "artist guest"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:    "comp a blocked seat",
+			request: model.CompTicketRequest{UserID: "guest-2", Tier: model.TierFrontRow, SeatNo: 31, Reason: "press"},
+			setupFunc: func() {
+				bookingStore.BlockSeat(model.SeatBlock{SeatNo: 31})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "seat outside tier",
+			request:        model.CompTicketRequest{UserID: "guest-3", Tier: model.TierVIP, SeatNo: 61, Reason: "press"},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "seat number is not in the tier",
+		},
+		{
+			name:           "missing reason",
+			request:        model.CompTicketRequest{UserID: "guest-3", Tier: model.TierGA, SeatNo: 61},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "reason is required",
+		},
+		{
+			name:    "seat already booked",
+			request: model.CompTicketRequest{UserID: "guest-4", Tier: model.TierGA, SeatNo: 62, Reason: "press"},
+			setupFunc: func() {
+				bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-1", Tier: model.TierGA, SeatNo: 62, PaymentStatus: model.PaymentStatusPending})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "seat already booked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			if tt.setupFunc != nil {
+				tt.setupFunc()
+			}
+
+			w := httptest.NewRecorder()
+			HandleAdminCompTicket(w, adminRequest(http.MethodPost, "/admin/comp", tt.request, nil))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if tt.expectedError != "" {
+				if response.Message != tt.expectedError {
+					t.Errorf("Expected error '%s', got '%s'", tt.expectedError, response.Message)
+				}
+				return
+			}
+
+			booking := response.Booking
+			if booking.TotalAmtInUSCent != 0 || !booking.Complimentary || booking.Status != model.BookingStatusConfirmed {
+				t.Errorf("Expected zero-cost confirmed complimentary booking, got %+v", booking)
+			}
+		})
+	}
+}
+
+func TestHandleAdminListBookingsAndIdempotency(t *testing.T) {
+	setupTestHandlers()
+
+	bookTicket(t, model.BookingOrder{
+		UserID: "user-1", Tier: model.TierVIP, SeatNo: 1, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "list-key-1", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+	bookTicket(t, model.BookingOrder{
+		UserID: "user-2", Tier: model.TierGA, SeatNo: 70, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "list-key-2", PaymentID: "pay-2", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+
+	w := httptest.NewRecorder()
+	HandleAdminListBookings(w, adminRequest(http.MethodGet, "/admin/bookings?tier=ga", nil, nil))
+	var list model.AdminBookingsResponse
+	json.NewDecoder(w.Body).Decode(&list)
+	if len(list.Bookings) != 1 || list.Bookings[0].UserID != "user-2" {
+		t.Errorf("Expected only user-2's GA booking, got %+v", list.Bookings)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminListBookings(w, adminRequest(http.MethodGet, "/admin/bookings?status=LOST", nil, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminIdempotencyRecord(w, adminRequest(http.MethodGet, "/admin/idempotency/list-key-1", nil, map[string]string{"key": "list-key-1"}))
+	var record model.IdempotencyRecordResponse
+	json.NewDecoder(w.Body).Decode(&record)
+	if record.Record == nil || record.Record.Status != model.BookingStatusConfirmed {
+		t.Errorf("Expected confirmed idempotency record, got %+v", record.Record)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminIdempotencyRecord(w, adminRequest(http.MethodGet, "/admin/idempotency/missing", nil, map[string]string{"key": "missing"}))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	HandleAdminAudit(w, adminRequest(http.MethodGet, "/admin/audit", nil, nil))
+	var auditLog model.AdminAuditResponse
+	json.NewDecoder(w.Body).Decode(&auditLog)
+	if len(auditLog.Entries) != 4 {
+		t.Errorf("Expected 4 audit entries, got %d", len(auditLog.Entries))
+	}
+}
+
+func TestAdminActionLogCarriesRequestID(t *testing.T) {
+	setupTestHandlers()
+	var out bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(logging.NewHandler(&out, logging.FormatJSON, slog.LevelInfo)))
+
+	req := adminRequest(http.MethodGet, "/admin/bookings", nil, nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-admin"))
+	HandleAdminListBookings(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q", out.String())
+	}
+	if record["msg"] != "admin action" || record["request_id"] != "req-admin" {
+		t.Errorf("Expected the admin action logged with its request ID, got %v", record)
+	}
+}
+
+func TestHandleAdminBookingHistory(t *testing.T) {
+	setupTestHandlers()
+
+	booking := bookTicket(t, model.BookingOrder{
+		UserID: "user-1", Tier: model.TierVIP, SeatNo: 3, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "history-key", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	}).Booking
+	HandleAdminCancelBooking(httptest.NewRecorder(), adminRequest(http.MethodPost, "/admin/bookings/"+booking.ID.String()+"/cancel", nil, map[string]string{"id": booking.ID.String()}))
+
+	tests := []struct {
+		name           string
+		id             string
+		expectedStatus int
+		expectedEvents []model.BookingEvent
+	}{
+		{
+			name:           "booking's transitions",
+			id:             booking.ID.String(),
+			expectedStatus: http.StatusOK,
+			expectedEvents: []model.BookingEvent{model.BookingEventCreated, model.BookingEventConfirmed, model.BookingEventCanceled},
+		},
+		{name: "unknown booking", id: "0b6c2a58-8d4e-4bcb-9a57-5d0c1c1d2f11", expectedStatus: http.StatusNotFound},
+		{name: "invalid id", id: "seat-3", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HandleAdminBookingHistory(w, adminRequest(http.MethodGet, "/admin/bookings/"+tt.id+"/history", nil, map[string]string{"id": tt.id}))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var history model.BookingHistoryResponse
+			json.NewDecoder(w.Body).Decode(&history)
+			if len(history.Entries) != len(tt.expectedEvents) {
+				t.Fatalf("Expected %d entries, got %+v", len(tt.expectedEvents), history.Entries)
+			}
+			for i, entry := range history.Entries {
+				if entry.Event != tt.expectedEvents[i] || entry.BookingID != booking.ID {
+					t.Errorf("Expected entry %d to be the booking's %s, got %+v", i, tt.expectedEvents[i], entry)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleAdminVerifyBookingAudit(t *testing.T) {
+	setupTestHandlers()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := UseBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected the audit log to open, got %v", err)
+	}
+	t.Cleanup(func() { audit.Close() })
+
+	bookTicket(t, model.BookingOrder{
+		UserID: "user-1", Tier: model.TierGA, SeatNo: 75, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "verify-key", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+
+	verify := func() model.AuditVerificationResponse {
+		w := httptest.NewRecorder()
+		HandleAdminVerifyBookingAudit(w, adminRequest(http.MethodGet, "/admin/audit/bookings/verify", nil, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response model.AuditVerificationResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return response
+	}
+
+	if response := verify(); !response.Verification.Valid || response.Verification.Entries != 2 {
+		t.Errorf("Expected an intact chain of 2 entries, got %+v", response.Verification)
+	}
+
+	// rewriting history in the file is caught too
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected to read the audit log, got %v", err)
+	}
+	data = bytes.Replace(data, []byte(`"userId":"user-1"`), []byte(`"userId":"user-2"`), 1)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("Expected to rewrite the audit log, got %v", err)
+	}
+	if response := verify(); response.Verification.Valid || response.Verification.BrokenAt != 1 {
+		t.Errorf("Expected the chain broken at entry 1, got %+v", response.Verification)
+	}
+}
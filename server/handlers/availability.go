@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// encoded availability responses, served until the seats or a sale window change
+var availability = newAvailabilityCache()
+
+/*
+  - availabilityCache keeps the encoded availability response per API version
+    and partner key (partners don't see each other's allocated seats).
+  - an entry is valid while the store's inventory version is unchanged and
+    no tier's sale state has changed since
+  - the ETag is the inventory version and a checksum of the body, so it
+    changes whenever the body does
+*/
+type availabilityCache struct {
+	mu      sync.RWMutex
+	entries map[availabilityKey]availabilityEntry
+}
+
+type availabilityKey struct {
+	version  utils.APIVersion
+	apiKeyID string
+}
+
+type availabilityEntry struct {
+	inventory  uint64
+	validUntil time.Time // next sale state change; zero when none is scheduled
+	etag       string
+	body       []byte
+}
+
+func newAvailabilityCache() *availabilityCache {
+	return &availabilityCache{entries: make(map[availabilityKey]availabilityEntry)}
+}
+
+func (c *availabilityCache) get(key availabilityKey, inventory uint64, now time.Time) (availabilityEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, exists := c.entries[key]
+	if !exists || entry.inventory != inventory {
+		return availabilityEntry{}, false
+	}
+	if !entry.validUntil.IsZero() && !now.Before(entry.validUntil) {
+		return availabilityEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *availabilityCache) put(key availabilityKey, entry availabilityEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// encodeAvailability builds and encodes the availability response for the
+// inventory version, reusing the cached body while it is still valid.
+func (c *availabilityCache) encodeAvailability(r *http.Request, principal auth.Principal) (availabilityEntry, error) {
+	key := availabilityKey{version: utils.APIVersionFromContext(r.Context()), apiKeyID: principal.APIKeyID}
+	// read before the seats, so a change while building makes the entry stale rather than wrong
+	inventory := bookingStore.Version()
+	now := clock.Now()
+
+	if entry, hit := c.get(key, inventory, now); hit {
+		return entry, nil
+	}
+
+	response := model.AvailabilityResponse{
+		Success: true,
+		Message: "reserved seats retrieved successfully",
+		Tiers:   availabilityTiers(principal),
+	}
+	body, err := utils.EncodeJSON(r, response)
+	if err != nil {
+		return availabilityEntry{}, err
+	}
+
+	entry := availabilityEntry{
+		inventory: inventory,
+		etag:      fmt.Sprintf(`"%d-%08x"`, inventory, crc32.ChecksumIEEE(body)),
+		body:      body,
+	}
+	for _, tier := range response.Tiers {
+		if !tier.SaleChangesAt.IsZero() && (entry.validUntil.IsZero() || tier.SaleChangesAt.Before(entry.validUntil)) {
+			entry.validUntil = tier.SaleChangesAt
+		}
+	}
+	c.put(key, entry)
+	return entry, nil
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/sales"
+)
+
+func getAvailability(ifNoneMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/booking/availability", nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	HandleAvailability(w, req)
+	return w
+}
+
+func TestHandleAvailability_ETag(t *testing.T) {
+	setupTestHandlers()
+
+	first := getAvailability("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("Expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+
+	tests := []struct {
+		name           string
+		setupFunc      func()
+		ifNoneMatch    string
+		expectedStatus int
+		expectedETag   bool // the same ETag as the first response
+	}{
+		{name: "unconditional", expectedStatus: http.StatusOK, expectedETag: true},
+		{name: "unchanged", ifNoneMatch: etag, expectedStatus: http.StatusNotModified, expectedETag: true},
+		{name: "another tag", ifNoneMatch: `"0-00000000"`, expectedStatus: http.StatusOK, expectedETag: true},
+		{
+			name: "seat booked",
+			setupFunc: func() {
+				bookTicket(t, model.BookingOrder{
+					UserID: "user-1", Tier: model.TierGA, SeatNo: 61, Country: "US", ZipCode: "10001", Currency: "USD",
+					IdempotencyKey: "etag-1", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+				})
+			},
+			ifNoneMatch:    etag,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setupFunc != nil {
+				tt.setupFunc()
+			}
+
+			w := getAvailability(tt.ifNoneMatch)
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if sameETag := w.Header().Get("ETag") == etag; sameETag != tt.expectedETag {
+				t.Errorf("Expected same ETag %v, got %s (first %s)", tt.expectedETag, w.Header().Get("ETag"), etag)
+			}
+			if w.Code == http.StatusNotModified && w.Body.Len() > 0 {
+				t.Errorf("Expected no body with 304, got %s", w.Body.String())
+			}
+			if w.Code == http.StatusOK && tt.expectedETag && !bytes.Equal(w.Body.Bytes(), first.Body.Bytes()) {
+				t.Error("Expected the cached body")
+			}
+		})
+	}
+}
+
+func TestHandleAvailability_ETagFollowsSaleWindows(t *testing.T) {
+	setupTestHandlers()
+	generalSaleStart := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	saleSchedule = sales.Schedule{Event: sales.Window{GeneralSaleStart: generalSaleStart}}
+	testClock := &fixedClock{now: generalSaleStart.Add(-time.Minute)}
+	clock = testClock
+
+	before := getAvailability("")
+	testClock.now = generalSaleStart
+
+	after := getAvailability(before.Header().Get("ETag"))
+	if after.Code != http.StatusOK {
+		t.Fatalf("Expected the opening sale to change the availability, got %d", after.Code)
+	}
+	if bytes.Equal(before.Body.Bytes(), after.Body.Bytes()) {
+		t.Error("Expected the sale state in the body to change")
+	}
+}
+
+func TestSetSeatLayout(t *testing.T) {
+	setupTestHandlers()
+	t.Cleanup(func() {
+		SetSeatLayout(model.DefaultSeatLayout())
+		setupTestHandlers()
+	})
+
+	layout := model.SeatLayout{
+		model.TierVIP:      {Min: 1, Max: 10},
+		model.TierFrontRow: {Min: 11, Max: 20},
+		model.TierGA:       {Min: 21, Max: 200},
+	}
+	if err := SetSeatLayout(layout); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var response model.AvailabilityResponse
+	if err := json.Unmarshal(getAvailability("").Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	for _, tierInfo := range response.Tiers {
+		if want := layout[tierInfo.Tier].Total(); tierInfo.TotalSeats != want {
+			t.Errorf("%s: Expected %d seats, got %d", tierInfo.Tier, want, tierInfo.TotalSeats)
+		}
+	}
+
+	// seats past the default 100 exist now
+	if _, err := bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-1", Tier: model.TierGA, SeatNo: 150, IdempotencyKey: "layout-1"}); err != nil {
+		t.Errorf("Expected seat 150 bookable, got %v", err)
+	}
+
+	invalid := model.SeatLayout{model.TierVIP: {Min: 1, Max: 10}}
+	if err := SetSeatLayout(invalid); err == nil {
+		t.Errorf("Expected an incomplete layout to fail")
+	}
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"slices"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+)
+
+var (
+	ErrSeatAllocated       = model.NewError(model.CodeSeatAllocated, "seat is allocated to a partner")
+	ErrSeatNotInAllocation = model.NewError(model.CodeSeatNotInAllocation, "seat is not in the API key's allocation")
+)
+
+// partner reseller API keys, with their quotas and seat allocations
+var apiKeyStore store.APIKeyStore = store.NewAPIKeyBucket()
+
+// LoadAPIKeys registers the partner API keys defined in a JSON file.
+func LoadAPIKeys(path string) error {
+	return store.LoadAPIKeys(apiKeyStore, path)
+}
+
+// APIKeys returns the key store the authentication middleware checks API keys against.
+func APIKeys() store.APIKeyStore {
+	return apiKeyStore
+}
+
+// checkChannelSeat returns an error unless the caller may sell the seat: partners
+// with an allocation only sell their own seats, and nobody else sells allocated seats.
+func checkChannelSeat(principal auth.Principal, seatNo uint32) error {
+	owner, allocated := apiKeyStore.AllocationOwner(seatNo)
+
+	if principal.APIKeyID != "" {
+		key, _ := apiKeyStore.GetAPIKey(principal.APIKeyID)
+		if len(key.Allocation) > 0 && !key.IsAllocated(seatNo) {
+			return ErrSeatNotInAllocation
+		}
+		if allocated && owner != principal.APIKeyID {
+			return ErrSeatAllocated
+		}
+		return nil
+	}
+
+	if allocated {
+		return ErrSeatAllocated
+	}
+	return nil
+}
+
+// channelReservedSeats adds the seats the caller can't sell to the reserved seats per tier.
+func channelReservedSeats(principal auth.Principal, reservedSeats map[string][]uint32) map[string][]uint32 {
+	visible := make(map[string][]uint32, len(reservedSeats))
+	for _, tier := range model.AllTiers() {
+		reserved := slices.Clone(reservedSeats[string(tier)])
+		reservedSet := make(map[uint32]bool, len(reserved))
+		for _, seat := range reserved {
+			reservedSet[seat] = true
+		}
+
+		seatRange := tier.SeatRange()
+		for seat := seatRange.Min; seat <= seatRange.Max; seat++ {
+			if !reservedSet[seat] && checkChannelSeat(principal, seat) != nil {
+				reserved = append(reserved, seat)
+			}
+		}
+		visible[string(tier)] = reserved
+	}
+	return visible
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+)
+
+func setupPartnerKey(t *testing.T) auth.Principal {
+	t.Helper()
+	err := apiKeyStore.RegisterAPIKey(model.APIKey{
+		ID:          "boxoffice-a",
+		KeyHash:     store.HashAPIKey("secret-a"),
+		Channel:     "BOX_OFFICE_A",
+		Scopes:      []model.APIScope{model.ScopeAvailabilityRead, model.ScopeBookingWrite},
+		MaxBookings: 2,
+		Allocation:  []model.SeatRange{{Min: 61, Max: 65}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register API key: %v", err)
+	}
+	return auth.Principal{APIKeyID: "boxoffice-a", Channel: "BOX_OFFICE_A"}
+}
+
+func partnerBooking(seatNo uint32, key string) model.BookingOrder {
+	return model.BookingOrder{
+		UserID:         "customer-1",
+		Tier:           model.TierGA,
+		SeatNo:         seatNo,
+		Country:        "US",
+		ZipCode:        "10001",
+		Currency:       "USD",
+		IdempotencyKey: key,
+		PaymentID:      "pay-" + key,
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+}
+
+func TestHandleBooking_PartnerChannel(t *testing.T) {
+	setupTestHandlers()
+	partner := setupPartnerKey(t)
+
+	tests := []struct {
+		name           string
+		order          model.BookingOrder
+		principal      *auth.Principal
+		expectedStatus int
+		expectedError  string
+	}{
+		{name: "partner sells allocated seat", order: partnerBooking(61, "p-1"), principal: &partner, expectedStatus: http.StatusOK},
+		{name: "partner seat outside allocation", order: partnerBooking(70, "p-2"), principal: &partner, expectedStatus: http.StatusForbidden, expectedError: "seat is not in the API key's allocation"},
+		{name: "public can't buy allocated seat", order: partnerBooking(62, "p-3"), expectedStatus: http.StatusConflict, expectedError: "seat is allocated to a partner"},
+		{name: "partner second ticket", order: partnerBooking(62, "p-4"), principal: &partner, expectedStatus: http.StatusOK},
+		{name: "partner quota used up", order: partnerBooking(63, "p-5"), principal: &partner, expectedStatus: http.StatusForbidden, expectedError: "API key booking quota exceeded"},
+		{name: "public buys unallocated seat", order: partnerBooking(70, "p-6"), expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.order)
+			req := httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body))
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), *tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			HandleBooking(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if tt.expectedError != "" && response.Message != tt.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tt.expectedError, response.Message)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			expectedChannel := ""
+			if tt.principal != nil {
+				expectedChannel = "BOX_OFFICE_A"
+			}
+			if response.Booking.Channel != expectedChannel {
+				t.Errorf("Expected channel '%s', got '%s'", expectedChannel, response.Booking.Channel)
+			}
+		})
+	}
+}
+
+func TestHandleAvailability_PartnerAllocation(t *testing.T) {
+	setupTestHandlers()
+	partner := setupPartnerKey(t)
+
+	availableGA := func(principal *auth.Principal) []uint32 {
+		req := httptest.NewRequest(http.MethodGet, "/booking/availability", nil)
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		HandleAvailability(w, req)
+
+		var response model.AvailabilityResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		for _, tier := range response.Tiers {
+			if tier.Tier == model.TierGA {
+				return tier.AvailableList
+			}
+		}
+		return nil
+	}
+
+	if public := availableGA(nil); slices.Contains(public, 61) || !slices.Contains(public, 66) {
+		t.Errorf("Expected public GA availability without the allocation, got %v", public)
+	}
+	if own := availableGA(&partner); !slices.Equal(own, []uint32{61, 62, 63, 64, 65}) {
+		t.Errorf("Expected partner to see only its allocation, got %v", own)
+	}
+}
+
+func TestHandleAdminCancelBooking_PartnerQuota(t *testing.T) {
+	setupTestHandlers()
+	partner := setupPartnerKey(t)
+
+	sell := func(order model.BookingOrder) (int, *model.Booking) {
+		body, _ := json.Marshal(order)
+		req := httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+		HandleBooking(w, req.WithContext(auth.WithPrincipal(req.Context(), partner)))
+
+		var response model.BookingResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response.Booking
+	}
+
+	_, first := sell(partnerBooking(61, "q-1"))
+	sell(partnerBooking(62, "q-2"))
+	if first == nil || first.APIKeyID != "boxoffice-a" {
+		t.Fatalf("Expected the booking to record API key 'boxoffice-a', got %+v", first)
+	}
+	if status, _ := sell(partnerBooking(63, "q-3")); status != http.StatusForbidden {
+		t.Fatalf("Expected the quota to be used up, got %d", status)
+	}
+
+	// an admin cancel gives the ticket back to the partner's quota
+	id := first.ID.String()
+	w := httptest.NewRecorder()
+	HandleAdminCancelBooking(w, adminRequest(http.MethodPost, "/admin/bookings/"+id+"/cancel", nil, map[string]string{"id": id}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected cancel to succeed, got %d", w.Code)
+	}
+	if sold := apiKeyStore.GetBookingsSold("boxoffice-a"); sold != 1 {
+		t.Errorf("Expected 1 ticket counted against the quota, got %d", sold)
+	}
+	if status, _ := sell(partnerBooking(63, "q-4")); status != http.StatusOK {
+		t.Errorf("Expected the partner to sell again after the cancel, got %d", status)
+	}
+}
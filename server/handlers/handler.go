@@ -1,24 +1,119 @@
 package handlers
 
 import (
-	"encoding/json"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
 	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/sales"
 	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
 	"github.com/ignius299792458/techkraft-ch-svr/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	bookingStore     store.BookingStore
 	idempotencyStore store.Idempotency
+	promoStore       store.PromoStore
+
+	// seat changes of bookingStore, streamed to availability viewers
+	seatFeed store.SeatFeed
+
+	// saves bookingStore with the file storage backend, nil in memory
+	fileStorage *store.FileStorage
+
+	// bookingStore's booking transitions (model.BookingEvent), hash-chained
+	bookingAudit store.BookingAudit = store.NewBookingAuditBucket()
+
+	// on-sale windows per tier; the zero schedule keeps every tier on sale
+	saleSchedule sales.Schedule
+
+	// time source for sale windows, promo validity and quotes
+	clock utils.Clock = utils.SystemClock{}
+)
+
+var (
+	ErrInvalidTier          = model.NewError(model.CodeInvalidTier, "invalid tier")
+	ErrInvalidBookingID     = model.NewError(model.CodeInvalidRequest, "invalid booking id")
+	ErrInvalidBookingStatus = model.NewError(model.CodeInvalidRequest, "invalid booking status")
+	ErrBookingCanceled      = model.NewError(model.CodeBookingCanceled, "booking was canceled")
+	ErrIdempotencyNotFound  = model.NewError(model.CodeIdempotencyNotFound, "idempotency record not found")
+	ErrIdempotencyMismatch  = model.NewError(model.CodeIdempotencyMismatch, "idempotency key was used for a different booking")
 )
 
 func init() {
 	bookingStore = store.NewBookingStoreBucket()
 	idempotencyStore = store.NewIdempotencyBucket()
+	promoStore = store.NewPromoBucket()
+	connectSeatFeed()
+	connectBookingAudit()
+}
+
+// connectSeatFeed feeds bookingStore's seat changes into a fresh seat feed.
+func connectSeatFeed() {
+	seatFeed = store.NewSeatFeedBucket()
+	bookingStore.OnSeatChange(seatFeed.Publish)
+}
+
+// connectBookingAudit records bookingStore's booking transitions in bookingAudit.
+func connectBookingAudit() {
+	bookingStore.OnBookingChange(func(event model.BookingEvent, booking model.Booking) {
+		bookingAudit.Append(event, booking)
+	})
+}
+
+// SetSeatLayout sets the venue's tier seat ranges. It starts from an empty
+// store, so it must be called before UseFileStorage and before serving.
+func SetSeatLayout(layout model.SeatLayout) error {
+	if err := model.SetSeatLayout(layout); err != nil {
+		return err
+	}
+	bookingStore = store.NewBookingStoreBucket()
+	connectSeatFeed()
+	connectBookingAudit()
+	availability = newAvailabilityCache()
+	return nil
+}
+
+// UseFileStorage restores the seat inventory saved at path and keeps saving it
+// there. Close the returned storage on shutdown to save the last changes.
+func UseFileStorage(path string) (*store.FileStorage, error) {
+	storage, err := store.OpenFileStorage(bookingStore, path)
+	if err != nil {
+		return nil, err
+	}
+	fileStorage = storage
+	return storage, nil
+}
+
+// UseBookingAuditFile keeps the booking audit log in the file at path,
+// appending to the entries it has. Close the returned log on shutdown.
+func UseBookingAuditFile(path string) (store.BookingAudit, error) {
+	audit, err := store.OpenBookingAuditFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bookingAudit = audit
+	return audit, nil
+}
+
+// LoadPromoCodes registers the promo codes defined in a JSON file.
+func LoadPromoCodes(path string) error {
+	return store.LoadPromoCodes(promoStore, path)
+}
+
+// SetSaleSchedule sets the presale / general sale windows enforced on booking.
+func SetSaleSchedule(schedule sales.Schedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	saleSchedule = schedule
+	return nil
 }
 
 func HandleBooking(w http.ResponseWriter, r *http.Request) {
@@ -29,19 +124,51 @@ func HandleBooking(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request
 	var req model.BookingOrder
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, "invalid request body", http.StatusBadRequest)
+
+	// every error answered is counted as the booking's outcome
+	respondError := func(err error) {
+		recordBookingOutcome(r.Context(), req.Tier, bookingErrorOutcome(err))
+		utils.RespondError(w, r, err)
+	}
+
+	_, decodeSpan := tracing.Start(r.Context(), "booking.decode")
+	err := utils.DecodeJSON(w, r, &req)
+	if err != nil {
+		decodeSpan.SetStatus(codes.Error, err.Error())
+		decodeSpan.End()
+		respondError(err)
 		return
 	}
+	decodeSpan.End()
+	trace.SpanFromContext(r.Context()).SetAttributes(
+		attribute.Int64("booking.seat_no", int64(req.SeatNo)),
+		attribute.String("booking.tier", string(req.Tier)),
+	)
+
+	// The authenticated subject is the user, whatever the body says
+	if subject, ok := auth.Subject(r.Context()); ok {
+		req.UserID = subject
+	}
 
 	// Validate request
 	if err := utils.ValidateBookingRequest(&req); err != nil {
-		utils.RespondError(w, err.Error(), http.StatusBadRequest)
+		respondError(err)
 		return
 	}
 
-	// Calculate amount based on tier
-	totalAmt := utils.CalculateAmount(req.Tier)
+	// Partner allocations: seats set aside for a partner are only sold through its API key
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if err := checkChannelSeat(principal, req.SeatNo); err != nil {
+		respondError(err)
+		return
+	}
+
+	// Base price of the tier (fixed, dynamic, or from a signed quote)
+	basePrice, quote, err := priceBooking(&req)
+	if err != nil {
+		respondError(err)
+		return
+	}
 
 	// Create booking order
 	bookingOrder := model.BookingOrder{
@@ -53,116 +180,222 @@ func HandleBooking(w http.ResponseWriter, r *http.Request) {
 		ZipCode:          req.ZipCode,
 		Currency:         req.Currency,
 		SeatNo:           req.SeatNo,
-		TotalAmtInUSCent: totalAmt,
+		PromoCode:        model.NormalizePromoCode(req.PromoCode),
+		TotalAmtInUSCent: basePrice,
 		PaymentID:        req.PaymentID,
 		PaymentStatus:    req.PaymentStatus,
+		Channel:          principal.Channel,
+		APIKeyID:         principal.APIKeyID,
 	}
 
 	// Handle idempotency - check if this request was already processed
-	idempotentOrder := idempotencyStore.HandleIdempotency(bookingOrder)
+	idempotentOrder := idempotencyStore.HandleIdempotencyContext(r.Context(), bookingOrder)
+
+	// A key reused for a different order must not replay (or book) the first one
+	if !idempotentOrder.SameOrder(bookingOrder) {
+		respondError(ErrIdempotencyMismatch)
+		return
+	}
 
 	if idempotentOrder.Status == model.BookingStatusConfirmed {
 		// Booking already confirmed
 		oldConfirmedBooking, err := bookingStore.GetBooking(idempotentOrder.SeatNo)
 		if err != nil {
-			utils.RespondError(w, err.Error(), http.StatusInternalServerError)
+			respondError(err)
+			return
+		}
+		recordBookingOutcome(r.Context(), idempotentOrder.Tier, outcomeReplay)
+		utils.RespondSuccess(w, r, "booking already confirmed", &oldConfirmedBooking)
+		return
+	}
+
+	if idempotentOrder.Status == model.BookingStatusCanceled {
+		// Booking was canceled (failed payment or by an admin) - a retry doesn't revive it
+		recordBookingOutcome(r.Context(), idempotentOrder.Tier, outcomeReplay)
+		utils.RespondError(w, r, ErrBookingCanceled)
+		return
+	}
+
+	// Tier must be on sale, or in presale with a valid access code
+	if err := saleSchedule.CheckBookable(idempotentOrder.Tier, req.AccessCode, clock.Now()); err != nil {
+		respondError(err)
+		return
+	}
+
+	// Partner quota - counted before the seat is taken, like promo codes,
+	// and given back if the booking doesn't go through
+	if principal.APIKeyID != "" {
+		if err := apiKeyStore.ReserveBooking(principal.APIKeyID); err != nil {
+			respondError(err)
+			return
+		}
+	}
+
+	// Apply promo code - redemption is counted before the seat is taken
+	// and given back if the booking doesn't go through
+	var discount uint64
+	if idempotentOrder.PromoCode != "" {
+		discount, err = promoStore.RedeemPromo(
+			idempotentOrder.PromoCode,
+			idempotentOrder.UserID,
+			idempotentOrder.Tier,
+			idempotentOrder.TotalAmtInUSCent,
+			clock.Now(),
+		)
+		if err != nil {
+			releaseHolds(idempotentOrder, principal, false)
+			respondError(err)
 			return
 		}
-		utils.RespondSuccess(w, "booking already confirmed", &oldConfirmedBooking)
+	}
+
+	// Final amount with fees and tax - a seat quote is charged exactly as quoted
+	breakdown, err := bookingBreakdown(idempotentOrder, discount, quote)
+	if err != nil {
+		releaseHolds(idempotentOrder, principal, true)
+		respondError(err)
 		return
 	}
+	idempotentOrder.DiscountInUSCent = breakdown.DiscountInUSCent
+	idempotentOrder.FeesInUSCent = breakdown.FeesInUSCent
+	idempotentOrder.TaxInUSCent = breakdown.TaxInUSCent
+	idempotentOrder.TotalAmtInUSCent = breakdown.TotalInUSCent
 
 	// Register the booking
-	newBooking, err := bookingStore.RegisterBooking(idempotentOrder)
+	newBooking, err := bookingStore.RegisterBookingContext(r.Context(), idempotentOrder)
 	if err != nil {
-		utils.RespondError(w, err.Error(), http.StatusConflict)
+		releaseHolds(idempotentOrder, principal, true)
+		respondError(err)
 		return
 	}
 
+	// A canceled payment shouldn't use up the promo code or the partner quota
+	if newBooking.Status == model.BookingStatusCanceled {
+		releaseHolds(idempotentOrder, principal, true)
+	}
+
+	// Confirmed sales drive the tier's booking velocity
+	if pricingEngine != nil && newBooking.Status == model.BookingStatusConfirmed {
+		pricingEngine.RecordSale(newBooking.Tier)
+	}
+
 	// Update booking order with the created booking ID
 	bookingOrder.Status = newBooking.Status
 
 	// Update idempotency store with complete booking info
 	if newBooking.Status != model.BookingStatusPending {
-		idempotencyStore.HandleIdempotency(bookingOrder)
+		idempotencyStore.HandleIdempotencyContext(r.Context(), bookingOrder)
 	}
 
-	duration := time.Since(start).Milliseconds()
-	slog.Info("Booking processed duration : ", "duration_ms", duration, "sec: ", duration/1000)
-
-	slog.Info("Booking created",
+	slog.InfoContext(r.Context(), "Booking created",
 		"booking_id", newBooking.ID,
 		"user_id", newBooking.UserID,
 		"seat", newBooking.SeatNo,
-		"tier", newBooking.Tier)
+		"tier", newBooking.Tier,
+		"duration_ms", time.Since(start).Milliseconds())
 
-	utils.RespondSuccess(w, "new booking successful", &newBooking)
+	recordBookingOutcome(r.Context(), newBooking.Tier, bookingStatusOutcome(newBooking.Status))
+	utils.RespondSuccess(w, r, "new booking successful", &newBooking)
+}
+
+// releaseHolds gives back the partner quota and, once redeemed, the promo code
+// use counted for an order that didn't go through.
+func releaseHolds(order model.BookingOrder, principal auth.Principal, promoRedeemed bool) {
+	if promoRedeemed && order.PromoCode != "" {
+		promoStore.ReleasePromo(order.PromoCode, order.UserID)
+	}
+	if principal.APIKeyID != "" {
+		apiKeyStore.ReleaseBooking(principal.APIKeyID)
+	}
 }
 
+// HandleAvailability serves a cached body with an ETag, and 304 Not Modified
+// when If-None-Match still matches it, so polling clients cost next to nothing.
 func HandleAvailability(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	// quotes are signed for each request, so dynamically priced availability is never cached
+	if pricingEngine != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		response := model.AvailabilityResponse{
+			Success: true,
+			Message: "reserved seats retrieved successfully",
+			Tiers:   availabilityTiers(principal),
+		}
+		utils.WriteJSON(w, r, http.StatusOK, response)
+		return
+	}
+
+	entry, err := availability.encodeAvailability(r, principal)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", entry.etag)
+	w.Header().Set("Cache-Control", "no-cache") // revalidate every time, it's cheap
+	if utils.ETagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(entry.body)
+}
 
+// availabilityTiers lists every tier's price, sale state and free seats as the principal sees them.
+func availabilityTiers(principal auth.Principal) []model.TierInfo {
 	reservedSeats := bookingStore.GetReservedSeats()
+	now := clock.Now()
 
-	// Calculate available counts based on seat ranges:
+	// seats allocated to partners are only listed for the partner's own API key
+	sellableReserved := channelReservedSeats(principal, reservedSeats)
+
+	// Seat ranges per tier come from the seat layout, by default:
 	// VIP: seats 1-30 (30 seats total)
 	// FRONT_ROW: seats 31-60 (30 seats total)
 	// GA: seats 61-100 (40 seats total)
-	const (
-		VIPTotal      uint32 = 30 // seats 1-30
-		FrontRowTotal uint32 = 30 // seats 31-60
-		GATotal       uint32 = 40 // seats 61-100
-	)
+	tiers := make([]model.TierInfo, 0, len(model.AllTiers()))
+	for _, tier := range model.AllTiers() {
+		seatRange := tier.SeatRange()
+		reserved := sellableReserved[string(tier)]
 
-	vipReserved := uint32(len(reservedSeats["VIP"]))
-	frontRowReserved := uint32(len(reservedSeats["FRONT_ROW"]))
-	gaReserved := uint32(len(reservedSeats["GA"]))
+		saleWindow := saleSchedule.WindowFor(tier)
 
-	// Helper function to calculate available seats
-	calculateAvailableSeats := func(minSeat, maxSeat uint32, reserved []uint32) []uint32 {
-		reservedSet := make(map[uint32]bool)
-		for _, seat := range reserved {
-			reservedSet[seat] = true
+		tierInfo := model.TierInfo{
+			Tier:          tier,
+			Price:         utils.CalculateAmount(tier),
+			TotalSeats:    seatRange.Total(),
+			ReservedCount: uint32(len(reserved)), // number of seats reserved for this tier
+			AvailableList: calculateAvailableSeats(seatRange, reserved),
+			SaleState:     saleWindow.StateAt(now),
+			SaleChangesAt: saleWindow.NextChange(now),
 		}
-		var available []uint32
-		for i := minSeat; i <= maxSeat; i++ {
-			if !reservedSet[i] {
-				available = append(available, i)
-			}
+
+		// With dynamic pricing, every listed price comes with a quote the booking can honour
+		if pricingEngine != nil {
+			tierInfo.Price = currentPrice(tier, reservedSeats)
+			tierInfo.QuoteID, tierInfo.QuoteExpiresAt = signTierQuote(tier, tierInfo.Price)
 		}
-		return available
-	}
-
-	tiers := []model.TierInfo{
-		{
-			Tier:          model.TierVIP,
-			Price:         uint64(model.PriceVIPCents),
-			TotalSeats:    VIPTotal,
-			ReservedCount: vipReserved, // number of seats reserved for this tier
-			AvailableList: calculateAvailableSeats(1, 30, reservedSeats["VIP"]),
-		},
-		{
-			Tier:          model.TierFrontRow,
-			Price:         uint64(model.PriceFrontRowCents),
-			TotalSeats:    FrontRowTotal,
-			ReservedCount: frontRowReserved, // number of seats reserved for this tier
-			AvailableList: calculateAvailableSeats(31, 60, reservedSeats["FRONT_ROW"]),
-		},
-		{
-			Tier:          model.TierGA,
-			Price:         uint64(model.PriceGACents),
-			TotalSeats:    GATotal,
-			ReservedCount: gaReserved, // number of seats reserved for this tier
-			AvailableList: calculateAvailableSeats(61, 100, reservedSeats["GA"]),
-		},
-	}
-
-	response := model.AvailabilityResponse{
-		Success: true,
-		Message: "reserved seats retrieved successfully",
-		Tiers:   tiers,
+
+		tiers = append(tiers, tierInfo)
 	}
+	return tiers
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+// calculateAvailableSeats lists the seats of a range that aren't reserved.
+func calculateAvailableSeats(seatRange model.SeatRange, reserved []uint32) []uint32 {
+	reservedSet := make(map[uint32]bool, len(reserved))
+	for _, seat := range reserved {
+		reservedSet[seat] = true
+	}
+	var available []uint32
+	for i := seatRange.Min; i <= seatRange.Max; i++ {
+		if !reservedSet[i] {
+			available = append(available, i)
+		}
+	}
+	return available
 }
@@ -20,7 +20,7 @@ func BenchmarkHandleBooking(b *testing.B) {
 		UserID:         "bench-user",
 		Tier:           model.TierVIP,
 		SeatNo:         1,
-		Country:        "USA",
+		Country:        "US",
 		ZipCode:        "10001",
 		Currency:       "USD",
 		IdempotencyKey: "bench-key",
@@ -37,6 +37,7 @@ func BenchmarkHandleBooking(b *testing.B) {
 			// Use different seat numbers to avoid conflicts
 			order := bookingOrder
 			order.SeatNo = seatNo
+			order.Tier, _ = model.TierForSeat(order.SeatNo)
 			order.IdempotencyKey = "bench-key-" + string(rune(seatNo))
 			seatNo++
 			if seatNo > 100 {
@@ -64,9 +65,10 @@ func BenchmarkHandleAvailability(b *testing.B) {
 
 	// Pre-populate with some bookings
 	for i := uint32(1); i <= 10; i++ {
+		tier, _ := model.TierForSeat(i)
 		bookingStore.RegisterBooking(model.BookingOrder{
 			UserID:         "user-bench",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-bench",
 			PaymentID:      "pay-bench",
@@ -82,15 +84,44 @@ func BenchmarkHandleAvailability(b *testing.B) {
 	}
 }
 
+// BenchmarkHandleAvailability_Uncached rebuilds the response on every request, as before the cache
+func BenchmarkHandleAvailability_Uncached(b *testing.B) {
+	setupTestHandlers()
+
+	req := httptest.NewRequest(http.MethodGet, "/booking/availability", nil)
+
+	for b.Loop() {
+		availability = newAvailabilityCache()
+		w := httptest.NewRecorder()
+		HandleAvailability(w, req)
+	}
+}
+
+// BenchmarkHandleAvailability_NotModified benchmarks polling with If-None-Match
+func BenchmarkHandleAvailability_NotModified(b *testing.B) {
+	setupTestHandlers()
+
+	w := httptest.NewRecorder()
+	HandleAvailability(w, httptest.NewRequest(http.MethodGet, "/booking/availability", nil))
+	req := httptest.NewRequest(http.MethodGet, "/booking/availability", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+
+	for b.Loop() {
+		w := httptest.NewRecorder()
+		HandleAvailability(w, req)
+	}
+}
+
 // BenchmarkHandleAvailability_Parallel benchmarks HandleAvailability with parallel requests
 func BenchmarkHandleAvailability_Parallel(b *testing.B) {
 	setupTestHandlers()
 
 	// Pre-populate with bookings
 	for i := uint32(1); i <= 20; i++ {
+		tier, _ := model.TierForSeat(i)
 		bookingStore.RegisterBooking(model.BookingOrder{
 			UserID:         "user-bench",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-bench",
 			PaymentID:      "pay-bench",
@@ -117,7 +148,7 @@ func BenchmarkHandleBooking_P95(b *testing.B) {
 		UserID:         "p95-user",
 		Tier:           model.TierVIP,
 		SeatNo:         1,
-		Country:        "USA",
+		Country:        "US",
 		ZipCode:        "10001",
 		Currency:       "USD",
 		IdempotencyKey: "p95-key",
@@ -134,6 +165,7 @@ func BenchmarkHandleBooking_P95(b *testing.B) {
 	for i := 0; i < iterations; i++ {
 		order := bookingOrder
 		order.SeatNo = uint32(i%100 + 1)
+		order.Tier, _ = model.TierForSeat(order.SeatNo)
 		order.IdempotencyKey = "p95-key-" + string(rune(i))
 
 		body, _ := json.Marshal(order)
@@ -174,9 +206,10 @@ func BenchmarkHandleAvailability_P95(b *testing.B) {
 
 	// Pre-populate with bookings
 	for i := uint32(1); i <= 50; i++ {
+		tier, _ := model.TierForSeat(i)
 		bookingStore.RegisterBooking(model.BookingOrder{
 			UserID:         "user-p95",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-p95",
 			PaymentID:      "pay-p95",
@@ -227,12 +260,13 @@ func BenchmarkHandleBooking_Concurrent(b *testing.B) {
 		for pb.Next() {
 			userID++
 			seatNo := uint32(userID%100 + 1)
+			tier, _ := model.TierForSeat(seatNo)
 
 			order := model.BookingOrder{
 				UserID:         "concurrent-user-" + string(rune(userID)),
-				Tier:           model.TierVIP,
+				Tier:           tier,
 				SeatNo:         seatNo,
-				Country:        "USA",
+				Country:        "US",
 				ZipCode:        "10001",
 				Currency:       "USD",
 				IdempotencyKey: "concurrent-key-" + string(rune(userID)),
@@ -6,15 +6,36 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
 	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/pricing"
+	"github.com/ignius299792458/techkraft-ch-svr/sales"
 	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
 )
 
 // setupTestHandlers creates fresh instances for testing
 func setupTestHandlers() {
 	bookingStore = store.NewBookingStoreBucket()
 	idempotencyStore = store.NewIdempotencyBucket()
+	promoStore = store.NewPromoBucket()
+	adminAudit = store.NewAdminAuditBucket()
+	apiKeyStore = store.NewAPIKeyBucket()
+	bookingAudit = store.NewBookingAuditBucket()
+	connectSeatFeed()
+	connectBookingAudit()
+	availability = newAvailabilityCache()
+	pricingEngine = nil
+	charges = pricing.Charges{}
+	saleSchedule = sales.Schedule{}
+	clock = utils.SystemClock{}
+	drain = newDrainState()
+	seatMapTickets = auth.NewTickets(seatMapTicketTTL)
+	socketOrigins = []string{"*"}
+	readiness = newReadinessState()
+	fileStorage = nil
 }
 
 func TestHandleBooking(t *testing.T) {
@@ -33,7 +54,7 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-123",
 				Tier:           model.TierVIP,
 				SeatNo:         1,
-				Country:        "USA",
+				Country:        "US",
 				ZipCode:        "10001",
 				Currency:       "USD",
 				IdempotencyKey: "key-1",
@@ -49,8 +70,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-456",
 				Tier:           model.TierFrontRow,
 				SeatNo:         31,
-				Country:        "UK",
-				ZipCode:        "SW1A",
+				Country:        "GB",
+				ZipCode:        "SW1A 1AA",
 				Currency:       "GBP",
 				IdempotencyKey: "key-2",
 				PaymentID:      "pay-2",
@@ -58,6 +79,9 @@ func TestHandleBooking(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  "",
+			setupFunc: func() {
+				SetCharges(pricing.Charges{ExchangeRates: map[string]float64{"GBP": 1.25}})
+			},
 		},
 		{
 			name: "successful GA booking",
@@ -65,8 +89,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-789",
 				Tier:           model.TierGA,
 				SeatNo:         61,
-				Country:        "Canada",
-				ZipCode:        "M5H",
+				Country:        "CA",
+				ZipCode:        "M5H 2N2",
 				Currency:       "CAD",
 				IdempotencyKey: "key-3",
 				PaymentID:      "pay-3",
@@ -74,6 +98,9 @@ func TestHandleBooking(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 			expectedError:  "",
+			setupFunc: func() {
+				SetCharges(pricing.Charges{ExchangeRates: map[string]float64{"CAD": 1.25}})
+			},
 		},
 		{
 			name: "missing user_id",
@@ -81,6 +108,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "",
 				Tier:           model.TierVIP,
 				SeatNo:         1,
+				Country:        "US",
+				ZipCode:        "10001",
 				IdempotencyKey: "key-4",
 				PaymentStatus:  model.PaymentStatusPending,
 			},
@@ -93,6 +122,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:        "user-123",
 				Tier:          model.TierVIP,
 				SeatNo:        1,
+				Country:       "US",
+				ZipCode:       "10001",
 				PaymentStatus: model.PaymentStatusPending,
 			},
 			expectedStatus: http.StatusBadRequest,
@@ -104,6 +135,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-123",
 				Tier:           "INVALID_TIER",
 				SeatNo:         1,
+				Country:        "US",
+				ZipCode:        "10001",
 				IdempotencyKey: "key-5",
 				PaymentStatus:  model.PaymentStatusPending,
 			},
@@ -116,6 +149,8 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-123",
 				Tier:           model.TierVIP,
 				SeatNo:         0,
+				Country:        "US",
+				ZipCode:        "10001",
 				IdempotencyKey: "key-6",
 				PaymentStatus:  model.PaymentStatusPending,
 			},
@@ -128,7 +163,7 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-123",
 				Tier:           model.TierVIP,
 				SeatNo:         2,
-				Country:        "USA",
+				Country:        "US",
 				ZipCode:        "10001",
 				Currency:       "USD",
 				IdempotencyKey: "key-7",
@@ -155,7 +190,7 @@ func TestHandleBooking(t *testing.T) {
 				UserID:         "user-123",
 				Tier:           model.TierVIP,
 				SeatNo:         3,
-				Country:        "USA",
+				Country:        "US",
 				ZipCode:        "10001",
 				Currency:       "USD",
 				IdempotencyKey: "key-idempotent",
@@ -400,3 +435,411 @@ func TestHandleAvailability(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleBooking_PromoCode(t *testing.T) {
+	tests := []struct {
+		name           string
+		promoCode      string
+		expectedStatus int
+		expectedTotal  uint64
+		expectedError  string
+	}{
+		{
+			name:           "discount applied",
+			promoCode:      "presale20",
+			expectedStatus: http.StatusOK,
+			expectedTotal:  8000,
+		},
+		{
+			name:           "unknown promo code",
+			promoCode:      "NOPE",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "promo code not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			promoStore.RegisterPromo(model.PromoCode{
+				Code:         "PRESALE20",
+				DiscountType: model.DiscountTypePercent,
+				PercentOff:   20,
+				MaxUses:      1,
+			})
+
+			body, _ := json.Marshal(model.BookingOrder{
+				UserID:         "user-promo",
+				Tier:           model.TierVIP,
+				SeatNo:         5,
+				Country:        "US",
+				ZipCode:        "10001",
+				IdempotencyKey: "key-promo",
+				PromoCode:      tt.promoCode,
+				PaymentID:      "pay-promo",
+				PaymentStatus:  model.PaymentStatusConfirmed,
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body))
+			w := httptest.NewRecorder()
+
+			HandleBooking(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+
+			var response model.BookingResponse
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+
+			if tt.expectedError != "" {
+				if response.Message != tt.expectedError {
+					t.Errorf("Expected error message '%s', got '%s'", tt.expectedError, response.Message)
+				}
+				return
+			}
+			if response.Booking == nil {
+				t.Fatal("Expected booking in response")
+			}
+			if response.Booking.TotalAmtInUSCent != tt.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tt.expectedTotal, response.Booking.TotalAmtInUSCent)
+			}
+			if response.Booking.DiscountInUSCent != 10000-tt.expectedTotal {
+				t.Errorf("Expected discount %d, got %d", 10000-tt.expectedTotal, response.Booking.DiscountInUSCent)
+			}
+		})
+	}
+}
+
+func TestHandleBooking_PromoReleasedOnConflict(t *testing.T) {
+	setupTestHandlers()
+	promoStore.RegisterPromo(model.PromoCode{
+		Code:              "ONCE",
+		DiscountType:      model.DiscountTypeFixed,
+		AmountOffInUSCent: 500,
+		MaxUses:           1,
+	})
+	bookingStore.RegisterBooking(model.BookingOrder{
+		UserID:         "user-existing",
+		Tier:           model.TierVIP,
+		SeatNo:         6,
+		IdempotencyKey: "key-existing",
+	})
+
+	body, _ := json.Marshal(model.BookingOrder{
+		UserID:         "user-promo",
+		Tier:           model.TierVIP,
+		SeatNo:         6,
+		Country:        "US",
+		ZipCode:        "10001",
+		IdempotencyKey: "key-promo-conflict",
+		PromoCode:      "ONCE",
+		PaymentStatus:  model.PaymentStatusPending,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+
+	HandleBooking(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if uses, _ := promoStore.GetPromoUsage("ONCE"); uses != 0 {
+		t.Errorf("Expected promo redemption to be released, got %d uses", uses)
+	}
+}
+
+func TestHandleBooking_DynamicPricingQuote(t *testing.T) {
+	setupTestHandlers()
+	err := EnableDynamicPricing(pricing.Config{
+		Tiers: map[model.Tier]pricing.TierRule{
+			model.TierVIP: {BaseInUSCent: 10000, FloorInUSCent: 8000, CeilingInUSCent: 20000},
+		},
+		ScarcityWeight: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to enable dynamic pricing: %v", err)
+	}
+
+	// quote from availability
+	w := httptest.NewRecorder()
+	HandleAvailability(w, httptest.NewRequest(http.MethodGet, "/booking/availability", nil))
+	var availability model.AvailabilityResponse
+	json.NewDecoder(w.Body).Decode(&availability)
+	vipTier := availability.Tiers[0]
+	if vipTier.QuoteID == "" {
+		t.Fatal("Expected quote ID on VIP tier")
+	}
+
+	// inventory sells down, raising the current price
+	for seat := uint32(10); seat < 25; seat++ {
+		bookingStore.RegisterBooking(model.BookingOrder{
+			UserID:         "user-other",
+			Tier:           model.TierVIP,
+			SeatNo:         seat,
+			IdempotencyKey: "key-other",
+		})
+	}
+
+	book := func(quoteID, idempotencyKey string, seatNo uint32) (*httptest.ResponseRecorder, model.BookingResponse) {
+		body, _ := json.Marshal(model.BookingOrder{
+			UserID:         "user-quote",
+			Tier:           model.TierVIP,
+			SeatNo:         seatNo,
+			Country:        "US",
+			ZipCode:        "10001",
+			IdempotencyKey: idempotencyKey,
+			QuoteID:        quoteID,
+			PaymentID:      "pay-quote",
+			PaymentStatus:  model.PaymentStatusConfirmed,
+		})
+		w := httptest.NewRecorder()
+		HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+		var response model.BookingResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	w, response := book(vipTier.QuoteID, "key-quote", 1)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+	if response.Booking.TotalAmtInUSCent != vipTier.Price {
+		t.Errorf("Expected quoted price %d, got %d", vipTier.Price, response.Booking.TotalAmtInUSCent)
+	}
+
+	w, response = book("", "key-no-quote", 2)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+	if response.Booking.TotalAmtInUSCent <= vipTier.Price {
+		t.Errorf("Expected current price above %d, got %d", vipTier.Price, response.Booking.TotalAmtInUSCent)
+	}
+
+	w, response = book(vipTier.QuoteID+"x", "key-bad-quote", 3)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for tampered quote, got %d", http.StatusBadRequest, w.Code)
+	}
+	if response.Message != pricing.ErrQuoteInvalid.Error() {
+		t.Errorf("Expected error message '%s', got '%s'", pricing.ErrQuoteInvalid.Error(), response.Message)
+	}
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestHandleBooking_SaleSchedule(t *testing.T) {
+	presaleStart := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	generalSaleStart := presaleStart.Add(48 * time.Hour)
+
+	tests := []struct {
+		name           string
+		now            time.Time
+		accessCode     string
+		expectedStatus int
+		expectedError  string
+		expectedState  model.SaleState
+	}{
+		{
+			name:           "before presale",
+			now:            presaleStart.Add(-time.Hour),
+			accessCode:     "FANCLUB",
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "tier is not on sale yet",
+			expectedState:  model.SaleStateNotOnSale,
+		},
+		{
+			name:           "presale without access code",
+			now:            presaleStart.Add(time.Hour),
+			expectedStatus: http.StatusForbidden,
+			expectedError:  "presale access code required",
+			expectedState:  model.SaleStatePresale,
+		},
+		{
+			name:           "presale with access code",
+			now:            presaleStart.Add(time.Hour),
+			accessCode:     "FANCLUB",
+			expectedStatus: http.StatusOK,
+			expectedState:  model.SaleStatePresale,
+		},
+		{
+			name:           "general sale",
+			now:            generalSaleStart,
+			expectedStatus: http.StatusOK,
+			expectedState:  model.SaleStateOnSale,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			clock = &fixedClock{now: tt.now}
+			err := SetSaleSchedule(sales.Schedule{
+				Event: sales.Window{
+					PresaleStart:     presaleStart,
+					GeneralSaleStart: generalSaleStart,
+					AccessCodes:      []string{"FANCLUB"},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Failed to set sale schedule: %v", err)
+			}
+
+			// availability reports the sale state
+			w := httptest.NewRecorder()
+			HandleAvailability(w, httptest.NewRequest(http.MethodGet, "/booking/availability", nil))
+			var availability model.AvailabilityResponse
+			json.NewDecoder(w.Body).Decode(&availability)
+			for _, tier := range availability.Tiers {
+				if tier.SaleState != tt.expectedState {
+					t.Errorf("Expected %s sale state %s, got %s", tier.Tier, tt.expectedState, tier.SaleState)
+				}
+			}
+
+			body, _ := json.Marshal(model.BookingOrder{
+				UserID:         "user-presale",
+				Tier:           model.TierVIP,
+				SeatNo:         1,
+				Country:        "US",
+				ZipCode:        "10001",
+				IdempotencyKey: "key-presale",
+				AccessCode:     tt.accessCode,
+				PaymentID:      "pay-presale",
+				PaymentStatus:  model.PaymentStatusConfirmed,
+			})
+			w = httptest.NewRecorder()
+			HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if tt.expectedError != "" && response.Message != tt.expectedError {
+				t.Errorf("Expected error message '%s', got '%s'", tt.expectedError, response.Message)
+			}
+		})
+	}
+}
+
+func TestHandleBooking_AuthenticatedSubject(t *testing.T) {
+	setupTestHandlers()
+
+	body, _ := json.Marshal(model.BookingOrder{
+		UserID:         "someone-else",
+		Tier:           model.TierVIP,
+		SeatNo:         8,
+		Country:        "US",
+		ZipCode:        "10001",
+		IdempotencyKey: "key-auth",
+		PaymentID:      "pay-auth",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "user-from-token"}))
+	w := httptest.NewRecorder()
+
+	HandleBooking(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response model.BookingResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Booking.UserID != "user-from-token" {
+		t.Errorf("Expected UserID 'user-from-token', got '%s'", response.Booking.UserID)
+	}
+}
+
+func TestHandleBooking_ProblemDetails(t *testing.T) {
+	setupTestHandlers()
+
+	order := model.BookingOrder{
+		UserID:         "user-1",
+		Tier:           model.TierVIP,
+		SeatNo:         1,
+		Country:        "US",
+		ZipCode:        "10001",
+		Currency:       "USD",
+		IdempotencyKey: "problem-1",
+		PaymentID:      "pay-1",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+
+	send := func(order model.BookingOrder) (*httptest.ResponseRecorder, model.Problem) {
+		body, _ := json.Marshal(order)
+		w := httptest.NewRecorder()
+		HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+
+		var problem model.Problem
+		json.NewDecoder(w.Body).Decode(&problem)
+		return w, problem
+	}
+
+	if w, _ := send(order); w.Code != http.StatusOK {
+		t.Fatalf("Expected first booking to succeed, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name           string
+		modify         func(o *model.BookingOrder)
+		expectedStatus int
+		expectedCode   model.ErrorCode
+		expectedField  string
+	}{
+		{
+			name:           "seat taken",
+			modify:         func(o *model.BookingOrder) { o.IdempotencyKey = "problem-2"; o.UserID = "user-2" },
+			expectedStatus: http.StatusConflict,
+			expectedCode:   model.CodeSeatTaken,
+		},
+		{
+			name:           "idempotency key reused for another seat",
+			modify:         func(o *model.BookingOrder) { o.SeatNo = 2 },
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   model.CodeIdempotencyMismatch,
+		},
+		{
+			name:           "invalid tier",
+			modify:         func(o *model.BookingOrder) { o.IdempotencyKey = "problem-3"; o.Tier = "BALCONY" },
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   model.CodeValidationFailed,
+			expectedField:  "tier",
+		},
+		{
+			name:           "non-ISO country",
+			modify:         func(o *model.BookingOrder) { o.IdempotencyKey = "problem-4"; o.Country = "USA" },
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   model.CodeValidationFailed,
+			expectedField:  "country",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := order
+			tt.modify(&o)
+			w, problem := send(o)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("Expected problem+json, got '%s'", w.Header().Get("Content-Type"))
+			}
+			if problem.Code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, problem.Code)
+			}
+			if tt.expectedField != "" && (len(problem.Errors) == 0 || problem.Errors[0].Field != tt.expectedField) {
+				t.Errorf("Expected field error on '%s', got %+v", tt.expectedField, problem.Errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// Readiness checks main reports done with MarkReady
+const (
+	ReadyConfig = "config" // configuration and data files loaded
+	ReadyStore  = "store"  // storage backend opened, bookings recovered
+)
+
+// checkDrain is the readiness check failing once the server drains.
+const checkDrain = "drain"
+
+// readinessState tracks the startup steps the server waits for before it reports ready.
+type readinessState struct {
+	mu   sync.Mutex
+	done map[string]bool
+}
+
+var readiness = newReadinessState()
+
+func newReadinessState() *readinessState {
+	return &readinessState{done: map[string]bool{ReadyConfig: false, ReadyStore: false}}
+}
+
+// when the process started, for uptime
+var startedAt = time.Now()
+
+// MarkReady reports a readiness check done. The server reports ready once
+// every check is, until it drains.
+func MarkReady(check string) {
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+	readiness.done[check] = true
+}
+
+/*
+* readinessChecks runs the readiness checks.
+  - config and store stay pending until main marks them ready
+  - store fails while the file storage backend can't save its snapshot, or
+    the booking audit log can't be written, so no booking is confirmed that a
+    restart would lose or the audit log would miss
+  - drain fails once the server shuts down
+*/
+func readinessChecks() (checks map[string]string, ready bool) {
+	readiness.mu.Lock()
+	checks = make(map[string]string, len(readiness.done)+1)
+	for check, done := range readiness.done {
+		checks[check] = model.CheckPending
+		if done {
+			checks[check] = model.CheckOK
+		}
+	}
+	readiness.mu.Unlock()
+
+	if checks[ReadyStore] == model.CheckOK && fileStorage != nil {
+		if err := fileStorage.Err(); err != nil {
+			checks[ReadyStore] = err.Error()
+		}
+	}
+	if checks[ReadyStore] == model.CheckOK {
+		if err := bookingAudit.Err(); err != nil {
+			checks[ReadyStore] = "audit log: " + err.Error()
+		}
+	}
+
+	checks[checkDrain] = model.CheckOK
+	if Draining() {
+		checks[checkDrain] = model.CheckDrain
+	}
+
+	ready = true
+	for _, result := range checks {
+		ready = ready && result == model.CheckOK
+	}
+	return checks, ready
+}
+
+// HandleHealth answers 200 as long as the process serves requests (liveness):
+// a failing liveness probe gets the process restarted, so it checks nothing else.
+func HandleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, r, http.StatusOK, model.HealthResponse{Status: model.HealthOK})
+}
+
+// HandleReadiness answers 200 once the server has started, and a 503 problem
+// before, while the store can't be saved and once it drains, so load balancers
+// stop routing to it before its connections close.
+func HandleReadiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	checks, ready := readinessChecks()
+	if checks[checkDrain] != model.CheckOK {
+		utils.RespondError(w, r, ErrDraining)
+		return
+	}
+	if !ready {
+		var failing []string
+		for _, check := range slices.Sorted(maps.Keys(checks)) {
+			if checks[check] != model.CheckOK {
+				failing = append(failing, check+": "+checks[check])
+			}
+		}
+		utils.RespondError(w, r, model.NewError(model.CodeUnavailable, "not ready, "+strings.Join(failing, "; ")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	utils.WriteJSON(w, r, http.StatusOK, model.ReadinessResponse{Status: model.HealthReady, Checks: checks})
+}
+
+// HandleStatus reports the readiness checks, uptime, build and store sizes.
+// It answers 200 whatever the status, so it can be read while not ready.
+func HandleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	checks, ready := readinessChecks()
+	status := model.StatusResponse{
+		Status:        model.HealthReady,
+		Checks:        checks,
+		StartedAt:     startedAt.UTC(),
+		UptimeSeconds: int64(time.Since(startedAt) / time.Second),
+		Build:         buildInfo(),
+		Store:         bookingStore.Stats(),
+		Idempotency:   model.IdempotencyStats{Entries: idempotencyStore.Len()},
+	}
+	if !ready {
+		status.Status = model.HealthNotReady
+	}
+
+	status.Store.Backend = "memory"
+	if fileStorage != nil {
+		status.Store.Backend = "file"
+	}
+
+	utils.WriteJSON(w, r, http.StatusOK, status)
+}
+
+// buildInfo reads what the toolchain recorded about the binary, once.
+var buildInfo = sync.OnceValue(func() model.BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return model.BuildInfo{GoVersion: "unknown"}
+	}
+
+	build := model.BuildInfo{
+		GoVersion: info.GoVersion,
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.RevisionTime, _ = time.Parse(time.RFC3339, setting.Value)
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+})
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestHandleHealth(t *testing.T) {
+	setupTestHandlers()
+	Drain() // liveness doesn't care
+
+	w := httptest.NewRecorder()
+	HandleHealth(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"status":"ok"`) {
+		t.Errorf("Expected status ok, got %s", w.Body.String())
+	}
+}
+
+func TestHandleReadiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupFunc      func()
+		expectedStatus int
+		expectedDetail string
+	}{
+		{
+			name:           "starting",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "not ready, config: pending; store: pending",
+		},
+		{
+			name:           "config loaded, store not recovered",
+			setupFunc:      func() { MarkReady(ReadyConfig) },
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: "not ready, store: pending",
+		},
+		{
+			name:           "started",
+			setupFunc:      markStarted,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "draining",
+			setupFunc: func() {
+				markStarted()
+				Drain()
+				Drain() // twice is fine: both signals and tests may call it
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedDetail: ErrDraining.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			if tt.setupFunc != nil {
+				tt.setupFunc()
+			}
+
+			w := httptest.NewRecorder()
+			HandleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got '%s'", cacheControl)
+			}
+
+			if tt.expectedDetail == "" {
+				return
+			}
+			var problem model.Problem
+			json.NewDecoder(w.Body).Decode(&problem)
+			if problem.Code != model.CodeUnavailable || problem.Detail != tt.expectedDetail {
+				t.Errorf("Expected %s '%s', got %s '%s'", model.CodeUnavailable, tt.expectedDetail, problem.Code, problem.Detail)
+			}
+		})
+	}
+}
+
+func TestHandleReadiness_StoreNotSaved(t *testing.T) {
+	setupTestHandlers()
+	dir := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	storage, err := UseFileStorage(filepath.Join(dir, "bookings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	markStarted()
+
+	ready := func() int {
+		w := httptest.NewRecorder()
+		HandleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return w.Code
+	}
+	if status := ready(); status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+
+	// the snapshot's directory is gone: saving fails until it's back
+	os.RemoveAll(dir)
+	storage.Save()
+	if status := ready(); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d while the store can't be saved, got %d", http.StatusServiceUnavailable, status)
+	}
+
+	os.Mkdir(dir, 0o755)
+	storage.Save()
+	if status := ready(); status != http.StatusOK {
+		t.Errorf("Expected status %d once saved again, got %d", http.StatusOK, status)
+	}
+}
+
+func TestHandleStatus(t *testing.T) {
+	setupTestHandlers()
+	markStarted()
+
+	order := model.BookingOrder{UserID: "user-1", Tier: model.TierGA, SeatNo: 61, IdempotencyKey: "key-1"}
+	idempotencyStore.HandleIdempotency(order)
+	if _, err := bookingStore.RegisterBooking(order); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	HandleStatus(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var status model.StatusResponse
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != model.HealthReady {
+		t.Errorf("Expected status %s, got %s", model.HealthReady, status.Status)
+	}
+	if status.Store.Backend != "memory" || status.Store.Bookings != 1 {
+		t.Errorf("Expected 1 booking in memory, got %d in %s", status.Store.Bookings, status.Store.Backend)
+	}
+	if ga := status.Store.Seats[model.TierGA]; ga.Booked != 1 || ga.Available != ga.Total-1 {
+		t.Errorf("Expected 1 GA seat booked, got %+v", ga)
+	}
+	if status.Idempotency.Entries != 1 {
+		t.Errorf("Expected 1 idempotency entry, got %d", status.Idempotency.Entries)
+	}
+	if status.Build.GoVersion == "" {
+		t.Errorf("Expected the Go version in the build info")
+	}
+	if status.StartedAt.IsZero() || status.UptimeSeconds < 0 {
+		t.Errorf("Expected a start time and uptime, got %v and %d", status.StartedAt, status.UptimeSeconds)
+	}
+}
+
+// markStarted marks every startup readiness check done, as main does.
+func markStarted() {
+	MarkReady(ReadyConfig)
+	MarkReady(ReadyStore)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var ErrDraining = model.NewError(model.CodeUnavailable, "server is shutting down, retry on another instance")
+
+/*
+* drainState tracks the server shutting down.
+  - Drain turns readiness false and ends the long-lived streams (availability
+    events, seat map sockets); clients resume them elsewhere from their last event
+  - http.Server.Shutdown waits for in-flight requests, SSE streams included,
+    but not for hijacked connections, so seat map sockets are counted in sockets
+*/
+type drainState struct {
+	mu       sync.Mutex // orders Drain against sockets starting
+	draining atomic.Bool
+	done     chan struct{} // closed by Drain
+	sockets  sync.WaitGroup
+}
+
+var drain = newDrainState()
+
+func newDrainState() *drainState {
+	return &drainState{done: make(chan struct{})}
+}
+
+// Drain starts the shutdown: the server stops reporting ready and streams end.
+func Drain() {
+	drain.mu.Lock()
+	defer drain.mu.Unlock()
+
+	if !drain.draining.Load() {
+		drain.draining.Store(true)
+		close(drain.done)
+	}
+}
+
+// startSocket counts a seat map socket until its done func is called. It
+// refuses sockets once draining, so none starts while WaitStreams waits.
+func startSocket() (done func(), ok bool) {
+	drain.mu.Lock()
+	defer drain.mu.Unlock()
+
+	if drain.draining.Load() {
+		return nil, false
+	}
+	drain.sockets.Add(1)
+	return drain.sockets.Done, true
+}
+
+// Draining reports whether Drain was called.
+func Draining() bool {
+	return drain.draining.Load()
+}
+
+// WaitStreams waits for the seat map sockets to close after Drain, or for ctx to end.
+func WaitStreams(ctx context.Context) error {
+	state := drain
+	closed := make(chan struct{})
+	go func() {
+		state.sockets.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/websocket"
+)
+
+func TestDrain_EndsAvailabilityStreams(t *testing.T) {
+	setupTestHandlers()
+	server := httptest.NewServer(http.HandlerFunc(HandleAvailabilityStream))
+	t.Cleanup(server.Close) // runs after openStream closes the streams
+
+	stream := openStream(t, server.URL, "")
+	readSSE(t, stream) // snapshot
+
+	Drain()
+
+	ended := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(stream)
+		ended <- err
+	}()
+	select {
+	case err := <-ended:
+		if err != nil {
+			t.Errorf("Expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the stream to end on drain")
+	}
+
+	// new streams are turned away
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d while draining, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestDrain_ClosesSeatMapSockets(t *testing.T) {
+	setupTestHandlers()
+	server := httptest.NewServer(http.HandlerFunc(HandleSeatMapSocket))
+	t.Cleanup(server.Close) // runs after dialSeatMap closes the sockets
+
+	conn := dialSeatMap(t, server, "userId=alice")
+	data, err := conn.ReadMessage() // snapshot
+	if err != nil {
+		t.Fatalf("Expected the snapshot, got %v (%s)", err, data)
+	}
+
+	Drain()
+
+	var closeErr *websocket.CloseError
+	for !errors.As(err, &closeErr) {
+		if _, err = conn.ReadMessage(); err != nil && !errors.As(err, &closeErr) {
+			t.Fatalf("Expected a close frame, got %v", err)
+		}
+	}
+	if closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("Expected close code %d, got %d", websocket.CloseGoingAway, closeErr.Code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := WaitStreams(ctx); err != nil {
+		t.Errorf("Expected the sockets closed, got %v", err)
+	}
+
+	if _, err := websocket.Dial("ws"+server.URL[len("http"):]+"?userId=bob", nil); err == nil {
+		t.Errorf("Expected sockets refused while draining")
+	}
+}
+
+func TestWaitStreams_Deadline(t *testing.T) {
+	setupTestHandlers()
+
+	done, ok := startSocket()
+	if !ok {
+		t.Fatal("Expected a socket to start before draining")
+	}
+	defer done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := WaitStreams(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	Drain()
+	if _, ok := startSocket(); ok {
+		t.Errorf("Expected no socket to start while draining")
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	ErrNotOwnBookings  = model.NewError(model.CodeForbidden, "forbidden: can only list your own bookings")
+	ErrInvalidPageSize = model.NewError(model.CodeInvalidRequest, "limit must be between 1 and "+strconv.Itoa(maxPageSize))
+)
+
+// canViewBooking reports whether the caller may see the booking: its own user, an
+// admin, or the partner channel that sold it. Without authentication everyone can.
+func canViewBooking(principal auth.Principal, authenticated bool, booking model.Booking) bool {
+	switch {
+	case !authenticated, principal.HasRole(auth.RoleAdmin):
+		return true
+	case principal.APIKeyID != "":
+		return booking.Channel != "" && booking.Channel == principal.Channel
+	default:
+		return principal.Subject == booking.UserID
+	}
+}
+
+// HandleGetBooking returns a booking by its ID, e.g. from a confirmation email.
+func HandleGetBooking(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	bookingID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		utils.RespondError(w, r, ErrInvalidBookingID)
+		return
+	}
+
+	booking, err := bookingStore.GetBookingByID(bookingID)
+	principal, authenticated := auth.PrincipalFromContext(r.Context())
+
+	// someone else's booking is reported as missing, not forbidden
+	if err != nil || !canViewBooking(principal, authenticated, booking) {
+		utils.RespondError(w, r, store.ErrBookingNotFound)
+		return
+	}
+
+	utils.RespondSuccess(w, r, "booking retrieved successfully", &booking)
+}
+
+// HandleListUserBookings lists a user's bookings, oldest first, with
+// ?status= filtering and ?cursor= / ?limit= pagination. Like a booking lookup,
+// an API key only sees the bookings its channel sold.
+func HandleListUserBookings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter := model.BookingFilter{UserID: r.PathValue("userId")}
+	principal, authenticated := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !authenticated, principal.HasRole(auth.RoleAdmin):
+	case principal.APIKeyID != "":
+		if principal.Channel == "" {
+			utils.RespondError(w, r, ErrNotOwnBookings)
+			return
+		}
+		filter.Channel = principal.Channel
+	case principal.Subject != filter.UserID:
+		utils.RespondError(w, r, ErrNotOwnBookings)
+		return
+	}
+
+	query := r.URL.Query()
+	status := model.BookingStatus(strings.ToUpper(query.Get("status")))
+	filter.Status = status
+	if status != "" && !status.IsValidBookingStatus() {
+		utils.RespondError(w, r, ErrInvalidBookingStatus)
+		return
+	}
+
+	limit := defaultPageSize
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageSize {
+			utils.RespondError(w, r, ErrInvalidPageSize)
+			return
+		}
+		limit = parsed
+	}
+
+	bookings, nextCursor, err := bookingStore.ListUserBookings(filter, query.Get("cursor"), limit)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	utils.WriteJSON(w, r, http.StatusOK, model.UserBookingsResponse{
+		Success:    true,
+		Message:    "bookings retrieved successfully",
+		Bookings:   bookings,
+		NextCursor: nextCursor,
+	})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestHandleGetBooking(t *testing.T) {
+	setupTestHandlers()
+	booking := bookTicket(t, model.BookingOrder{
+		UserID: "user-1", Tier: model.TierVIP, SeatNo: 1, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "lookup-1", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	}).Booking
+
+	tests := []struct {
+		name           string
+		id             string
+		principal      *auth.Principal
+		expectedStatus int
+	}{
+		{name: "unauthenticated", id: booking.ID.String(), expectedStatus: http.StatusOK},
+		{name: "owner", id: booking.ID.String(), principal: &auth.Principal{Subject: "user-1"}, expectedStatus: http.StatusOK},
+		{name: "admin", id: booking.ID.String(), principal: &auth.Principal{Subject: "ops", Roles: []string{auth.RoleAdmin}}, expectedStatus: http.StatusOK},
+		{name: "another user", id: booking.ID.String(), principal: &auth.Principal{Subject: "user-2"}, expectedStatus: http.StatusNotFound},
+		{name: "partner that didn't sell it", id: booking.ID.String(), principal: &auth.Principal{APIKeyID: "boxoffice-a", Channel: "BOX_OFFICE_A"}, expectedStatus: http.StatusNotFound},
+		{name: "unknown booking", id: "7f1c1a52-3c8e-4c55-9b8c-0c6f0c1f6f00", expectedStatus: http.StatusNotFound},
+		{name: "invalid id", id: "seat-1", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/booking/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+			if tt.principal != nil {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), *tt.principal))
+			}
+			w := httptest.NewRecorder()
+
+			HandleGetBooking(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+			if tt.expectedStatus == http.StatusOK && response.Booking.ID != booking.ID {
+				t.Errorf("Expected booking %s, got %s", booking.ID, response.Booking.ID)
+			}
+		})
+	}
+}
+
+func TestHandleListUserBookings(t *testing.T) {
+	setupTestHandlers()
+	for i, seat := range []uint32{1, 2, 3} {
+		bookTicket(t, model.BookingOrder{
+			UserID: "user-1", Tier: model.TierVIP, SeatNo: seat, Country: "US", ZipCode: "10001", Currency: "USD",
+			IdempotencyKey: "list-" + string(rune('a'+i)), PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+		})
+	}
+
+	list := func(query string, principal *auth.Principal) (*httptest.ResponseRecorder, model.UserBookingsResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/users/user-1/bookings"+query, nil)
+		req.SetPathValue("userId", "user-1")
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *principal))
+		}
+		w := httptest.NewRecorder()
+		HandleListUserBookings(w, req)
+
+		var response model.UserBookingsResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	owner := &auth.Principal{Subject: "user-1"}
+	w, page := list("?limit=2", owner)
+	if w.Code != http.StatusOK || len(page.Bookings) != 2 || page.NextCursor == "" {
+		t.Fatalf("Expected first page of 2 with a cursor, got %d: %+v", w.Code, page)
+	}
+	_, page = list("?limit=2&cursor="+page.NextCursor, owner)
+	if len(page.Bookings) != 1 || page.Bookings[0].SeatNo != 3 || page.NextCursor != "" {
+		t.Errorf("Expected last page with seat 3, got %+v", page)
+	}
+
+	if _, page = list("?status=canceled", owner); len(page.Bookings) != 0 {
+		t.Errorf("Expected no canceled bookings, got %d", len(page.Bookings))
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		principal      *auth.Principal
+		expectedStatus int
+	}{
+		{name: "another user", principal: &auth.Principal{Subject: "user-2"}, expectedStatus: http.StatusForbidden},
+		{name: "admin", principal: &auth.Principal{Subject: "ops", Roles: []string{auth.RoleAdmin}}, expectedStatus: http.StatusOK},
+		{name: "partner", principal: &auth.Principal{APIKeyID: "boxoffice-a", Channel: "BOX_OFFICE_A"}, expectedStatus: http.StatusOK},
+		{name: "invalid status", query: "?status=LOST", principal: owner, expectedStatus: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=1000", principal: owner, expectedStatus: http.StatusBadRequest},
+		{name: "invalid cursor", query: "?cursor=bm9wZQ", principal: owner, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w, _ := list(tt.query, tt.principal); w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+	// a partner only sees the user's bookings its channel sold
+	sold, err := bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-1", Tier: model.TierVIP, SeatNo: 4, Channel: "BOX_OFFICE_A"})
+	if err != nil {
+		t.Fatalf("Expected a partner booking, got %v", err)
+	}
+	for _, principal := range []*auth.Principal{
+		{APIKeyID: "boxoffice-a", Channel: "BOX_OFFICE_A"},
+		{APIKeyID: "boxoffice-b", Channel: "BOX_OFFICE_B"},
+	} {
+		_, page := list("", principal)
+		expected := 0
+		if principal.Channel == sold.Channel {
+			expected = 1
+		}
+		if len(page.Bookings) != expected || (expected == 1 && page.Bookings[0].ID != sold.ID) {
+			t.Errorf("Expected %s to see %d of the user's bookings, got %+v", principal.Channel, expected, page.Bookings)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Booking outcomes, the outcome label of booking_outcomes_total
+const (
+	outcomeConfirmed     = "confirmed"
+	outcomePending       = "pending"           // seat taken, payment not confirmed
+	outcomePaymentFailed = "payment_failed"    // payment failed or canceled, seat given back
+	outcomeReplay        = "idempotent_replay" // a retry answered from the idempotency record
+	outcomeConflict      = "conflict"          // seat taken, blocked, held or allocated to a partner
+	outcomeValidation    = "validation_error"  // malformed or invalid request, bad promo code or quote
+	outcomeRejected      = "rejected"          // not on sale, quota or promo code used up, ...
+	outcomeError         = "error"             // server error
+)
+
+var bookingOutcomes = metrics.Default.CounterVec("booking_outcomes_total",
+	"Booking requests by tier and outcome.",
+	"tier", "outcome")
+
+func init() {
+	metrics.Default.GaugeFunc("booking_seats_sold",
+		"Seats booked per tier.",
+		func(report func(float64, ...string)) {
+			stats := bookingStore.Stats()
+			for _, tier := range model.AllTiers() {
+				report(float64(stats.Seats[tier].Booked), string(tier))
+			}
+		}, "tier")
+	metrics.Default.GaugeFunc("booking_seats_available",
+		"Seats on sale per tier: neither booked, blocked nor held.",
+		func(report func(float64, ...string)) {
+			stats := bookingStore.Stats()
+			for _, tier := range model.AllTiers() {
+				report(float64(stats.Seats[tier].Available), string(tier))
+			}
+		}, "tier")
+	metrics.Default.GaugeFunc("booking_idempotency_entries",
+		"Idempotency records kept.",
+		func(report func(float64, ...string)) {
+			report(float64(idempotencyStore.Len()))
+		})
+}
+
+// recordBookingOutcome counts a booking request. Tiers come from the request,
+// so unknown ones share a label.
+func recordBookingOutcome(ctx context.Context, tier model.Tier, outcome string) {
+	label := string(tier)
+	if !tier.IsValidTier() {
+		label = "unknown"
+	}
+	bookingOutcomes.With(label, outcome).Inc()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("booking.outcome", outcome))
+}
+
+// bookingStatusOutcome is the outcome of a booking registered with status.
+func bookingStatusOutcome(status model.BookingStatus) string {
+	switch status {
+	case model.BookingStatusConfirmed:
+		return outcomeConfirmed
+	case model.BookingStatusCanceled:
+		return outcomePaymentFailed
+	}
+	return outcomePending
+}
+
+// bookingErrorOutcome is the outcome of a booking refused with err: a conflict
+// over the seat, else by the status err is served with.
+func bookingErrorOutcome(err error) string {
+	var appErr *model.Error
+	if !errors.As(err, &appErr) {
+		return outcomeError
+	}
+	switch appErr.Code {
+	case model.CodeSeatTaken, model.CodeSeatBlocked, model.CodeSeatHeld, model.CodeSeatAllocated:
+		return outcomeConflict
+	}
+	switch status := appErr.Code.Status(); {
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity || status == http.StatusRequestEntityTooLarge:
+		return outcomeValidation
+	case status >= http.StatusInternalServerError:
+		return outcomeError
+	}
+	return outcomeRejected
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestHandleBooking_Outcomes(t *testing.T) {
+	setupTestHandlers()
+
+	order := model.BookingOrder{
+		UserID:         "user-metrics",
+		Tier:           model.TierGA,
+		SeatNo:         80,
+		Country:        "US",
+		ZipCode:        "10001",
+		IdempotencyKey: "key-metrics",
+		PaymentID:      "pay-metrics",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+	conflicting := order
+	conflicting.UserID, conflicting.IdempotencyKey = "user-other", "key-metrics-other"
+	pending := order
+	pending.SeatNo, pending.IdempotencyKey, pending.PaymentStatus = 81, "key-metrics-pending", model.PaymentStatusPending
+	invalid := order
+	invalid.Tier, invalid.IdempotencyKey = "BALCONY", "key-metrics-invalid"
+
+	tests := []struct {
+		name            string
+		body            model.BookingOrder
+		expectedTier    string
+		expectedOutcome string
+	}{
+		{name: "confirmed", body: order, expectedTier: "GA", expectedOutcome: outcomeConfirmed},
+		{name: "retry", body: order, expectedTier: "GA", expectedOutcome: outcomeReplay},
+		{name: "seat taken", body: conflicting, expectedTier: "GA", expectedOutcome: outcomeConflict},
+		{name: "pending payment", body: pending, expectedTier: "GA", expectedOutcome: outcomePending},
+		{name: "unknown tier", body: invalid, expectedTier: "unknown", expectedOutcome: outcomeValidation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := bookingOutcomes.With(tt.expectedTier, tt.expectedOutcome)
+			before := counter.Value()
+
+			body, _ := json.Marshal(tt.body)
+			HandleBooking(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewReader(body)))
+
+			if got := counter.Value() - before; got != 1 {
+				t.Errorf("Expected 1 %s booking of tier %s, got %d", tt.expectedOutcome, tt.expectedTier, got)
+			}
+		})
+	}
+}
+
+func TestMetrics_SeatGauges(t *testing.T) {
+	setupTestHandlers()
+	bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-1", Tier: model.TierVIP, SeatNo: 1, IdempotencyKey: "key-1"})
+	idempotencyStore.HandleIdempotency(model.BookingOrder{UserID: "user-1", Tier: model.TierVIP, SeatNo: 1, IdempotencyKey: "key-1"})
+
+	w := httptest.NewRecorder()
+	metrics.Default.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	for _, expected := range []string{
+		`booking_seats_sold{tier="VIP"} 1`,
+		`booking_seats_available{tier="VIP"} 29`,
+		`booking_seats_available{tier="GA"} 40`,
+		"booking_idempotency_entries 1",
+		"# TYPE booking_lock_wait_seconds histogram",
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected %s in:\n%s", expected, w.Body.String())
+		}
+	}
+}
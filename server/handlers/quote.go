@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/pricing"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+var (
+	// fees, tax and exchange rates; the zero value charges the bare ticket price in USD
+	charges pricing.Charges
+
+	// signs quote IDs; the random default secret doesn't survive a restart
+	quoteSigner *pricing.QuoteSigner
+
+	// nil unless dynamic pricing is enabled
+	pricingEngine *pricing.Engine
+)
+
+func init() {
+	quoteSigner = pricing.NewQuoteSigner([]byte(rand.Text()), clockNow)
+}
+
+// SetQuoteSecret sets the HMAC secret quote IDs are signed with.
+func SetQuoteSecret(secret []byte) {
+	quoteSigner = pricing.NewQuoteSigner(secret, clockNow)
+}
+
+func clockNow() time.Time {
+	return clock.Now()
+}
+
+// SetCharges sets the fees, tax and exchange rates used for quotes and bookings.
+func SetCharges(c pricing.Charges) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	charges = c
+	return nil
+}
+
+// EnableDynamicPricing switches tier prices from the fixed constants to the pricing engine.
+func EnableDynamicPricing(cfg pricing.Config) error {
+	engine, err := pricing.NewEngine(cfg)
+	if err != nil {
+		return err
+	}
+	pricingEngine = engine
+	return nil
+}
+
+// currentPrice returns the tier price right now: the fixed price, or the dynamic price.
+func currentPrice(tier model.Tier, reservedSeats map[string][]uint32) uint64 {
+	if pricingEngine == nil {
+		return utils.CalculateAmount(tier)
+	}
+	available, total := tierInventory(tier, reservedSeats)
+	return pricingEngine.Price(tier, available, total)
+}
+
+// signTierQuote signs a tier-level quote for a price listed in availability.
+func signTierQuote(tier model.Tier, price uint64) (string, time.Time) {
+	expiresAt := clock.Now().Add(charges.QuoteTTL()).Unix()
+	quoteID := quoteSigner.Sign(pricing.Quote{
+		Tier:          tier,
+		PriceInUSCent: price,
+		ExpiresAt:     expiresAt,
+	})
+	return quoteID, time.Unix(expiresAt, 0).UTC()
+}
+
+// priceBooking returns the base price to charge for a booking order, honouring
+// its quote ID if it has one. The quote is returned so its breakdown can be honoured too.
+func priceBooking(req *model.BookingOrder) (uint64, *pricing.Quote, error) {
+	if req.QuoteID == "" {
+		return currentPrice(req.Tier, bookingStore.GetReservedSeats()), nil, nil
+	}
+
+	quote, err := quoteSigner.Parse(req.QuoteID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !quote.Matches(*req) {
+		return 0, nil, pricing.ErrQuoteMismatch
+	}
+	return quote.PriceInUSCent, &quote, nil
+}
+
+// bookingBreakdown returns the final price of a booking order. Seat quotes are
+// charged as quoted; otherwise fees and tax are applied to the base price, and
+// it is converted to the order's currency as a quote would, or rejected when
+// the currency isn't supported.
+func bookingBreakdown(order model.BookingOrder, discount uint64, quote *pricing.Quote) (model.PriceBreakdown, error) {
+	if quote != nil && quote.Breakdown != nil {
+		return *quote.Breakdown, nil
+	}
+	return charges.Breakdown(order.TotalAmtInUSCent, discount, order.Country, order.Currency)
+}
+
+// tierInventory returns the available and total seat counts of a tier.
+func tierInventory(tier model.Tier, reservedSeats map[string][]uint32) (uint32, uint32) {
+	total := tier.SeatRange().Total()
+	reserved := uint32(len(reservedSeats[string(tier)]))
+	if reserved > total {
+		return 0, total
+	}
+	return total - reserved, total
+}
+
+func HandleQuote(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req model.QuoteRequest
+	if err := utils.DecodeJSON(w, r, &req); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+	if err := utils.ValidateQuoteRequest(&req); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+	if _, err := bookingStore.GetBooking(req.SeatNo); err == nil {
+		utils.RespondError(w, r, store.ErrSeatAlreadyBooked)
+		return
+	}
+	principal, _ := auth.PrincipalFromContext(r.Context())
+	if err := checkChannelSeat(principal, req.SeatNo); err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	now := clock.Now()
+	basePrice := currentPrice(req.Tier, bookingStore.GetReservedSeats())
+
+	// promo is checked but not redeemed until booking
+	promoCode := model.NormalizePromoCode(req.PromoCode)
+	var discount uint64
+	if promoCode != "" {
+		var err error
+		userID, _ := auth.Subject(r.Context())
+		discount, err = promoStore.PreviewPromo(promoCode, userID, req.Tier, basePrice, now)
+		if err != nil {
+			utils.RespondError(w, r, err)
+			return
+		}
+	}
+
+	breakdown, err := charges.Breakdown(basePrice, discount, req.Country, req.Currency)
+	if err != nil {
+		utils.RespondError(w, r, err)
+		return
+	}
+
+	expiresAt := now.Add(charges.QuoteTTL())
+	quoteID := quoteSigner.Sign(pricing.Quote{
+		Tier:          req.Tier,
+		PriceInUSCent: basePrice,
+		SeatNo:        req.SeatNo,
+		PromoCode:     promoCode,
+		Country:       pricing.NormalizeCode(req.Country),
+		Breakdown:     &breakdown,
+		ExpiresAt:     expiresAt.Unix(),
+	})
+
+	utils.WriteJSON(w, r, http.StatusOK, model.QuoteResponse{
+		Success: true,
+		Message: "price quote created",
+		Quote: &model.PriceQuote{
+			QuoteID:   quoteID,
+			ExpiresAt: time.Unix(expiresAt.Unix(), 0).UTC(),
+			Tier:      req.Tier,
+			SeatNo:    req.SeatNo,
+			PromoCode: promoCode,
+			Breakdown: breakdown,
+		},
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/pricing"
+)
+
+func requestQuote(t *testing.T, quoteReq model.QuoteRequest) (*httptest.ResponseRecorder, model.QuoteResponse) {
+	t.Helper()
+	body, _ := json.Marshal(quoteReq)
+	w := httptest.NewRecorder()
+	HandleQuote(w, httptest.NewRequest(http.MethodPost, "/booking/quote", bytes.NewBuffer(body)))
+
+	var response model.QuoteResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return w, response
+}
+
+func TestHandleQuote(t *testing.T) {
+	tests := []struct {
+		name           string
+		request        model.QuoteRequest
+		setupFunc      func()
+		expectedStatus int
+		expectedError  string
+		expected       model.PriceBreakdown
+	}{
+		{
+			name:           "breakdown with fees, tax, discount and conversion",
+			request:        model.QuoteRequest{Tier: model.TierVIP, SeatNo: 1, PromoCode: "save10", Country: "US", Currency: "EUR"},
+			expectedStatus: http.StatusOK,
+			// subtotal 9000, fees 100, tax 0.1*9100
+			expected: model.PriceBreakdown{BaseInUSCent: 10000, DiscountInUSCent: 1000, FeesInUSCent: 100, TaxInUSCent: 910, TotalInUSCent: 10010, Currency: "EUR", ExchangeRate: 0.5, TotalInCurrency: 5005},
+		},
+		{
+			name:           "invalid tier",
+			request:        model.QuoteRequest{Tier: "BALCONY", SeatNo: 1},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "invalid tier",
+		},
+		{
+			name:           "seat of another tier",
+			request:        model.QuoteRequest{Tier: model.TierGA, SeatNo: 1},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "seat_no 1 is not a GA seat",
+		},
+		{
+			name:           "unsupported currency",
+			request:        model.QuoteRequest{Tier: model.TierVIP, SeatNo: 1, Currency: "JPY"},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  pricing.ErrUnsupportedCurrency.Error(),
+		},
+		{
+			name:           "unknown promo code",
+			request:        model.QuoteRequest{Tier: model.TierVIP, SeatNo: 1, PromoCode: "NOPE"},
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "promo code not found",
+		},
+		{
+			name:    "seat already booked",
+			request: model.QuoteRequest{Tier: model.TierVIP, SeatNo: 2},
+			setupFunc: func() {
+				bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-existing", Tier: model.TierVIP, SeatNo: 2, IdempotencyKey: "key-existing"})
+			},
+			expectedStatus: http.StatusConflict,
+			expectedError:  "seat already booked",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			SetCharges(pricing.Charges{
+				ServiceFeeInUSCent: 100,
+				TaxRates:           map[string]float64{"US": 0.1},
+				ExchangeRates:      map[string]float64{"EUR": 0.5},
+			})
+			promoStore.RegisterPromo(model.PromoCode{Code: "SAVE10", DiscountType: model.DiscountTypePercent, PercentOff: 10})
+			if tt.setupFunc != nil {
+				tt.setupFunc()
+			}
+
+			w, response := requestQuote(t, tt.request)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedError != "" {
+				if response.Message != tt.expectedError {
+					t.Errorf("Expected error message '%s', got '%s'", tt.expectedError, response.Message)
+				}
+				return
+			}
+			if response.Quote == nil {
+				t.Fatal("Expected quote in response")
+			}
+			if response.Quote.QuoteID == "" {
+				t.Error("Expected quote ID")
+			}
+			if response.Quote.Breakdown != tt.expected {
+				t.Errorf("Expected breakdown %+v, got %+v", tt.expected, response.Quote.Breakdown)
+			}
+		})
+	}
+}
+
+func TestHandleBooking_WithQuote(t *testing.T) {
+	setupTestHandlers()
+	SetCharges(pricing.Charges{ServiceFeeInUSCent: 100, TaxRates: map[string]float64{"US": 0.1}})
+
+	_, quoteResponse := requestQuote(t, model.QuoteRequest{Tier: model.TierGA, SeatNo: 61, Country: "US", Currency: "USD"})
+	quote := quoteResponse.Quote
+
+	book := func(quoteID string, seatNo uint32, idempotencyKey string) (*httptest.ResponseRecorder, model.BookingResponse) {
+		body, _ := json.Marshal(model.BookingOrder{
+			UserID:         "user-quote",
+			Tier:           model.TierGA,
+			SeatNo:         seatNo,
+			Country:        "US",
+			ZipCode:        "10001",
+			Currency:       "USD",
+			IdempotencyKey: idempotencyKey,
+			QuoteID:        quoteID,
+			PaymentID:      "pay-quote",
+			PaymentStatus:  model.PaymentStatusConfirmed,
+		})
+		w := httptest.NewRecorder()
+		HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+		var response model.BookingResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w, response
+	}
+
+	w, response := book(quote.QuoteID, 62, "key-other-seat")
+	if w.Code != http.StatusBadRequest || response.Message != pricing.ErrQuoteMismatch.Error() {
+		t.Errorf("Expected %d '%s' for another seat, got %d '%s'", http.StatusBadRequest, pricing.ErrQuoteMismatch.Error(), w.Code, response.Message)
+	}
+
+	w, response = book(quote.QuoteID[:len(quote.QuoteID)-2], 61, "key-tampered")
+	if w.Code != http.StatusBadRequest || response.Message != pricing.ErrQuoteInvalid.Error() {
+		t.Errorf("Expected %d '%s' for tampered quote, got %d '%s'", http.StatusBadRequest, pricing.ErrQuoteInvalid.Error(), w.Code, response.Message)
+	}
+
+	// charges change after quoting, but the quote holds
+	SetCharges(pricing.Charges{ServiceFeeInUSCent: 500})
+
+	w, response = book(quote.QuoteID, 61, "key-quoted")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, response.Message)
+	}
+	if response.Booking.TotalAmtInUSCent != quote.Breakdown.TotalInUSCent {
+		t.Errorf("Expected quoted total %d, got %d", quote.Breakdown.TotalInUSCent, response.Booking.TotalAmtInUSCent)
+	}
+	if response.Booking.FeesInUSCent != 100 || response.Booking.TaxInUSCent != 110 {
+		t.Errorf("Expected fees 100 and tax 110, got %d and %d", response.Booking.FeesInUSCent, response.Booking.TaxInUSCent)
+	}
+}
+
+func TestHandleBooking_CurrencyWithoutQuote(t *testing.T) {
+	tests := []struct {
+		name           string
+		currency       string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"supported currency", "EUR", http.StatusOK, ""},
+		{"unsupported currency", "JPY", http.StatusBadRequest, pricing.ErrUnsupportedCurrency.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTestHandlers()
+			SetCharges(pricing.Charges{ServiceFeeInUSCent: 100, TaxRates: map[string]float64{"US": 0.1}, ExchangeRates: map[string]float64{"EUR": 0.5}})
+
+			body, _ := json.Marshal(model.BookingOrder{
+				UserID:         "user-currency",
+				Tier:           model.TierGA,
+				SeatNo:         61,
+				Country:        "US",
+				ZipCode:        "10001",
+				Currency:       tt.currency,
+				IdempotencyKey: "key-" + tt.currency,
+				PaymentID:      "pay-currency",
+				PaymentStatus:  model.PaymentStatusConfirmed,
+			})
+			w := httptest.NewRecorder()
+			HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewBuffer(body)))
+			var response model.BookingResponse
+			json.NewDecoder(w.Body).Decode(&response)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, response.Message)
+			}
+			if response.Message != tt.expectedError && tt.expectedError != "" {
+				t.Errorf("Expected error message '%s', got '%s'", tt.expectedError, response.Message)
+			}
+			if tt.expectedError == "" && (response.Booking == nil || response.Booking.FeesInUSCent != 100) {
+				t.Errorf("Expected a booking charged the same fees as a quote, got %+v", response.Booking)
+			}
+			if tt.expectedError != "" {
+				if _, err := bookingStore.GetBooking(61); err == nil {
+					t.Error("Expected the seat left free")
+				}
+			}
+		})
+	}
+}
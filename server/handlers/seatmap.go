@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+	"github.com/ignius299792458/techkraft-ch-svr/websocket"
+)
+
+const (
+	// how long a seat stays held without being booked or held again
+	seatHoldTTL = 2 * time.Minute
+
+	// pings keep the socket open through proxies and detect dead clients
+	seatMapPingInterval = 30 * time.Second
+	seatMapPongWait     = 2 * seatMapPingInterval
+
+	// long enough to open the socket right after asking for the ticket
+	seatMapTicketTTL = 30 * time.Second
+)
+
+// ErrHolderRequired rejects anonymous seat map sockets, whose holds would have no one to book them.
+var ErrHolderRequired = utils.NewValidationError("userId", model.CodeRequired, "userId is required")
+
+var (
+	// tickets open seat map sockets for callers authenticated by a header
+	seatMapTickets = auth.NewTickets(seatMapTicketTTL)
+
+	// pages that may open seat map sockets, the CORS allow-list
+	socketOrigins = []string{"*"}
+)
+
+// SeatMapTickets is the ticket store the authentication middleware redeems seat map tickets from.
+func SeatMapTickets() *auth.Tickets {
+	return seatMapTickets
+}
+
+// SetSocketOrigins sets the origins whose pages may open seat map sockets, e.g. "https://tickets.example.com", or "*" for any.
+func SetSocketOrigins(origins []string) {
+	socketOrigins = origins
+}
+
+// HandleSeatMapTicket issues a one-time ticket that opens a seat map socket as the caller.
+func HandleSeatMapTicket(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		// without authentication there are no credentials to stand in for: connect with ?userId=
+		utils.RespondError(w, r, auth.ErrTokenMissing)
+		return
+	}
+
+	ticket, expiresAt := seatMapTickets.Issue(principal)
+	utils.WriteJSON(w, r, http.StatusOK, model.SeatMapTicketResponse{
+		Success:   true,
+		Ticket:    ticket,
+		ExpiresAt: expiresAt,
+	})
+}
+
+/*
+* HandleSeatMapSocket upgrades to a WebSocket for live seat selection.
+  - the socket subscribes to the seat map at once: a "snapshot" message (skipped
+    when ?lastEventId= resumes), then a "seat" message for every seat change
+  - clients send {"type":"hold","seatNo":N} and {"type":"release","seatNo":N};
+    a hold is answered with "held", a release with "released", a failure with
+    "error" right away, e.g. SEAT_HELD or SEAT_TAKEN when someone else has it
+  - a hold that runs out is reported as an "error" with HOLD_EXPIRED
+  - browsers authenticate with a ?ticket= from HandleSeatMapTicket, since
+    they can't send headers with the upgrade
+  - the holder is the authenticated user; partner API keys name their
+    customer with ?userId=, as in their bookings, and so does every client
+    when authentication is off. A socket only releases the holds it took, and
+    they end when it closes
+  - a client too slow to keep up with seat changes is closed with 1013 and
+    resumes from its last event ID, as is every client with 1001 when the
+    server drains
+*/
+func HandleSeatMapSocket(w http.ResponseWriter, r *http.Request) {
+	// hijacked once upgraded, so the server's shutdown doesn't wait for it
+	socketDone, ok := startSocket()
+	if !ok {
+		utils.RespondError(w, r, ErrDraining)
+		return
+	}
+	defer socketDone()
+
+	principal, authenticated := auth.PrincipalFromContext(r.Context())
+	holderID := principal.Subject
+	if !authenticated || principal.APIKeyID != "" {
+		holderID = r.URL.Query().Get("userId")
+	}
+	if holderID == "" {
+		utils.RespondError(w, r, ErrHolderRequired)
+		return
+	}
+
+	conn, err := websocket.Accept(w, r, socketOrigins)
+	if err != nil {
+		slog.WarnContext(r.Context(), "seat map upgrade failed", "err", err)
+		return
+	}
+
+	session := &seatMapSession{
+		ctx:       r.Context(),
+		conn:      conn,
+		principal: principal,
+		holderID:  holderID,
+		replies:   make(chan model.SeatMapMessage, 16),
+		drained:   drain.done,
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		holds:     make(map[uint32]bool),
+	}
+
+	replay, resumed, cursor, events, cancel := seatFeed.Subscribe(r.URL.Query().Get("lastEventId"))
+	defer cancel()
+
+	go session.readCommands()
+	session.writeEvents(replay, resumed, cursor, events)
+
+	// the writer closed the socket, which ends the reader; a hold it is taking
+	// must be recorded before the holds are given back, or it would outlive the socket
+	<-session.done
+	session.releaseHolds()
+}
+
+// seatMapSession is one seat map socket: a reader goroutine turns commands
+// into replies, the handler's goroutine writes replies and seat changes.
+type seatMapSession struct {
+	ctx       context.Context // the upgrade request's, so logs carry its ID
+	conn      *websocket.Conn
+	principal auth.Principal
+	holderID  string
+
+	replies chan model.SeatMapMessage
+	drained <-chan struct{} // closed once the server drains
+	done    chan struct{}   // closed once the client is gone
+	stopped chan struct{}   // closed once the writer gave up
+
+	mu    sync.Mutex
+	holds map[uint32]bool // seats held through this socket
+}
+
+func (s *seatMapSession) writeEvents(replay []model.SeatEvent, resumed bool, cursor string, events <-chan model.SeatEvent) {
+	defer close(s.stopped)
+	defer s.conn.Close(websocket.CloseNormal, "")
+
+	if !resumed {
+		snapshot := model.SeatMapMessage{
+			Type: model.SeatMapSnapshot,
+			ID:   cursor,
+			Availability: &model.AvailabilityResponse{
+				Success: true,
+				Tiers:   availabilityTiers(s.principal),
+			},
+		}
+		if !s.send(snapshot) {
+			return
+		}
+	}
+	for _, event := range replay {
+		if !s.sendEvent(event) {
+			return
+		}
+	}
+
+	ping := time.NewTicker(seatMapPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.drained:
+			s.conn.Close(websocket.CloseGoingAway, "server restarting, resume from the last event")
+			return
+		case event, open := <-events:
+			if !open {
+				// dropped by the seat feed as too slow
+				s.conn.Close(websocket.CloseTryAgainLater, "too slow, resume from the last event")
+				return
+			}
+			if !s.sendEvent(event) {
+				return
+			}
+		case reply := <-s.replies:
+			if !s.send(reply) {
+				return
+			}
+		case <-ping.C:
+			if s.conn.Ping() != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendEvent forwards a seat change, and tells the client when it ended one of its holds.
+func (s *seatMapSession) sendEvent(event model.SeatEvent) bool {
+	if checkChannelSeat(s.principal, event.SeatNo) != nil {
+		return true // partners always see another partner's seats as taken
+	}
+
+	s.mu.Lock()
+	held := s.holds[event.SeatNo]
+	if held && event.State != model.SeatStateHeld {
+		delete(s.holds, event.SeatNo)
+	}
+	s.mu.Unlock()
+
+	if !s.send(model.SeatMapMessage{Type: model.SeatMapSeat, ID: event.ID, Event: &event}) {
+		return false
+	}
+	// only the holder can book a held seat, so only a release can end it unasked
+	if held && event.State == model.SeatStateReleased {
+		return s.send(model.SeatMapMessage{
+			Type:   model.SeatMapError,
+			SeatNo: event.SeatNo,
+			Code:   model.CodeHoldExpired,
+			Detail: "hold expired",
+		})
+	}
+	return true
+}
+
+func (s *seatMapSession) send(message model.SeatMapMessage) bool {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		slog.ErrorContext(s.ctx, "failed to encode seat map message", "type", message.Type, "err", err)
+		return true
+	}
+	return s.conn.WriteText(payload) == nil
+}
+
+// readCommands runs until the client goes away. A client that sends commands
+// faster than their replies can be written waits for the writer, whose writes
+// time out on a client that stopped reading.
+func (s *seatMapSession) readCommands() {
+	defer close(s.done)
+
+	for {
+		s.conn.SetReadDeadline(time.Now().Add(seatMapPongWait))
+		message, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var command model.SeatMapCommand
+		reply := model.SeatMapMessage{Type: model.SeatMapError, Code: model.CodeInvalidRequest, Detail: "invalid command"}
+		if json.Unmarshal(message, &command) == nil {
+			reply = s.handleCommand(command)
+		}
+
+		select {
+		case s.replies <- reply:
+		case <-s.stopped:
+			return
+		}
+	}
+}
+
+func (s *seatMapSession) handleCommand(command model.SeatMapCommand) model.SeatMapMessage {
+	switch command.Type {
+	case model.SeatMapHold:
+		hold, err := s.hold(command)
+		if err != nil {
+			return seatMapError(s.ctx, command.SeatNo, err)
+		}
+		return model.SeatMapMessage{Type: model.SeatMapHeld, SeatNo: hold.SeatNo, Hold: &hold}
+
+	case model.SeatMapRelease:
+		// forget the hold first, so its RELEASED event isn't taken for an expiry
+		s.mu.Lock()
+		held := s.holds[command.SeatNo]
+		delete(s.holds, command.SeatNo)
+		s.mu.Unlock()
+
+		// another socket of the same holder can't release this one's holds
+		if !held {
+			return seatMapError(s.ctx, command.SeatNo, store.ErrHoldNotFound)
+		}
+		if err := bookingStore.ReleaseHold(command.SeatNo, s.holderID); err != nil {
+			return seatMapError(s.ctx, command.SeatNo, err)
+		}
+		return model.SeatMapMessage{Type: model.SeatMapReleased, SeatNo: command.SeatNo}
+
+	default:
+		return model.SeatMapMessage{Type: model.SeatMapError, Code: model.CodeInvalidRequest, Detail: "unknown command type"}
+	}
+}
+
+// hold applies the checks of a booking that can be made before checkout, then holds the seat.
+func (s *seatMapSession) hold(command model.SeatMapCommand) (model.SeatHold, error) {
+	if !s.principal.HasScope(model.ScopeBookingWrite) {
+		return model.SeatHold{}, model.NewError(model.CodeForbidden, "forbidden: "+string(model.ScopeBookingWrite)+" scope required")
+	}
+	tier, valid := model.TierForSeat(command.SeatNo)
+	if !valid {
+		return model.SeatHold{}, store.ErrInvalidSeatNumber
+	}
+	if err := checkChannelSeat(s.principal, command.SeatNo); err != nil {
+		return model.SeatHold{}, err
+	}
+	if err := saleSchedule.CheckBookable(tier, command.AccessCode, clock.Now()); err != nil {
+		return model.SeatHold{}, err
+	}
+
+	hold, err := bookingStore.HoldSeat(command.SeatNo, s.holderID, seatHoldTTL)
+	if err != nil {
+		return model.SeatHold{}, err
+	}
+
+	s.mu.Lock()
+	s.holds[command.SeatNo] = true
+	s.mu.Unlock()
+	return hold, nil
+}
+
+// releaseHolds gives back the seats still held through the socket once both its goroutines are done.
+func (s *seatMapSession) releaseHolds() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for seatNo := range s.holds {
+		bookingStore.ReleaseHold(seatNo, s.holderID)
+		delete(s.holds, seatNo)
+	}
+}
+
+func seatMapError(ctx context.Context, seatNo uint32, err error) model.SeatMapMessage {
+	var apiErr *model.Error
+	if !errors.As(err, &apiErr) {
+		slog.ErrorContext(ctx, "seat map command failed", "seat", seatNo, "err", err)
+		apiErr = model.NewError(model.CodeInternal, "internal error")
+	}
+	return model.SeatMapMessage{Type: model.SeatMapError, SeatNo: seatNo, Code: apiErr.Code, Detail: apiErr.Message}
+}
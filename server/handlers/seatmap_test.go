@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/store"
+	"github.com/ignius299792458/techkraft-ch-svr/websocket"
+)
+
+// seatMapServer serves handler until the test ends, then waits for its sockets
+// to finish, so they don't outlive the handlers' state.
+func seatMapServer(t *testing.T, handler http.Handler) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(func() { // runs after dialSeatMap closes the sockets
+		server.Close()
+		WaitStreams(context.Background())
+	})
+	return server
+}
+
+func dialSeatMap(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	conn, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"?"+query, nil)
+	if err != nil {
+		t.Fatalf("Expected the socket to open, got %v", err)
+	}
+	t.Cleanup(func() { conn.Close(websocket.CloseNormal, "") })
+	return conn
+}
+
+// nextSeatMapMessage skips messages of other types until one of messageType arrives.
+func nextSeatMapMessage(t *testing.T, conn *websocket.Conn, messageType model.SeatMapMessageType) model.SeatMapMessage {
+	t.Helper()
+	for {
+		data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("Expected a %s message, got %v", messageType, err)
+		}
+		var message model.SeatMapMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			t.Fatalf("Expected a JSON message, got %s", data)
+		}
+		if message.Type == messageType {
+			return message
+		}
+	}
+}
+
+func sendSeatMapCommand(t *testing.T, conn *websocket.Conn, command model.SeatMapCommand) {
+	t.Helper()
+	data, _ := json.Marshal(command)
+	if err := conn.WriteText(data); err != nil {
+		t.Fatalf("Expected the command to be sent, got %v", err)
+	}
+}
+
+func TestHandleSeatMapSocket(t *testing.T) {
+	setupTestHandlers()
+	server := seatMapServer(t, http.HandlerFunc(HandleSeatMapSocket))
+
+	alice := dialSeatMap(t, server, "userId=alice")
+	bob := dialSeatMap(t, server, "userId=bob")
+	if snapshot := nextSeatMapMessage(t, alice, model.SeatMapSnapshot); snapshot.Availability == nil {
+		t.Fatal("Expected the snapshot to carry the availability")
+	}
+	nextSeatMapMessage(t, bob, model.SeatMapSnapshot)
+
+	sendSeatMapCommand(t, alice, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 61})
+	held := nextSeatMapMessage(t, alice, model.SeatMapHeld)
+	if held.Hold == nil || held.Hold.SeatNo != 61 || held.Hold.HolderID != "alice" {
+		t.Errorf("Expected alice to hold seat 61, got %+v", held.Hold)
+	}
+
+	seen := nextSeatMapMessage(t, bob, model.SeatMapSeat)
+	if seen.Event.SeatNo != 61 || seen.Event.State != model.SeatStateHeld {
+		t.Errorf("Expected bob to see seat 61 HELD, got %+v", seen.Event)
+	}
+
+	sendSeatMapCommand(t, bob, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 61})
+	if failed := nextSeatMapMessage(t, bob, model.SeatMapError); failed.Code != model.CodeSeatHeld || failed.SeatNo != 61 {
+		t.Errorf("Expected bob's hold to fail with SEAT_HELD, got %+v", failed)
+	}
+
+	body, _ := json.Marshal(model.BookingOrder{
+		UserID: "bob", Tier: model.TierGA, SeatNo: 61, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "seatmap-bob", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+	w := httptest.NewRecorder()
+	HandleBooking(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewReader(body)))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected bob's booking of a held seat to fail with 409, got %d", w.Code)
+	}
+
+	sendSeatMapCommand(t, alice, model.SeatMapCommand{Type: model.SeatMapRelease, SeatNo: 61})
+	nextSeatMapMessage(t, alice, model.SeatMapReleased)
+	if seen := nextSeatMapMessage(t, bob, model.SeatMapSeat); seen.Event.State != model.SeatStateReleased {
+		t.Errorf("Expected bob to see seat 61 RELEASED, got %+v", seen.Event)
+	}
+
+	sendSeatMapCommand(t, bob, model.SeatMapCommand{Type: "teleport"})
+	if failed := nextSeatMapMessage(t, bob, model.SeatMapError); failed.Code != model.CodeInvalidRequest {
+		t.Errorf("Expected an unknown command to fail with INVALID_REQUEST, got %+v", failed)
+	}
+}
+
+func TestHandleSeatMapSocket_ReleasesHoldsOnClose(t *testing.T) {
+	setupTestHandlers()
+	server := seatMapServer(t, http.HandlerFunc(HandleSeatMapSocket))
+
+	alice := dialSeatMap(t, server, "userId=alice")
+	bob := dialSeatMap(t, server, "userId=bob")
+
+	sendSeatMapCommand(t, alice, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 62})
+	nextSeatMapMessage(t, alice, model.SeatMapHeld)
+	alice.Close(websocket.CloseGoingAway, "")
+
+	for {
+		seen := nextSeatMapMessage(t, bob, model.SeatMapSeat)
+		if seen.Event.SeatNo == 62 && seen.Event.State == model.SeatStateReleased {
+			break
+		}
+	}
+}
+
+// blockingHoldStore stops a hold until proceed is closed, so a test can act while it is being taken.
+type blockingHoldStore struct {
+	store.BookingStore
+	holding chan struct{}
+	proceed chan struct{}
+	held    chan struct{}
+}
+
+func (s blockingHoldStore) HoldSeat(seatNo uint32, holderID string, ttl time.Duration) (model.SeatHold, error) {
+	close(s.holding)
+	<-s.proceed
+	defer close(s.held)
+	return s.BookingStore.HoldSeat(seatNo, holderID, ttl)
+}
+
+func TestHandleSeatMapSocket_ClosesDuringHold(t *testing.T) {
+	setupTestHandlers()
+	bucket := bookingStore
+	blocking := blockingHoldStore{BookingStore: bucket, holding: make(chan struct{}), proceed: make(chan struct{}), held: make(chan struct{})}
+	bookingStore = blocking
+
+	handled := make(chan struct{})
+	server := seatMapServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		HandleSeatMapSocket(w, r)
+		close(handled)
+	}))
+
+	alice := dialSeatMap(t, server, "userId=alice")
+	sendSeatMapCommand(t, alice, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 63})
+	<-blocking.holding
+
+	// the writer closes the socket while the reader is still taking the hold
+	Drain()
+	for {
+		if _, err := alice.ReadMessage(); err != nil {
+			break
+		}
+	}
+	close(blocking.proceed)
+	<-blocking.held
+
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the socket to finish once the hold was taken")
+	}
+	if _, err := bucket.HoldSeat(63, "bob", seatHoldTTL); err != nil {
+		t.Errorf("Expected the hold taken while closing to be released, got %v", err)
+	}
+}
+
+func TestHandleSeatMapSocket_ReleasesOwnHoldsOnly(t *testing.T) {
+	setupTestHandlers()
+	server := seatMapServer(t, http.HandlerFunc(HandleSeatMapSocket))
+
+	alice := dialSeatMap(t, server, "userId=alice")
+	impostor := dialSeatMap(t, server, "userId=alice")
+
+	sendSeatMapCommand(t, alice, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 64})
+	nextSeatMapMessage(t, alice, model.SeatMapHeld)
+
+	sendSeatMapCommand(t, impostor, model.SeatMapCommand{Type: model.SeatMapRelease, SeatNo: 64})
+	if failed := nextSeatMapMessage(t, impostor, model.SeatMapError); failed.Code != model.CodeHoldNotFound {
+		t.Errorf("Expected another socket's release to fail with HOLD_NOT_FOUND, got %+v", failed)
+	}
+	if _, err := bookingStore.HoldSeat(64, "bob", seatHoldTTL); !errors.Is(err, store.ErrSeatHeld) {
+		t.Errorf("Expected seat 64 still held by alice, got %v", err)
+	}
+}
+
+func TestHandleSeatMapSocket_Ticket(t *testing.T) {
+	setupTestHandlers()
+	verifier, err := auth.NewVerifier(auth.Config{HS256Secret: []byte("seatmap-test-secret-of-32-bytes!")})
+	if err != nil {
+		t.Fatalf("Expected a verifier, got %v", err)
+	}
+	server := seatMapServer(t, auth.Authenticate(verifier, apiKeyStore, SeatMapTickets())(http.HandlerFunc(HandleSeatMapSocket)))
+
+	// the ticket handler trades the caller's credentials for a ticket
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/booking/seatmap/ticket", nil)
+	HandleSeatMapTicket(w, req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "carol"})))
+	var response model.SeatMapTicketResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Ticket == "" {
+		t.Fatalf("Expected a ticket, got %d %+v", w.Code, response)
+	}
+
+	// ?userId= can't name someone else once authenticated
+	carol := dialSeatMap(t, server, "ticket="+response.Ticket+"&userId=mallory")
+	sendSeatMapCommand(t, carol, model.SeatMapCommand{Type: model.SeatMapHold, SeatNo: 65})
+	if held := nextSeatMapMessage(t, carol, model.SeatMapHeld); held.Hold == nil || held.Hold.HolderID != "carol" {
+		t.Errorf("Expected carol to hold seat 65, got %+v", held.Hold)
+	}
+
+	for _, query := range []string{"userId=mallory", "ticket=" + response.Ticket} {
+		if _, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"?"+query, nil); !errors.Is(err, websocket.ErrNotWebSocket) {
+			t.Errorf("Expected %s to be refused, got %v", query, err)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	HandleSeatMapTicket(w, httptest.NewRequest(http.MethodPost, "/booking/seatmap/ticket", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without credentials, got %d", w.Code)
+	}
+}
+
+func TestHandleSeatMapSocket_RequiresHolder(t *testing.T) {
+	setupTestHandlers()
+
+	w := httptest.NewRecorder()
+	HandleSeatMapSocket(w, httptest.NewRequest(http.MethodGet, "/booking/seatmap", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+const (
+	// comment lines keep idle streams open through proxies
+	streamHeartbeat = 15 * time.Second
+
+	// how long EventSource clients wait before reconnecting
+	streamRetry = 3 * time.Second
+)
+
+/*
+* HandleAvailabilityStream pushes seat changes as Server-Sent Events.
+  - event "snapshot": the full availability, sent first unless the client resumes
+  - event "seat": one model.SeatEvent (BOOKED, HELD or RELEASED)
+  - clients reconnect with Last-Event-ID (or ?lastEventId=) and get the events
+    they missed, or a fresh snapshot if those are no longer kept
+  - the stream ends when the server drains, and EventSource reconnects
+*/
+func HandleAvailabilityStream(w http.ResponseWriter, r *http.Request) {
+	if Draining() {
+		utils.RespondError(w, r, ErrDraining)
+		return
+	}
+	drained := drain.done
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+
+	replay, resumed, cursor, events, cancel := seatFeed.Subscribe(lastEventID)
+	defer cancel()
+
+	// streams outlive the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	principal, _ := auth.PrincipalFromContext(r.Context())
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if !resumed {
+		writeSSE(r.Context(), w, cursor, "snapshot", utils.AdaptResponse(utils.APIVersionFromContext(r.Context()), model.AvailabilityResponse{
+			Success: true,
+			Tiers:   availabilityTiers(principal),
+		}))
+	}
+	for _, event := range replay {
+		writeSeatEvent(r.Context(), w, principal, event)
+	}
+	if err := controller.Flush(); err != nil {
+		slog.WarnContext(r.Context(), "availability stream cannot flush", "err", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-drained:
+			return
+		case event, open := <-events:
+			if !open {
+				return // dropped as too slow; the client resumes from its last event
+			}
+			writeSeatEvent(r.Context(), w, principal, event)
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeSeatEvent sends a seat change, unless the seat is allocated to another
+// partner: the principal always sees those seats as taken.
+func writeSeatEvent(ctx context.Context, w io.Writer, principal auth.Principal, event model.SeatEvent) {
+	if checkChannelSeat(principal, event.SeatNo) != nil {
+		return
+	}
+	writeSSE(ctx, w, event.ID, "seat", event)
+}
+
+func writeSSE(ctx context.Context, w io.Writer, id, event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode stream event", "event", event, "err", err)
+		return
+	}
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, payload)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+// readSSE parses the events of a stream, skipping retry and comment lines.
+func readSSE(t *testing.T, stream *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Expected another event, got %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if event.event != "" {
+				return event
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			event.data = value
+		}
+	}
+}
+
+func openStream(t *testing.T, url, lastEventID string) *bufio.Reader {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected the stream to open, got %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, got %s", contentType)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestHandleAvailabilityStream(t *testing.T) {
+	setupTestHandlers()
+	server := httptest.NewServer(http.HandlerFunc(HandleAvailabilityStream))
+	t.Cleanup(server.Close) // runs after openStream closes the streams
+
+	stream := openStream(t, server.URL, "")
+	snapshot := readSSE(t, stream)
+	if snapshot.event != "snapshot" {
+		t.Fatalf("Expected a snapshot first, got %s", snapshot.event)
+	}
+	var availability model.AvailabilityResponse
+	if err := json.Unmarshal([]byte(snapshot.data), &availability); err != nil || len(availability.Tiers) != len(model.AllTiers()) {
+		t.Errorf("Expected the snapshot to list every tier, got %s", snapshot.data)
+	}
+
+	bookTicket(t, model.BookingOrder{
+		UserID: "user-1", Tier: model.TierGA, SeatNo: 61, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "stream-1", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+
+	booked := readSSE(t, stream)
+	var event model.SeatEvent
+	json.Unmarshal([]byte(booked.data), &event)
+	if booked.event != "seat" || event.SeatNo != 61 || event.State != model.SeatStateBooked {
+		t.Errorf("Expected seat 61 BOOKED, got %s %s", booked.event, booked.data)
+	}
+	if booked.id == snapshot.id || booked.id != event.ID {
+		t.Errorf("Expected the event ID %s to follow the snapshot %s", booked.id, snapshot.id)
+	}
+
+	bookTicket(t, model.BookingOrder{
+		UserID: "user-2", Tier: model.TierGA, SeatNo: 62, Country: "US", ZipCode: "10001", Currency: "USD",
+		IdempotencyKey: "stream-2", PaymentID: "pay-2", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+
+	t.Run("resume replays missed events without a snapshot", func(t *testing.T) {
+		resumed := readSSE(t, openStream(t, server.URL, booked.id))
+		json.Unmarshal([]byte(resumed.data), &event)
+		if resumed.event != "seat" || event.SeatNo != 62 {
+			t.Errorf("Expected seat 62 to be replayed, got %s %s", resumed.event, resumed.data)
+		}
+	})
+
+	t.Run("unknown last event falls back to a snapshot", func(t *testing.T) {
+		fresh := readSSE(t, openStream(t, server.URL, "0-1"))
+		if fresh.event != "snapshot" {
+			t.Errorf("Expected a snapshot, got %s", fresh.event)
+		}
+	})
+}
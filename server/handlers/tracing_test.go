@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// tracedBooking serves a traced booking request and returns its spans by
+// name, with their attributes.
+func tracedBooking(t *testing.T, order model.BookingOrder) map[string]tracetest.SpanStub {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer provider.Shutdown(context.Background())
+
+	body, _ := json.Marshal(order)
+	handler := tracing.Middleware(provider)(http.HandlerFunc(HandleBooking))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/booking/ticket", bytes.NewReader(body)))
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		if _, seen := spans[span.Name]; !seen {
+			spans[span.Name] = span
+		}
+	}
+	return spans
+}
+
+// spanAttributes returns a span's attributes by key.
+func spanAttributes(span tracetest.SpanStub) map[string]any {
+	attrs := make(map[string]any, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return attrs
+}
+
+func TestHandleBooking_Spans(t *testing.T) {
+	setupTestHandlers()
+
+	order := model.BookingOrder{
+		UserID:         "user-tracing",
+		Tier:           model.TierVIP,
+		SeatNo:         7,
+		Country:        "US",
+		ZipCode:        "10001",
+		IdempotencyKey: "key-tracing",
+		PaymentID:      "pay-tracing",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+
+	spans := tracedBooking(t, order)
+	server := spans["POST"]
+	for _, name := range []string{"booking.decode", "idempotency.HandleIdempotency", "store.RegisterBooking"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("Expected a %s span, got %v", name, spans)
+		}
+		if span.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Errorf("Expected %s to be a child of the request's span", name)
+		}
+	}
+
+	attrs := spanAttributes(server)
+	if attrs["booking.seat_no"] != int64(7) || attrs["booking.tier"] != "VIP" || attrs["booking.outcome"] != outcomeConfirmed {
+		t.Errorf("Expected seat, tier and outcome on the request's span, got %v", attrs)
+	}
+	store := spanAttributes(spans["store.RegisterBooking"])
+	if store["booking.status"] != string(model.BookingStatusConfirmed) || store["lock.wait_ms"] != float64(0) {
+		t.Errorf("Expected the booking status and lock wait on the store span, got %v", store)
+	}
+	if replay := spanAttributes(spans["idempotency.HandleIdempotency"])["booking.idempotent_replay"]; replay != false {
+		t.Errorf("Expected a first request not to be a replay, got %v", replay)
+	}
+
+	// the retry replays, and never reaches the store
+	spans = tracedBooking(t, order)
+	if replay := spanAttributes(spans["idempotency.HandleIdempotency"])["booking.idempotent_replay"]; replay != true {
+		t.Errorf("Expected the retry to be a replay, got %v", replay)
+	}
+	if _, ok := spans["store.RegisterBooking"]; ok {
+		t.Errorf("Expected a replay not to register a booking")
+	}
+}
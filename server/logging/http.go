@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// RequestIDHeader carries a request's ID, from the caller or assigned here.
+const RequestIDHeader = "X-Request-ID"
+
+// longest request ID taken from a caller
+const maxRequestIDLength = 128
+
+/*
+* Middleware gives every request an ID and logs it once served.
+  - a caller's X-Request-ID is kept, so a request is found by the same ID in
+    every service it went through; a missing or unusable one is replaced by a UUID
+  - the ID is sent back in X-Request-ID and put in the request's context,
+    where ContextHandler adds it to log records
+  - with an accessLog logger, a line per request records its method, route
+    pattern, status, bytes written and duration; without one nothing is logged
+*/
+func Middleware(accessLog *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			id := r.Header.Get(RequestIDHeader)
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+			w.Header().Set(RequestIDHeader, id)
+			r = r.WithContext(WithRequestID(r.Context(), id))
+
+			if accessLog == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r, route := utils.TrackRoute(r)
+			recorder := utils.RecordResponse(w)
+
+			next.ServeHTTP(recorder, r)
+
+			accessLog.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("method", r.Method),
+				slog.String("route", route.Pattern),
+				slog.Int("status", recorder.Status()),
+				slog.Int64("bytes", recorder.Bytes()),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			)
+		})
+	}
+}
+
+// validRequestID accepts up to 128 visible ASCII characters, which log as they are.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
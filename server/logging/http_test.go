@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+func TestMiddleware_RequestID(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectedID string // "" for a new UUID
+	}{
+		{name: "propagated", header: "edge-7f3a:42", expectedID: "edge-7f3a:42"},
+		{name: "missing"},
+		{name: "spaces", header: "edge 42"},
+		{name: "control characters", header: "edge\n42"},
+		{name: "too long", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			handler := Middleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/booking/availability", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.expectedID != "" && id != tt.expectedID {
+				t.Errorf("Expected request ID %q, got %q", tt.expectedID, id)
+			}
+			if tt.expectedID == "" && uuid.Validate(id) != nil {
+				t.Errorf("Expected a new UUID, got %q", id)
+			}
+			if contextID != id {
+				t.Errorf("Expected the context to carry %q, got %q", id, contextID)
+			}
+		})
+	}
+}
+
+func TestMiddleware_AccessLog(t *testing.T) {
+	var out bytes.Buffer
+	accessLog := slog.New(NewHandler(&out, FormatJSON, slog.LevelInfo))
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /booking/{id}", utils.Route("GET /booking/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":404}`))
+	})))
+	handler := Middleware(accessLog)(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/booking/0b6c2a58-8d4e-4bcb-9a57-5d0c1c1d2f11", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a JSON line, got %q", out.String())
+	}
+	for key, expected := range map[string]any{
+		"msg":        "request",
+		"method":     http.MethodGet,
+		"route":      "/booking/{id}",
+		"status":     float64(404),
+		"bytes":      float64(14),
+		"request_id": "req-1",
+	} {
+		if record[key] != expected {
+			t.Errorf("Expected %s %v, got %v", key, expected, record[key])
+		}
+	}
+	if _, ok := record["duration_ms"].(float64); !ok {
+		t.Errorf("Expected the duration, got %v", record["duration_ms"])
+	}
+}
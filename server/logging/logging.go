@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log formats
+const (
+	FormatText = "text" // key=value pairs
+	FormatJSON = "json" // a JSON object per line
+)
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying a request's ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID of the request ctx belongs to, "" outside requests.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewHandler returns a handler writing records of level and above to w in
+// format, FormatText or FormatJSON, with the request ID of their context.
+func NewHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	options := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return ContextHandler{slog.NewJSONHandler(w, options)}
+	}
+	return ContextHandler{slog.NewTextHandler(w, options)}
+}
+
+/*
+* ContextHandler adds what a record's context knows about its request:
+  - request_id, set by Middleware
+  - trace_id and span_id, when the request is traced
+  - only records logged with a context carry them: use slog.InfoContext(r.Context(), ...)
+    and friends in request handlers
+*/
+type ContextHandler struct {
+	slog.Handler
+}
+
+func (h ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h ContextHandler) WithGroup(name string) slog.Handler {
+	return ContextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestContextHandler(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	defer provider.Shutdown(context.Background())
+	tracedCtx, span := provider.Tracer("test").Start(WithRequestID(context.Background(), "req-1"), "POST /booking/ticket")
+	defer span.End()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected map[string]any
+	}{
+		{
+			name:     "outside requests",
+			ctx:      context.Background(),
+			expected: map[string]any{"request_id": nil, "trace_id": nil},
+		},
+		{
+			name:     "request",
+			ctx:      WithRequestID(context.Background(), "req-1"),
+			expected: map[string]any{"request_id": "req-1", "trace_id": nil},
+		},
+		{
+			name: "traced request",
+			ctx:  tracedCtx,
+			expected: map[string]any{
+				"request_id": "req-1",
+				"trace_id":   span.SpanContext().TraceID().String(),
+				"span_id":    span.SpanContext().SpanID().String(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(NewHandler(&out, FormatJSON, slog.LevelInfo)).With("component", "test")
+			logger.InfoContext(tt.ctx, "Booking created", "seat", 7)
+
+			var record map[string]any
+			if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+				t.Fatalf("Expected a JSON record, got %q", out.String())
+			}
+			if record["msg"] != "Booking created" || record["seat"] != float64(7) || record["component"] != "test" {
+				t.Errorf("Expected the record's own attributes, got %v", record)
+			}
+			for key, value := range tt.expected {
+				if record[key] != value {
+					t.Errorf("Expected %s %v, got %v", key, value, record[key])
+				}
+			}
+		})
+	}
+}
+
+func TestNewHandler_Text(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(NewHandler(&out, FormatText, slog.LevelWarn))
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "dropped")
+	logger.WarnContext(WithRequestID(context.Background(), "req-1"), "rejected API key")
+
+	if strings.Contains(out.String(), "dropped") {
+		t.Errorf("Expected records below the level dropped, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), `msg="rejected API key" request_id=req-1`) {
+		t.Errorf("Expected a text record with the request ID, got %q", out.String())
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// request latency buckets, in seconds; 0.3 is the p99 target
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.2, 0.3, 0.5, 1, 2.5, 5, 10}
+
+var (
+	httpRequests = Default.CounterVec("http_requests_total",
+		"HTTP requests by route pattern, method and status.",
+		"route", "method", "status")
+	httpDuration = Default.HistogramVec("http_request_duration_seconds",
+		"HTTP request latency by route pattern, method and status. Streams and WebSockets count until they close.",
+		latencyBuckets, "route", "method", "status")
+)
+
+/*
+* Middleware counts and times every request by route pattern and status.
+  - it must wrap the outermost mux, so it sees every request
+  - routes name themselves with utils.Route, so paths with IDs share a series;
+    requests no route serves (404, 405) are labeled "unmatched"
+*/
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, route := utils.TrackRoute(r)
+		recorder := utils.RecordResponse(w)
+
+		next.ServeHTTP(recorder, r)
+
+		status := strconv.Itoa(recorder.Status())
+		httpRequests.With(route.Pattern, route.Method, status).Inc()
+		httpDuration.With(route.Pattern, route.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /booking/{id}", utils.Route("GET /booking/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})))
+	mux.Handle("/users/", utils.Route("/users/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}")) // implicit 200
+	})))
+	handler := Middleware(mux)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedRoute  string
+		expectedMethod string
+		expectedStatus string
+	}{
+		{
+			name:           "route pattern, not the path",
+			method:         http.MethodGet,
+			path:           "/booking/0b6c2a58-8d4e-4bcb-9a57-5d0c1c1d2f11",
+			expectedRoute:  "/booking/{id}",
+			expectedMethod: http.MethodGet,
+			expectedStatus: "404",
+		},
+		{
+			name:           "prefix route takes the request method",
+			method:         http.MethodPost,
+			path:           "/users/u-1/bookings",
+			expectedRoute:  "/users/",
+			expectedMethod: http.MethodPost,
+			expectedStatus: "200",
+		},
+		{
+			name:           "nonstandard method",
+			method:         "BREW",
+			path:           "/users/u-1/bookings",
+			expectedRoute:  "/users/",
+			expectedMethod: "other",
+			expectedStatus: "200",
+		},
+		{
+			name:           "no route",
+			method:         http.MethodGet,
+			path:           "/wp-login.php",
+			expectedRoute:  utils.UnmatchedRoute,
+			expectedMethod: http.MethodGet,
+			expectedStatus: "404",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := httpRequests.With(tt.expectedRoute, tt.expectedMethod, tt.expectedStatus)
+			histogram := httpDuration.With(tt.expectedRoute, tt.expectedMethod, tt.expectedStatus)
+			before, observed := counter.Value(), histogram.Count()
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+
+			if got := counter.Value() - before; got != 1 {
+				t.Errorf("Expected 1 request counted, got %d", got)
+			}
+			if got := histogram.Count() - observed; got != 1 {
+				t.Errorf("Expected 1 latency observed, got %d", got)
+			}
+		})
+	}
+}
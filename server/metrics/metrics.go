@@ -0,0 +1,330 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+* Registry holds metrics and writes them in the Prometheus text format (0.0.4).
+  - counters and histograms are updated with atomics, so recording never waits
+    on a scrape
+  - gauges are functions called at scrape time, reading state the server keeps anyway
+  - a labeled series is created on first use; label values must come from a
+    small, fixed set (tiers, route patterns, status codes), never from requests
+*/
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer, name string)
+}
+
+// Default is the registry the server's metrics are defined in and /metrics serves.
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds a metric; names are fixed in code, so a duplicate is a bug.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.metrics[name]; exists {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.metrics[name] = m
+}
+
+// WriteTo writes every metric, sorted by name.
+func (r *Registry) WriteTo(out io.Writer) (int64, error) {
+	r.mu.Lock()
+	names := slices.Sorted(maps.Keys(r.metrics))
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	counter := &countingWriter{w: out}
+	w := bufio.NewWriter(counter)
+	for i, m := range metrics {
+		m.write(w, names[i])
+	}
+	err := w.Flush()
+	return counter.n, err
+}
+
+// Handler serves the registry to Prometheus scrapes.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		r.WriteTo(w)
+	})
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ---- Counter ----
+
+// Counter is a count that only goes up.
+type Counter struct {
+	value atomic.Uint64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Value() uint64 {
+	return c.value.Load()
+}
+
+// CounterVec is a counter per combination of label values.
+type CounterVec struct {
+	help string
+	vec  vec[*Counter]
+}
+
+// CounterVec defines a counter with the given labels.
+func (r *Registry) CounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{help: help, vec: newVec(labels, func() *Counter { return new(Counter) })}
+	r.register(name, c)
+	return c
+}
+
+// With returns the counter of the label values, in the order the labels were defined.
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.vec.with(values)
+}
+
+func (c *CounterVec) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, c.help, "counter")
+	c.vec.each(func(labels string, counter *Counter) {
+		writeSample(w, name, labels, "", float64(counter.Value()))
+	})
+}
+
+// ---- Histogram ----
+
+// Histogram counts observations in buckets, by upper bound.
+type Histogram struct {
+	bounds []float64
+	counts []atomic.Uint64 // per bucket, not cumulative; the last one is +Inf
+	sum    atomic.Uint64   // float64 bits
+	count  atomic.Uint64
+}
+
+func newHistogram(bounds []float64) *Histogram {
+	return &Histogram{bounds: bounds, counts: make([]atomic.Uint64, len(bounds)+1)}
+}
+
+func (h *Histogram) Observe(value float64) {
+	bucket, _ := slices.BinarySearch(h.bounds, value)
+	h.counts[bucket].Add(1)
+	for {
+		old := h.sum.Load()
+		if h.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+value)) {
+			break
+		}
+	}
+	h.count.Add(1)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() uint64 {
+	return h.count.Load()
+}
+
+// HistogramVec is a histogram per combination of label values.
+type HistogramVec struct {
+	help string
+	vec  vec[*Histogram]
+}
+
+// HistogramVec defines a histogram with the given bucket upper bounds, ascending, and labels.
+func (r *Registry) HistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	if !slices.IsSorted(bounds) {
+		panic("metrics: " + name + " buckets are not sorted")
+	}
+	h := &HistogramVec{help: help, vec: newVec(labels, func() *Histogram { return newHistogram(bounds) })}
+	r.register(name, h)
+	return h
+}
+
+// With returns the histogram of the label values, in the order the labels were defined.
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.vec.with(values)
+}
+
+func (h *HistogramVec) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, h.help, "histogram")
+	h.vec.each(func(labels string, histogram *Histogram) {
+		// the count is the buckets' total, so +Inf and _count agree while observations land
+		var cumulative uint64
+		for i, bound := range histogram.bounds {
+			cumulative += histogram.counts[i].Load()
+			writeSample(w, name+"_bucket", labels, `le="`+formatFloat(bound)+`"`, float64(cumulative))
+		}
+		cumulative += histogram.counts[len(histogram.bounds)].Load()
+		writeSample(w, name+"_bucket", labels, `le="+Inf"`, float64(cumulative))
+		writeSample(w, name+"_sum", labels, "", math.Float64frombits(histogram.sum.Load()))
+		writeSample(w, name+"_count", labels, "", float64(cumulative))
+	})
+}
+
+// ---- Gauge ----
+
+// GaugeFunc is a gauge read at scrape time: collect reports each series'
+// value with its label values.
+type GaugeFunc struct {
+	help    string
+	labels  []string
+	collect func(report func(value float64, values ...string))
+}
+
+// GaugeFunc defines a gauge with the given labels, read by collect at every scrape.
+func (r *Registry) GaugeFunc(name, help string, collect func(report func(value float64, values ...string)), labels ...string) {
+	r.register(name, &GaugeFunc{help: help, labels: labels, collect: collect})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer, name string) {
+	writeHeader(w, name, g.help, "gauge")
+	g.collect(func(value float64, values ...string) {
+		writeSample(w, name, formatLabels(g.labels, values), "", value)
+	})
+}
+
+// ---- Labeled series ----
+
+// vec keeps a series per combination of label values.
+type vec[T any] struct {
+	labels []string
+	create func() T
+
+	mu     sync.RWMutex
+	series map[string]labeled[T] // by label values joined with \xff
+}
+
+type labeled[T any] struct {
+	labels string // formatted: a="x",b="y"
+	value  T
+}
+
+func newVec[T any](labels []string, create func() T) vec[T] {
+	return vec[T]{labels: labels, create: create, series: make(map[string]labeled[T])}
+}
+
+func (v *vec[T]) with(values []string) T {
+	if len(values) != len(v.labels) {
+		panic("metrics: want " + strconv.Itoa(len(v.labels)) + " label values, got " + strconv.Itoa(len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	series, exists := v.series[key]
+	v.mu.RUnlock()
+	if exists {
+		return series.value
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if series, exists := v.series[key]; exists {
+		return series.value
+	}
+	series = labeled[T]{labels: formatLabels(v.labels, values), value: v.create()}
+	v.series[key] = series
+	return series.value
+}
+
+// each calls fn for every series, sorted by label values.
+func (v *vec[T]) each(fn func(labels string, value T)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	series := make([]labeled[T], len(keys))
+	slices.Sort(keys)
+	for i, key := range keys {
+		series[i] = v.series[key]
+	}
+	v.mu.RUnlock()
+
+	for _, s := range series {
+		fn(s.labels, s.value)
+	}
+}
+
+// ---- Text format ----
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	w.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+// writeSample writes name{labels,extra} value.
+func writeSample(w *bufio.Writer, name, labels, extra string, value float64) {
+	w.WriteString(name)
+	if labels != "" || extra != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		if labels != "" && extra != "" {
+			w.WriteByte(',')
+		}
+		w.WriteString(extra)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatLabels(labels, values []string) string {
+	var b strings.Builder
+	for i, label := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label + `="` + labelEscaper.Replace(values[i]) + `"`)
+	}
+	return b.String()
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
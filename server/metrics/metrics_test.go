@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func scrape(t *testing.T, registry *Registry) string {
+	t.Helper()
+	var out strings.Builder
+	if _, err := registry.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	registry := NewRegistry()
+
+	requests := registry.CounterVec("requests_total", "Requests by route.", "route", "status")
+	requests.With("/booking/ticket", "201").Inc()
+	requests.With("/booking/ticket", "201").Inc()
+	requests.With("/booking/availability", "200").Inc()
+
+	latency := registry.HistogramVec("latency_seconds", "Latency.", []float64{0.1, 0.3}, "route")
+	latency.With("/booking/ticket").Observe(0.05)
+	latency.With("/booking/ticket").Observe(0.3) // bounds are inclusive
+	latency.With("/booking/ticket").Observe(2)
+
+	registry.GaugeFunc("seats_available", "Seats on sale.", func(report func(float64, ...string)) {
+		report(28, "VIP")
+		report(40, "GA")
+	}, "tier")
+	registry.GaugeFunc("entries", "Entries\nkept.", func(report func(float64, ...string)) {
+		report(3)
+	})
+
+	expected := `# HELP entries Entries\nkept.
+# TYPE entries gauge
+entries 3
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/booking/ticket",le="0.1"} 1
+latency_seconds_bucket{route="/booking/ticket",le="0.3"} 2
+latency_seconds_bucket{route="/booking/ticket",le="+Inf"} 3
+latency_seconds_sum{route="/booking/ticket"} 2.35
+latency_seconds_count{route="/booking/ticket"} 3
+# HELP requests_total Requests by route.
+# TYPE requests_total counter
+requests_total{route="/booking/availability",status="200"} 1
+requests_total{route="/booking/ticket",status="201"} 2
+# HELP seats_available Seats on sale.
+# TYPE seats_available gauge
+seats_available{tier="VIP"} 28
+seats_available{tier="GA"} 40
+`
+	if got := scrape(t, registry); got != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestRegistry_EscapesLabelValues(t *testing.T) {
+	registry := NewRegistry()
+	registry.CounterVec("errors_total", "Errors.", "message").With("bad \"quote\"\\\n").Inc()
+
+	expected := `errors_total{message="bad \"quote\"\\\n"} 1`
+	if got := scrape(t, registry); !strings.Contains(got, expected) {
+		t.Errorf("Expected %s in:\n%s", expected, got)
+	}
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	registry := NewRegistry()
+	registry.CounterVec("requests_total", "Requests.")
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected a panic registering requests_total twice")
+		}
+	}()
+	registry.CounterVec("requests_total", "Requests.")
+}
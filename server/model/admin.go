@@ -0,0 +1,103 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ---- Admin ----
+
+// SeatBlock keeps a seat off sale, e.g. a production hold.
+type SeatBlock struct {
+	SeatNo    uint32    `json:"seatNo"`
+	Tier      Tier      `json:"tier"`
+	Reason    string    `json:"reason,omitempty"`
+	BlockedBy string    `json:"blockedBy"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+// BookingFilter narrows a booking search; zero fields match everything.
+type BookingFilter struct {
+	UserID  string
+	Status  BookingStatus
+	Tier    Tier
+	Channel string
+}
+
+// Matches reports whether the booking passes the filter.
+func (f BookingFilter) Matches(booking Booking) bool {
+	if f.UserID != "" && booking.UserID != f.UserID {
+		return false
+	}
+	if f.Status != "" && booking.Status != f.Status {
+		return false
+	}
+	if f.Tier != "" && booking.Tier != f.Tier {
+		return false
+	}
+	if f.Channel != "" && booking.Channel != f.Channel {
+		return false
+	}
+	return true
+}
+
+type AdminAction string
+
+const (
+	AdminActionSearchBookings  AdminAction = "SEARCH_BOOKINGS"
+	AdminActionCancelBooking   AdminAction = "CANCEL_BOOKING"
+	AdminActionBlockSeat       AdminAction = "BLOCK_SEAT"
+	AdminActionUnblockSeat     AdminAction = "UNBLOCK_SEAT"
+	AdminActionCompTicket      AdminAction = "COMP_TICKET"
+	AdminActionViewIdempotency AdminAction = "VIEW_IDEMPOTENCY"
+	AdminActionViewHistory     AdminAction = "VIEW_BOOKING_HISTORY"
+	AdminActionVerifyAudit     AdminAction = "VERIFY_BOOKING_AUDIT"
+)
+
+// AdminAuditEntry records one admin action, successful or not.
+type AdminAuditEntry struct {
+	ID      uuid.UUID   `json:"id"`
+	At      time.Time   `json:"at"`
+	Actor   string      `json:"actor"`
+	Action  AdminAction `json:"action"`
+	Target  string      `json:"target,omitempty"`
+	Reason  string      `json:"reason,omitempty"`
+	Success bool        `json:"success"`
+	Error   string      `json:"error,omitempty"`
+}
+
+type AdminReasonRequest struct {
+	Reason string `json:"reason"`
+}
+
+type CompTicketRequest struct {
+	UserID string `json:"userId"`
+	Tier   Tier   `json:"tier"`
+	SeatNo uint32 `json:"seatNo"`
+	Reason string `json:"reason"`
+}
+
+type AdminBookingsResponse struct {
+	Success  bool      `json:"success"`
+	Message  string    `json:"message,omitempty"`
+	Bookings []Booking `json:"bookings"`
+}
+
+type SeatBlocksResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Blocks  []SeatBlock `json:"blocks"`
+}
+
+type IdempotencyRecordResponse struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message,omitempty"`
+	Record  *BookingOrder `json:"record,omitempty"`
+}
+
+type AdminAuditResponse struct {
+	Success bool              `json:"success"`
+	Message string            `json:"message,omitempty"`
+	Entries []AdminAuditEntry `json:"entries"`
+}
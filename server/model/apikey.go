@@ -0,0 +1,54 @@
+package model
+
+import "slices"
+
+// ---- Partner API keys ----
+
+type APIScope string
+
+const (
+	ScopeAvailabilityRead APIScope = "availability:read"
+	ScopeBookingRead      APIScope = "booking:read"
+	ScopeBookingWrite     APIScope = "booking:write"
+)
+
+func (s APIScope) IsValidAPIScope() bool {
+	switch s {
+	case ScopeAvailabilityRead, ScopeBookingRead, ScopeBookingWrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKey is a partner reseller's server-to-server credential. Only the
+// SHA-256 of the key is kept; the raw key is never stored.
+type APIKey struct {
+	ID      string     `json:"id"`
+	Name    string     `json:"name,omitempty"`
+	KeyHash string     `json:"keyHash"` // hex SHA-256 of the raw key
+	Channel string     `json:"channel"` // tagged on every booking sold with the key; defaults to ID
+	Scopes  []APIScope `json:"scopes"`
+
+	// Tickets the key may sell in total, 0 for no limit
+	MaxBookings uint32 `json:"maxBookings,omitempty"`
+
+	// Seats set aside for the partner. When set, the key can only sell these
+	// seats and nobody else can; when empty it sells from the public inventory.
+	Allocation []SeatRange `json:"allocation,omitempty"`
+}
+
+// HasScope reports whether the key was granted the scope.
+func (k APIKey) HasScope(scope APIScope) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IsAllocated reports whether the seat is in the key's allocation.
+func (k APIKey) IsAllocated(seatNo uint32) bool {
+	for _, seats := range k.Allocation {
+		if seatNo >= seats.Min && seatNo <= seats.Max {
+			return true
+		}
+	}
+	return false
+}
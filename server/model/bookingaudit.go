@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ---- Booking audit ----
+
+// BookingEvent is a transition of a booking's state. These are the only
+// transitions the API makes: it has no ticket transfers or refunds, so the
+// booking audit log holds no such events, and gains them with those operations.
+type BookingEvent string
+
+const (
+	BookingEventCreated   BookingEvent = "CREATED"   // seat taken, payment pending
+	BookingEventConfirmed BookingEvent = "CONFIRMED" // payment confirmed
+	BookingEventCanceled  BookingEvent = "CANCELED"  // payment failed or canceled, or canceled by an admin
+)
+
+/*
+* BookingAuditEntry records one booking transition and the booking's state after it.
+  - Seq numbers the entries of the log from 1, without gaps
+  - PrevHash is the Hash of the entry before, 64 zeros for the first one
+  - Hash is the SHA-256 of the entry's JSON without Hash, in hex: changing,
+    removing or reordering entries breaks the chain from there on
+*/
+type BookingAuditEntry struct {
+	Seq   uint64       `json:"seq"`
+	At    time.Time    `json:"at"`
+	Event BookingEvent `json:"event"`
+
+	BookingID        uuid.UUID     `json:"bookingId"`
+	UserID           string        `json:"userId"`
+	SeatNo           uint32        `json:"seatNo"`
+	Tier             Tier          `json:"tier"`
+	Status           BookingStatus `json:"status"`
+	PaymentID        string        `json:"paymentID,omitempty"`
+	PaymentStatus    PaymentStatus `json:"paymentStatus"`
+	TotalAmtInUSCent uint64        `json:"totalAmtInUSCent"`
+
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// AuditVerification reports whether a hash chain is intact.
+type AuditVerification struct {
+	Valid   bool   `json:"valid"`
+	Entries uint64 `json:"entries"`        // entries checked
+	Head    string `json:"head,omitempty"` // hash of the last entry
+	// first entry that doesn't chain, and why; unset while Valid
+	BrokenAt uint64 `json:"brokenAt,omitempty"`
+	Problem  string `json:"problem,omitempty"`
+}
+
+type BookingHistoryResponse struct {
+	Success   bool                `json:"success"`
+	Message   string              `json:"message,omitempty"`
+	BookingID uuid.UUID           `json:"bookingId"`
+	Entries   []BookingAuditEntry `json:"entries"`
+}
+
+type AuditVerificationResponse struct {
+	Success      bool              `json:"success"`
+	Message      string            `json:"message,omitempty"`
+	Verification AuditVerification `json:"verification"`
+}
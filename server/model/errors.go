@@ -0,0 +1,186 @@
+package model
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ---- Error catalogue ----
+
+// ErrorCode is a stable, machine-readable error identifier. Clients should
+// switch on it instead of matching messages, which may change.
+type ErrorCode string
+
+const (
+	// request
+	CodeInvalidRequest   ErrorCode = "INVALID_REQUEST"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodeInvalidTier      ErrorCode = "INVALID_TIER"
+	CodeInvalidSeat      ErrorCode = "INVALID_SEAT"
+	CodeInvalidCursor    ErrorCode = "INVALID_CURSOR"
+	CodeRequestTooLarge  ErrorCode = "REQUEST_TOO_LARGE"
+
+	// field errors of VALIDATION_FAILED
+	CodeRequired          ErrorCode = "REQUIRED"
+	CodeUnknownField      ErrorCode = "UNKNOWN_FIELD"
+	CodeInvalidType       ErrorCode = "INVALID_TYPE"
+	CodeInvalidCountry    ErrorCode = "INVALID_COUNTRY"
+	CodeInvalidPostalCode ErrorCode = "INVALID_POSTAL_CODE"
+	CodeInvalidCurrency   ErrorCode = "INVALID_CURRENCY"
+
+	// seats and bookings
+	CodeSeatTaken              ErrorCode = "SEAT_TAKEN"
+	CodeSeatBlocked            ErrorCode = "SEAT_BLOCKED"
+	CodeSeatNotBlocked         ErrorCode = "SEAT_NOT_BLOCKED"
+	CodeSeatAllocated          ErrorCode = "SEAT_ALLOCATED"
+	CodeSeatNotInAllocation    ErrorCode = "SEAT_NOT_IN_ALLOCATION"
+	CodeBookingNotFound        ErrorCode = "BOOKING_NOT_FOUND"
+	CodeBookingCanceled        ErrorCode = "BOOKING_CANCELED"
+	CodeSeatHeld               ErrorCode = "SEAT_HELD"
+	CodeHoldNotFound           ErrorCode = "HOLD_NOT_FOUND"
+	CodeHoldLimitReached       ErrorCode = "HOLD_LIMIT_REACHED"
+	CodeHoldExpired            ErrorCode = "HOLD_EXPIRED"
+	CodeIdempotencyMismatch    ErrorCode = "IDEMPOTENCY_MISMATCH"
+	CodeIdempotencyNotFound    ErrorCode = "IDEMPOTENCY_NOT_FOUND"
+	CodeBookingQuotaExceeded   ErrorCode = "BOOKING_QUOTA_EXCEEDED"
+	CodeBookingAlreadyCanceled ErrorCode = "BOOKING_ALREADY_CANCELED"
+
+	// sale windows
+	CodeNotOnSale          ErrorCode = "NOT_ON_SALE"
+	CodeSaleEnded          ErrorCode = "SALE_ENDED"
+	CodeAccessCodeRequired ErrorCode = "ACCESS_CODE_REQUIRED"
+	CodeAccessCodeInvalid  ErrorCode = "ACCESS_CODE_INVALID"
+
+	// pricing
+	CodePromoNotFound       ErrorCode = "PROMO_NOT_FOUND"
+	CodePromoNotActive      ErrorCode = "PROMO_NOT_ACTIVE"
+	CodePromoNotApplicable  ErrorCode = "PROMO_NOT_APPLICABLE"
+	CodePromoUsageLimit     ErrorCode = "PROMO_USAGE_LIMIT"
+	CodeQuoteInvalid        ErrorCode = "QUOTE_INVALID"
+	CodeQuoteExpired        ErrorCode = "QUOTE_EXPIRED"
+	CodeQuoteMismatch       ErrorCode = "QUOTE_MISMATCH"
+	CodeUnsupportedCurrency ErrorCode = "UNSUPPORTED_CURRENCY"
+
+	// access
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	CodeForbidden    ErrorCode = "FORBIDDEN"
+	CodeRateLimited  ErrorCode = "RATE_LIMITED"
+	CodeNotFound     ErrorCode = "NOT_FOUND"
+
+	CodeInternal    ErrorCode = "INTERNAL"
+	CodeUnavailable ErrorCode = "UNAVAILABLE"
+)
+
+type errorKind struct {
+	status int
+	title  string
+}
+
+var errorCatalogue = map[ErrorCode]errorKind{
+	CodeInvalidRequest:   {http.StatusBadRequest, "Invalid request"},
+	CodeValidationFailed: {http.StatusBadRequest, "Validation failed"},
+	CodeInvalidTier:      {http.StatusBadRequest, "Invalid tier"},
+	CodeInvalidSeat:      {http.StatusBadRequest, "Invalid seat"},
+	CodeInvalidCursor:    {http.StatusBadRequest, "Invalid cursor"},
+	CodeRequestTooLarge:  {http.StatusRequestEntityTooLarge, "Request body too large"},
+
+	CodeRequired:          {http.StatusBadRequest, "Field required"},
+	CodeUnknownField:      {http.StatusBadRequest, "Unknown field"},
+	CodeInvalidType:       {http.StatusBadRequest, "Invalid field type"},
+	CodeInvalidCountry:    {http.StatusBadRequest, "Invalid country code"},
+	CodeInvalidPostalCode: {http.StatusBadRequest, "Invalid postal code"},
+	CodeInvalidCurrency:   {http.StatusBadRequest, "Invalid currency code"},
+
+	CodeSeatTaken:              {http.StatusConflict, "Seat already taken"},
+	CodeSeatBlocked:            {http.StatusConflict, "Seat blocked"},
+	CodeSeatNotBlocked:         {http.StatusNotFound, "Seat not blocked"},
+	CodeSeatAllocated:          {http.StatusConflict, "Seat allocated to a partner"},
+	CodeSeatNotInAllocation:    {http.StatusForbidden, "Seat outside allocation"},
+	CodeBookingNotFound:        {http.StatusNotFound, "Booking not found"},
+	CodeBookingCanceled:        {http.StatusConflict, "Booking canceled"},
+	CodeBookingAlreadyCanceled: {http.StatusConflict, "Booking already canceled"},
+	CodeSeatHeld:               {http.StatusConflict, "Seat held by another shopper"},
+	CodeHoldNotFound:           {http.StatusNotFound, "Hold not found"},
+	CodeHoldLimitReached:       {http.StatusConflict, "Hold limit reached"},
+	CodeHoldExpired:            {http.StatusGone, "Hold expired"},
+	CodeIdempotencyMismatch:    {http.StatusUnprocessableEntity, "Idempotency key reused"},
+	CodeIdempotencyNotFound:    {http.StatusNotFound, "Idempotency record not found"},
+	CodeBookingQuotaExceeded:   {http.StatusForbidden, "Booking quota exceeded"},
+
+	CodeNotOnSale:          {http.StatusForbidden, "Not on sale"},
+	CodeSaleEnded:          {http.StatusForbidden, "Sale ended"},
+	CodeAccessCodeRequired: {http.StatusForbidden, "Access code required"},
+	CodeAccessCodeInvalid:  {http.StatusForbidden, "Invalid access code"},
+
+	CodePromoNotFound:       {http.StatusBadRequest, "Promo code not found"},
+	CodePromoNotActive:      {http.StatusBadRequest, "Promo code not active"},
+	CodePromoNotApplicable:  {http.StatusBadRequest, "Promo code not applicable"},
+	CodePromoUsageLimit:     {http.StatusConflict, "Promo code used up"},
+	CodeQuoteInvalid:        {http.StatusBadRequest, "Invalid quote"},
+	CodeQuoteExpired:        {http.StatusBadRequest, "Quote expired"},
+	CodeQuoteMismatch:       {http.StatusBadRequest, "Quote mismatch"},
+	CodeUnsupportedCurrency: {http.StatusBadRequest, "Unsupported currency"},
+
+	CodeUnauthorized: {http.StatusUnauthorized, "Unauthorized"},
+	CodeForbidden:    {http.StatusForbidden, "Forbidden"},
+	CodeRateLimited:  {http.StatusTooManyRequests, "Too many requests"},
+	CodeNotFound:     {http.StatusNotFound, "Not found"},
+
+	CodeInternal:    {http.StatusInternalServerError, "Internal error"},
+	CodeUnavailable: {http.StatusServiceUnavailable, "Service unavailable"},
+}
+
+// Status returns the HTTP status the code is served with.
+func (c ErrorCode) Status() int {
+	if kind, exists := errorCatalogue[c]; exists {
+		return kind.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Title returns the code's short, human-readable summary.
+func (c ErrorCode) Title() string {
+	if kind, exists := errorCatalogue[c]; exists {
+		return kind.title
+	}
+	return errorCatalogue[CodeInternal].title
+}
+
+// Type returns the problem type URI of the code, e.g. /problems/seat-taken.
+func (c ErrorCode) Type() string {
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(string(c)), "_", "-")
+}
+
+// Error is an error with a catalogue code. Sentinel errors across the
+// packages are *Error values, so errors.Is keeps working on them.
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Fields  []FieldError // set for validation errors
+}
+
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// FieldError is one invalid field of a request.
+type FieldError struct {
+	Field   string    `json:"field"`
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     ErrorCode    `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
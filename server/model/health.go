@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// ---- Health ----
+
+// Health statuses of /healthz, /readyz and /status
+const (
+	HealthOK       = "ok"        // the process serves requests
+	HealthReady    = "ready"     // every readiness check passed
+	HealthNotReady = "not_ready" // a readiness check failed or is pending
+)
+
+// Readiness check results other than an error message
+const (
+	CheckOK      = "ok"
+	CheckPending = "pending"  // not done starting yet
+	CheckDrain   = "draining" // shutting down
+)
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"` // check name -> CheckOK, CheckPending, CheckDrain or an error
+}
+
+// StatusResponse is the detailed /status report, for operators rather than load balancers.
+type StatusResponse struct {
+	Status        string            `json:"status"`
+	Checks        map[string]string `json:"checks"`
+	StartedAt     time.Time         `json:"startedAt"`
+	UptimeSeconds int64             `json:"uptimeSeconds"`
+	Build         BuildInfo         `json:"build"`
+	Store         StoreStats        `json:"store"`
+	Idempotency   IdempotencyStats  `json:"idempotency"`
+}
+
+// BuildInfo identifies the running binary, as far as the Go toolchain recorded it.
+type BuildInfo struct {
+	GoVersion    string    `json:"goVersion"`
+	Module       string    `json:"module,omitempty"`
+	Version      string    `json:"version,omitempty"`  // module version, "(devel)" when built from a checkout
+	Revision     string    `json:"revision,omitempty"` // VCS commit
+	RevisionTime time.Time `json:"revisionTime,omitzero"`
+	Modified     bool      `json:"modified,omitempty"` // built with uncommitted changes
+}
+
+type StoreStats struct {
+	Backend  string                 `json:"backend"`
+	Bookings int                    `json:"bookings"` // every booking ever made, canceled ones included
+	Version  uint64                 `json:"version"`  // inventory version, see the availability ETag
+	Seats    map[Tier]TierSeatStats `json:"seats"`
+}
+
+// TierSeatStats counts a tier's seats by state; Available is what's left.
+type TierSeatStats struct {
+	Total     uint32 `json:"total"`
+	Booked    uint32 `json:"booked"`
+	Blocked   uint32 `json:"blocked"`
+	Held      uint32 `json:"held"`
+	Available uint32 `json:"available"`
+}
+
+type IdempotencyStats struct {
+	Entries int `json:"entries"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// ---- Seat holds ----
+
+// SeatHold keeps a seat for one shopper while they check out. Other shoppers
+// can neither hold nor book it until the hold is released, expires, or the
+// holder books the seat.
+type SeatHold struct {
+	SeatNo    uint32    `json:"seatNo"`
+	Tier      Tier      `json:"tier"`
+	HolderID  string    `json:"holderId"` // user ID the booking must carry
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SeatMapCommandType is what a seat map client asks for.
+type SeatMapCommandType string
+
+const (
+	SeatMapHold    SeatMapCommandType = "hold"
+	SeatMapRelease SeatMapCommandType = "release"
+)
+
+// SeatMapCommand is a message from a seat map WebSocket client.
+type SeatMapCommand struct {
+	Type       SeatMapCommandType `json:"type"`
+	SeatNo     uint32             `json:"seatNo"`
+	AccessCode string             `json:"accessCode,omitempty"` // presale holds need the tier's code
+}
+
+// SeatMapTicketResponse carries a one-time ticket to open the seat map
+// WebSocket with, as ?ticket=, since browsers can't send credentials with it.
+type SeatMapTicketResponse struct {
+	Success   bool      `json:"success"`
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SeatMapMessageType tells seat map clients how to read a message.
+type SeatMapMessageType string
+
+const (
+	SeatMapSnapshot SeatMapMessageType = "snapshot" // Availability, the seat map to apply events to
+	SeatMapSeat     SeatMapMessageType = "seat"     // Event, a seat changed
+	SeatMapHeld     SeatMapMessageType = "held"     // Hold, the client's hold was taken or extended
+	SeatMapReleased SeatMapMessageType = "released" // SeatNo, the client's hold was released
+	SeatMapError    SeatMapMessageType = "error"    // Code and Detail, a command failed or a hold expired
+)
+
+// SeatMapMessage is a message to a seat map WebSocket client.
+type SeatMapMessage struct {
+	Type SeatMapMessageType `json:"type"`
+
+	ID           string                `json:"id,omitempty"` // resume point, see SeatEvent.ID
+	Availability *AvailabilityResponse `json:"availability,omitempty"`
+	Event        *SeatEvent            `json:"event,omitempty"`
+	Hold         *SeatHold             `json:"hold,omitempty"`
+
+	SeatNo uint32    `json:"seatNo,omitempty"`
+	Code   ErrorCode `json:"code,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+)
+
+// SeatLayout assigns every tier its range of seat numbers.
+type SeatLayout map[Tier]SeatRange
+
+// DefaultSeatLayout is VIP 1-30, FRONT_ROW 31-60, GA 61-100.
+func DefaultSeatLayout() SeatLayout {
+	return SeatLayout{
+		TierVIP:      {Min: 1, Max: 30},
+		TierFrontRow: {Min: 31, Max: 60},
+		TierGA:       {Min: 61, Max: 100},
+	}
+}
+
+// seatLayout is the venue's layout, set once at startup.
+var seatLayout = DefaultSeatLayout()
+
+// SetSeatLayout replaces the venue's layout. It must be called before any
+// booking is made and is not safe for concurrent use.
+func SetSeatLayout(layout SeatLayout) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	seatLayout = layout
+	return nil
+}
+
+// TotalSeats returns the highest seat number of the venue's layout.
+func TotalSeats() uint32 {
+	return seatLayout.TotalSeats()
+}
+
+// Validate checks every tier has a range of its own: no tier missing, no
+// unknown tier, and no seat in two tiers.
+func (l SeatLayout) Validate() error {
+	for tier := range l {
+		if !tier.IsValidTier() {
+			return fmt.Errorf("invalid tier %q", tier)
+		}
+	}
+
+	ranges := make([]SeatRange, 0, len(l))
+	for _, tier := range AllTiers() {
+		seatRange, exists := l[tier]
+		if !exists {
+			return fmt.Errorf("tier %s: missing", tier)
+		}
+		if seatRange.Total() == 0 {
+			return fmt.Errorf("tier %s: want 1 <= min <= max, got %d-%d", tier, seatRange.Min, seatRange.Max)
+		}
+		ranges = append(ranges, seatRange)
+	}
+
+	slices.SortFunc(ranges, func(a, b SeatRange) int { return int(a.Min) - int(b.Min) })
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].Min <= ranges[i-1].Max {
+			return fmt.Errorf("seats %d-%d and %d-%d overlap", ranges[i-1].Min, ranges[i-1].Max, ranges[i].Min, ranges[i].Max)
+		}
+	}
+	return nil
+}
+
+// TotalSeats returns the highest seat number of the layout.
+func (l SeatLayout) TotalSeats() uint32 {
+	var total uint32
+	for _, seatRange := range l {
+		total = max(total, seatRange.Max)
+	}
+	return total
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ---- Promo codes ----
+
+type DiscountType string
+
+const (
+	DiscountTypePercent DiscountType = "PERCENT" // PercentOff of the base amount
+	DiscountTypeFixed   DiscountType = "FIXED"   // AmountOffInUSCent off the base amount
+)
+
+func (t DiscountType) IsValidDiscountType() bool {
+	switch t {
+	case DiscountTypePercent, DiscountTypeFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+type PromoCode struct {
+	Code         string       `json:"code"`
+	DiscountType DiscountType `json:"discountType"`
+
+	PercentOff        uint32 `json:"percentOff,omitempty"`        // 1-100, for PERCENT
+	AmountOffInUSCent uint64 `json:"amountOffInUSCent,omitempty"` // for FIXED
+
+	// Tiers the code can be used on; empty means every tier.
+	Tiers []Tier `json:"tiers,omitempty"`
+
+	// Usage caps; 0 means unlimited.
+	MaxUses        uint32 `json:"maxUses,omitempty"`
+	MaxUsesPerUser uint32 `json:"maxUsesPerUser,omitempty"`
+
+	// Validity window; zero values leave that side of the window open.
+	ValidFrom  time.Time `json:"validFrom,omitempty"`
+	ValidUntil time.Time `json:"validUntil,omitempty"`
+}
+
+// NormalizePromoCode makes codes case-insensitive and whitespace tolerant.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// AppliesToTier reports whether the code may be used on the given tier.
+func (p PromoCode) AppliesToTier(tier Tier) bool {
+	if len(p.Tiers) == 0 {
+		return true
+	}
+	for _, t := range p.Tiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActiveAt reports whether now falls inside the validity window.
+func (p PromoCode) IsActiveAt(now time.Time) bool {
+	if !p.ValidFrom.IsZero() && now.Before(p.ValidFrom) {
+		return false
+	}
+	if !p.ValidUntil.IsZero() && !now.Before(p.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// DiscountFor returns the discount in cents for the given amount, never more than the amount itself.
+func (p PromoCode) DiscountFor(amountInUSCent uint64) uint64 {
+	var discount uint64
+	switch p.DiscountType {
+	case DiscountTypePercent:
+		discount = amountInUSCent * uint64(p.PercentOff) / 100
+	case DiscountTypeFixed:
+		discount = p.AmountOffInUSCent
+	}
+	if discount > amountInUSCent {
+		return amountInUSCent
+	}
+	return discount
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// ---- Seat events ----
+
+// SeatState is what a seat change turned the seat into.
+type SeatState string
+
+const (
+	SeatStateBooked   SeatState = "BOOKED"   // taken by a booking
+	SeatStateHeld     SeatState = "HELD"     // off sale but not booked: blocked by an admin or held by a shopper
+	SeatStateReleased SeatState = "RELEASED" // back on sale
+)
+
+// SeatEvent is one seat-level change of availability. Events carry the seat's
+// new state rather than a difference, so replaying one twice is harmless.
+type SeatEvent struct {
+	ID     string    `json:"id"` // set by the seat feed; resume with Last-Event-ID
+	SeatNo uint32    `json:"seatNo"`
+	Tier   Tier      `json:"tier"`
+	State  SeatState `json:"state"`
+	At     time.Time `json:"at"`
+}
@@ -25,6 +25,42 @@ func (t Tier) IsValidTier() bool {
 	}
 }
 
+// AllTiers lists the tiers in display order.
+func AllTiers() []Tier {
+	return []Tier{TierVIP, TierFrontRow, TierGA}
+}
+
+// SeatRange is an inclusive range of seat numbers.
+type SeatRange struct {
+	Min uint32 `json:"min"`
+	Max uint32 `json:"max"`
+}
+
+// Total returns the number of seats in the range.
+func (r SeatRange) Total() uint32 {
+	if r.Min == 0 || r.Max < r.Min {
+		return 0
+	}
+	return r.Max - r.Min + 1
+}
+
+// SeatRange returns the seats reserved for the tier by the venue's layout,
+// by default VIP 1-30, FRONT_ROW 31-60, GA 61-100.
+func (t Tier) SeatRange() SeatRange {
+	return seatLayout[t]
+}
+
+// TierForSeat returns the tier whose seat range holds the seat.
+func TierForSeat(seatNo uint32) (Tier, bool) {
+	for _, tier := range AllTiers() {
+		seatRange := tier.SeatRange()
+		if seatNo >= seatRange.Min && seatNo <= seatRange.Max {
+			return tier, true
+		}
+	}
+	return "", false
+}
+
 // Store money as integer cents to avoid float precision issues.
 const (
 	PriceVIPCents      int64 = 10000 // $100.00
@@ -87,6 +123,23 @@ type Booking struct {
 	ZipCode  string `json:"zipCode"`
 	Currency string `json:"currency"`
 
+	// Complimentary tickets are issued by an admin at zero cost
+	Complimentary bool `json:"complimentary,omitempty"`
+
+	// Sales channel of a partner reseller; empty for direct sales
+	Channel string `json:"channel,omitempty"`
+
+	// Partner API key the booking was sold with, whose quota it counts against
+	APIKeyID string `json:"apiKeyId,omitempty"`
+
+	// Promo
+	PromoCode        string `json:"promoCode,omitempty"`
+	DiscountInUSCent uint64 `json:"discountInUSCent,omitempty"`
+
+	// Charges on top of the ticket price
+	FeesInUSCent uint64 `json:"feesInUSCent,omitempty"`
+	TaxInUSCent  uint64 `json:"taxInUSCent,omitempty"`
+
 	// Payment
 	TotalAmtInUSCent uint64        `json:"totalAmtInUSCent"`
 	PaymentID        string        `json:"paymentID"`
@@ -103,6 +156,12 @@ type BookingOrder struct {
 
 	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 
+	// Signed price quote (from availability or POST /quote), honoured at booking time
+	QuoteID string `json:"quoteId,omitempty"`
+
+	// Presale access code, required while the tier is in presale
+	AccessCode string `json:"accessCode,omitempty"`
+
 	// country
 	Country  string `json:"country"`
 	ZipCode  string `json:"zipCode"`
@@ -111,12 +170,44 @@ type BookingOrder struct {
 	// seat
 	SeatNo uint32 `json:"seatNo"`
 
+	// Set only by the admin comp endpoint, never from a request body
+	Complimentary bool `json:"-"`
+
+	// Set from the partner API key the order was made with
+	Channel  string `json:"-"`
+	APIKeyID string `json:"-"`
+
+	// Promo
+	PromoCode        string `json:"promoCode,omitempty"`
+	DiscountInUSCent uint64 `json:"discountInUSCent,omitempty"`
+
+	// Charges on top of the ticket price
+	FeesInUSCent uint64 `json:"feesInUSCent,omitempty"`
+	TaxInUSCent  uint64 `json:"taxInUSCent,omitempty"`
+
 	// Payment
 	TotalAmtInUSCent uint64        `json:"totalAmtInUSCent"`
 	PaymentID        string        `json:"paymentID"`
 	PaymentStatus    PaymentStatus `json:"paymentStatus"`
 }
 
+// SameOrder reports whether two orders sent with the same idempotency key ask
+// for the same booking. The price may legitimately differ between retries.
+func (o BookingOrder) SameOrder(other BookingOrder) bool {
+	return o.UserID == other.UserID &&
+		o.Tier == other.Tier &&
+		o.SeatNo == other.SeatNo &&
+		o.PromoCode == other.PromoCode &&
+		o.Channel == other.Channel
+}
+
+type UserBookingsResponse struct {
+	Success    bool      `json:"success"`
+	Message    string    `json:"message,omitempty"`
+	Bookings   []Booking `json:"bookings"`
+	NextCursor string    `json:"nextCursor,omitempty"` // absent on the last page
+}
+
 type BookingResponse struct {
 	Success bool     `json:"success"`
 	Message string   `json:"message,omitempty"`
@@ -124,10 +215,9 @@ type BookingResponse struct {
 }
 
 type AvailabilityResponse struct {
-	Success        bool                `json:"success"`
-	Message        string              `json:"message,omitempty"`
-	AvaliableSeats map[string][]uint32 `json:"avaliableSeats,omitempty"`
-	Tiers          []TierInfo          `json:"tiers,omitempty"`
+	Success bool       `json:"success"`
+	Message string     `json:"message,omitempty"`
+	Tiers   []TierInfo `json:"tiers,omitempty"`
 }
 
 type TierInfo struct {
@@ -136,4 +226,60 @@ type TierInfo struct {
 	TotalSeats    uint32   `json:"totalSeats"`    // total seats for this tier
 	ReservedCount uint32   `json:"reservedCount"` // number of seats reserved for this tier
 	AvailableList []uint32 `json:"availableList,omitempty"`
+
+	SaleState     SaleState `json:"saleState"`
+	SaleChangesAt time.Time `json:"saleChangesAt,omitzero"` // when SaleState next changes
+
+	// Set when dynamic pricing is enabled; pass QuoteID when booking to pay Price.
+	QuoteID        string    `json:"quoteId,omitempty"`
+	QuoteExpiresAt time.Time `json:"quoteExpiresAt,omitzero"`
+}
+
+// ---- Price quote ----
+
+type PriceBreakdown struct {
+	BaseInUSCent     uint64 `json:"baseInUSCent"`
+	DiscountInUSCent uint64 `json:"discountInUSCent"`
+	FeesInUSCent     uint64 `json:"feesInUSCent"`
+	TaxInUSCent      uint64 `json:"taxInUSCent"`
+	TotalInUSCent    uint64 `json:"totalInUSCent"`
+
+	// Total converted to the requested currency, in its minor units
+	Currency        string  `json:"currency"`
+	ExchangeRate    float64 `json:"exchangeRate"` // currency units per USD
+	TotalInCurrency uint64  `json:"totalInCurrency"`
 }
+
+type QuoteRequest struct {
+	Tier      Tier   `json:"tier"`
+	SeatNo    uint32 `json:"seatNo"`
+	PromoCode string `json:"promoCode,omitempty"`
+	Country   string `json:"country"`
+	Currency  string `json:"currency"`
+}
+
+type PriceQuote struct {
+	QuoteID   string         `json:"quoteId"` // pass as quoteId when booking
+	ExpiresAt time.Time      `json:"expiresAt"`
+	Tier      Tier           `json:"tier"`
+	SeatNo    uint32         `json:"seatNo"`
+	PromoCode string         `json:"promoCode,omitempty"`
+	Breakdown PriceBreakdown `json:"breakdown"`
+}
+
+type QuoteResponse struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message,omitempty"`
+	Quote   *PriceQuote `json:"quote,omitempty"`
+}
+
+// ---- Sale schedule ----
+
+type SaleState string
+
+const (
+	SaleStateNotOnSale SaleState = "NOT_ON_SALE" // before presale / general sale
+	SaleStatePresale   SaleState = "PRESALE"     // presale access code required
+	SaleStateOnSale    SaleState = "ON_SALE"     // open to everyone
+	SaleStateEnded     SaleState = "ENDED"       // sale closed
+)
@@ -0,0 +1,230 @@
+// Package openapi builds an OpenAPI 3.1 document from route descriptions and
+// the Go types handlers decode and encode, so the spec can't drift from the code.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lower-case HTTP methods to operations.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // path or query
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`             // http or apiKey
+	Scheme       string `json:"scheme,omitempty"` // bearer
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"` // header name of an apiKey
+	In           string `json:"in,omitempty"`
+}
+
+// Operation describes one route. Request and Response are values of the types
+// the handler decodes and encodes; a nil Request means the route takes no body.
+type Operation struct {
+	Method   string
+	Path     string // full path in http.ServeMux syntax, e.g. /booking/{id}
+	Summary  string
+	Tag      string
+	Query    []string // optional query parameters
+	Security []string // alternative security schemes; empty means none
+	Request  any
+	Response any
+
+	// MediaType of the response; application/json when empty. For
+	// text/event-stream, Response is the JSON payload of one event.
+	MediaType string
+}
+
+// Builder collects operations into a Document.
+type Builder struct {
+	doc       Document
+	schemas   *schemaGenerator
+	errorBody *Schema
+}
+
+// NewBuilder starts a document. Every operation's default response is
+// errorBody served as application/problem+json.
+func NewBuilder(title, version string, errorBody any) *Builder {
+	schemas := newSchemaGenerator()
+	return &Builder{
+		doc: Document{
+			OpenAPI: Version,
+			Info:    Info{Title: title, Version: version},
+			Paths:   make(map[string]*PathItem),
+			Components: Components{
+				Schemas:         schemas.components,
+				SecuritySchemes: make(map[string]SecurityScheme),
+			},
+		},
+		schemas:   schemas,
+		errorBody: schemas.schemaFor(reflect.TypeOf(errorBody)),
+	}
+}
+
+// Enum restricts a string type, e.g. model.Tier, to the given values.
+func (b *Builder) Enum(value any, values ...string) {
+	b.schemas.enums[reflect.TypeOf(value)] = values
+}
+
+func (b *Builder) SecurityScheme(name string, scheme SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+var pathParam = regexp.MustCompile(`\{([^}.]+)(\.\.\.)?\}`)
+
+func (b *Builder) Add(op Operation) {
+	path := pathParam.ReplaceAllString(op.Path, "{$1}")
+	item, exists := b.doc.Paths[path]
+	if !exists {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	operation := &OperationObject{
+		OperationID: operationID(op.Method, path),
+		Summary:     op.Summary,
+		Responses: map[string]Response{
+			"default": {
+				Description: "Problem details",
+				Content:     map[string]MediaType{"application/problem+json": {Schema: b.errorBody}},
+			},
+		},
+	}
+	if op.Tag != "" {
+		operation.Tags = []string{op.Tag}
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(path, -1) {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	for _, name := range op.Query {
+		operation.Parameters = append(operation.Parameters, Parameter{
+			Name: name, In: "query", Schema: &Schema{Type: "string"},
+		})
+	}
+	if op.Request != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.schemas.schemaFor(reflect.TypeOf(op.Request))}},
+		}
+	}
+	if op.Response != nil {
+		mediaType := op.MediaType
+		if mediaType == "" {
+			mediaType = "application/json"
+		}
+		operation.Responses["200"] = Response{
+			Description: "OK",
+			Content:     map[string]MediaType{mediaType: {Schema: b.schemas.schemaFor(reflect.TypeOf(op.Response))}},
+		}
+	}
+	for _, scheme := range op.Security {
+		operation.Security = append(operation.Security, map[string][]string{scheme: {}})
+	}
+
+	(*item)[strings.ToLower(op.Method)] = operation
+}
+
+func (b *Builder) Document() *Document {
+	return &b.doc
+}
+
+// operationID turns "GET /users/{userId}/bookings" into "getUsersUserIdBookings".
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+	return id
+}
+
+// Operation returns the operation served for method and a concrete request
+// path, e.g. GET /booking/3f2a..., with the path parameters it matched.
+func (d *Document) Operation(method, path string) (*OperationObject, bool) {
+	method = strings.ToLower(method)
+	if method == strings.ToLower(http.MethodHead) {
+		method = "get"
+	}
+	// like http.ServeMux, a literal segment wins over a wildcard
+	var best *OperationObject
+	bestWildcards := -1
+	for pattern, item := range d.Paths {
+		operation, exists := (*item)[method]
+		if !exists || !matchPath(pattern, path) {
+			continue
+		}
+		if wildcards := strings.Count(pattern, "{"); best == nil || wildcards < bestWildcards {
+			best, bestWildcards = operation, wildcards
+		}
+	}
+	return best, best != nil
+}
+
+func matchPath(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternParts) != len(pathParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if !strings.HasPrefix(part, "{") && part != pathParts[i] {
+			return false
+		}
+	}
+	return true
+}
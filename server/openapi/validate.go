@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ValidateResponse checks a response body against the schema the document
+// declares for the operation and status. Undeclared fields are errors.
+func (d *Document) ValidateResponse(method, path string, status int, body []byte) error {
+	operation, exists := d.Operation(method, path)
+	if !exists {
+		return fmt.Errorf("%s %s is not in the spec", method, path)
+	}
+
+	response, exists := operation.Responses[fmt.Sprint(status)]
+	if !exists {
+		response = operation.Responses["default"]
+	}
+	var schema *Schema
+	for _, media := range response.Content {
+		schema = media.Schema
+	}
+	if schema == nil {
+		return fmt.Errorf("%s %s declares no body for %d", method, path, status)
+	}
+
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return fmt.Errorf("response is not JSON: %w", err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) validate(schema *Schema, value any, at string) error {
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		component, exists := d.Components.Schemas[name]
+		if !exists {
+			return fmt.Errorf("%s: unknown schema %s", at, schema.Ref)
+		}
+		return d.validate(component, value, at)
+	}
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			if d.validate(option, value, at) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v matches none of the allowed schemas", at, value)
+	}
+	if schema.Type == nil {
+		return nil
+	}
+
+	allowed, got := typeNames(schema.Type), jsonType(value)
+	if !slices.Contains(allowed, got) && (got != "integer" || !slices.Contains(allowed, "number")) {
+		return fmt.Errorf("%s: expected %v, got %s", at, schema.Type, got)
+	}
+
+	switch value := value.(type) {
+	case string:
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+			return fmt.Errorf("%s: %q is not one of %v", at, value, schema.Enum)
+		}
+	case float64:
+		if schema.Minimum != nil && value < *schema.Minimum {
+			return fmt.Errorf("%s: %v is below %v", at, value, *schema.Minimum)
+		}
+	case []any:
+		for i, item := range value {
+			if err := d.validate(schema.Items, item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		return d.validateObject(schema, value, at)
+	}
+	return nil
+}
+
+func (d *Document) validateObject(schema *Schema, value map[string]any, at string) error {
+	for _, name := range schema.Required {
+		if _, exists := value[name]; !exists {
+			return fmt.Errorf("%s: missing required field %q", at, name)
+		}
+	}
+	for name, field := range value {
+		property, declared := schema.Properties[name]
+		if !declared {
+			additional, ok := schema.AdditionalProperties.(*Schema)
+			if !ok {
+				return fmt.Errorf("%s: field %q is not in the spec", at, name)
+			}
+			property = additional
+		}
+		if err := d.validate(property, field, at+"."+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func typeNames(schemaType any) []string {
+	switch schemaType := schemaType.(type) {
+	case string:
+		return []string{schemaType}
+	case []string:
+		return schemaType
+	default:
+		return nil
+	}
+}
+
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,120 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var ErrUnsupportedCurrency = model.NewError(model.CodeUnsupportedCurrency, "unsupported currency")
+
+/*
+* Price breakdown of a ticket:
+  - subtotal = base - discount
+  - fees     = serviceFeeInUSCent + serviceFeePercent% of subtotal
+  - tax      = taxRates[country] * (subtotal + fees)
+  - total    = subtotal + fees + tax, also converted with exchangeRates[currency]
+*/
+type Charges struct {
+	ServiceFeeInUSCent uint64  `json:"serviceFeeInUSCent"`
+	ServiceFeePercent  float64 `json:"serviceFeePercent"`
+
+	TaxRates      map[string]float64 `json:"taxRates"`      // country code -> rate, e.g. "US": 0.08
+	ExchangeRates map[string]float64 `json:"exchangeRates"` // currency code -> units per USD, e.g. "EUR": 0.92
+
+	QuoteTTLSeconds uint32 `json:"quoteTTLSeconds"` // default 120
+}
+
+// LoadCharges reads fees, tax and exchange rates from a JSON file.
+func LoadCharges(path string) (Charges, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Charges{}, err
+	}
+
+	var charges Charges
+	if err := json.Unmarshal(data, &charges); err != nil {
+		return Charges{}, fmt.Errorf("parse charges: %w", err)
+	}
+	return charges, charges.Validate()
+}
+
+func (c Charges) Validate() error {
+	if c.ServiceFeePercent < 0 || c.ServiceFeePercent > 100 {
+		return errors.New("service fee percent must be between 0 and 100")
+	}
+	for country, rate := range c.TaxRates {
+		if rate < 0 || rate >= 1 {
+			return fmt.Errorf("tax rate for %s must be between 0 and 1", country)
+		}
+	}
+	for currency, rate := range c.ExchangeRates {
+		if rate <= 0 {
+			return fmt.Errorf("exchange rate for %s must be greater than 0", currency)
+		}
+	}
+	return nil
+}
+
+// QuoteTTL is how long a signed quote is honoured.
+func (c Charges) QuoteTTL() time.Duration {
+	if c.QuoteTTLSeconds == 0 {
+		return 2 * time.Minute
+	}
+	return time.Duration(c.QuoteTTLSeconds) * time.Second
+}
+
+// NormalizeCode upper-cases country and currency codes.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// NormalizeCurrency upper-cases the currency code, defaulting to USD.
+func NormalizeCurrency(currency string) string {
+	if currency = NormalizeCode(currency); currency == "" {
+		return "USD"
+	}
+	return currency
+}
+
+// Breakdown prices a ticket with the given base price and discount.
+func (c Charges) Breakdown(baseInUSCent, discountInUSCent uint64, country, currency string) (model.PriceBreakdown, error) {
+	if discountInUSCent > baseInUSCent {
+		discountInUSCent = baseInUSCent
+	}
+	subtotal := baseInUSCent - discountInUSCent
+
+	fees := c.ServiceFeeInUSCent + roundCents(float64(subtotal)*c.ServiceFeePercent/100)
+	tax := roundCents(float64(subtotal+fees) * c.TaxRates[NormalizeCode(country)])
+	total := subtotal + fees + tax
+
+	currency = NormalizeCurrency(currency)
+	rate := 1.0
+	if currency != "USD" {
+		var exists bool
+		if rate, exists = c.ExchangeRates[currency]; !exists {
+			return model.PriceBreakdown{}, ErrUnsupportedCurrency
+		}
+	}
+
+	return model.PriceBreakdown{
+		BaseInUSCent:     baseInUSCent,
+		DiscountInUSCent: discountInUSCent,
+		FeesInUSCent:     fees,
+		TaxInUSCent:      tax,
+		TotalInUSCent:    total,
+		Currency:         currency,
+		ExchangeRate:     rate,
+		TotalInCurrency:  roundCents(float64(total) * rate),
+	}, nil
+}
+
+func roundCents(amount float64) uint64 {
+	return uint64(math.Round(amount))
+}
@@ -0,0 +1,77 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestBreakdown(t *testing.T) {
+	charges := Charges{
+		ServiceFeeInUSCent: 150,
+		ServiceFeePercent:  5,
+		TaxRates:           map[string]float64{"US": 0.08},
+		ExchangeRates:      map[string]float64{"EUR": 0.9},
+	}
+
+	tests := []struct {
+		name          string
+		charges       Charges
+		base          uint64
+		discount      uint64
+		country       string
+		currency      string
+		expected      model.PriceBreakdown
+		expectedError error
+	}{
+		{
+			name:     "no charges",
+			base:     10000,
+			expected: model.PriceBreakdown{BaseInUSCent: 10000, TotalInUSCent: 10000, Currency: "USD", ExchangeRate: 1, TotalInCurrency: 10000},
+		},
+		{
+			name:     "fees and tax",
+			charges:  charges,
+			base:     10000,
+			discount: 2000,
+			country:  "us",
+			currency: "usd",
+			// subtotal 8000, fees 150+400, tax 0.08*8550
+			expected: model.PriceBreakdown{BaseInUSCent: 10000, DiscountInUSCent: 2000, FeesInUSCent: 550, TaxInUSCent: 684, TotalInUSCent: 9234, Currency: "USD", ExchangeRate: 1, TotalInCurrency: 9234},
+		},
+		{
+			name:     "currency conversion",
+			charges:  charges,
+			base:     1000,
+			country:  "DE",
+			currency: "EUR",
+			expected: model.PriceBreakdown{BaseInUSCent: 1000, FeesInUSCent: 200, TotalInUSCent: 1200, Currency: "EUR", ExchangeRate: 0.9, TotalInCurrency: 1080},
+		},
+		{
+			name:          "unsupported currency",
+			charges:       charges,
+			base:          1000,
+			currency:      "XYZ",
+			expectedError: ErrUnsupportedCurrency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown, err := tt.charges.Breakdown(tt.base, tt.discount, tt.country, tt.currency)
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("Expected error '%v', got '%v'", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if breakdown != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, breakdown)
+			}
+		})
+	}
+}
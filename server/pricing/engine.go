@@ -0,0 +1,166 @@
+package pricing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+/*
+* Dynamic price of a tier:
+  - price = base * (1 + scarcityWeight*soldRatio + velocityWeight*velocityRatio)
+  - soldRatio     : share of the tier's seats already reserved (0..1)
+  - velocityRatio : sales within the velocity window / velocity target, capped at 1
+  - the result is clamped to the tier's floor and ceiling
+*/
+
+// TierRule bounds the dynamic price of a single tier.
+type TierRule struct {
+	BaseInUSCent    uint64 `json:"baseInUSCent"`
+	FloorInUSCent   uint64 `json:"floorInUSCent"`
+	CeilingInUSCent uint64 `json:"ceilingInUSCent"`
+}
+
+type Config struct {
+	Tiers map[model.Tier]TierRule `json:"tiers"`
+
+	ScarcityWeight float64 `json:"scarcityWeight"`
+	VelocityWeight float64 `json:"velocityWeight"`
+
+	VelocityWindowSeconds uint32 `json:"velocityWindowSeconds"` // default 300
+	VelocityTarget        uint32 `json:"velocityTarget"`        // sales per window for the full velocity weight, default 10
+}
+
+// DefaultRule keeps a tier at its fixed price.
+func DefaultRule(tier model.Tier) TierRule {
+	var base uint64
+	switch tier {
+	case model.TierVIP:
+		base = uint64(model.PriceVIPCents)
+	case model.TierFrontRow:
+		base = uint64(model.PriceFrontRowCents)
+	default:
+		base = uint64(model.PriceGACents)
+	}
+	return TierRule{BaseInUSCent: base, FloorInUSCent: base, CeilingInUSCent: base}
+}
+
+// LoadConfig reads a pricing config from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parse pricing config: %w", err)
+	}
+	return cfg, nil
+}
+
+type Engine struct {
+	cfg Config
+
+	now func() time.Time
+
+	// recent sale times per tier, used for booking velocity
+	mu    sync.Mutex
+	sales map[model.Tier][]time.Time
+}
+
+func NewEngine(cfg Config) (*Engine, error) {
+	if cfg.ScarcityWeight < 0 || cfg.VelocityWeight < 0 {
+		return nil, errors.New("pricing weights must not be negative")
+	}
+	if cfg.VelocityWindowSeconds == 0 {
+		cfg.VelocityWindowSeconds = 300
+	}
+	if cfg.VelocityTarget == 0 {
+		cfg.VelocityTarget = 10
+	}
+
+	tiers := make(map[model.Tier]TierRule, len(cfg.Tiers))
+	for tier, rule := range cfg.Tiers {
+		if !tier.IsValidTier() {
+			return nil, fmt.Errorf("invalid tier %q", tier)
+		}
+		if rule.FloorInUSCent > rule.BaseInUSCent || rule.BaseInUSCent > rule.CeilingInUSCent {
+			return nil, fmt.Errorf("tier %s: floor <= base <= ceiling is required", tier)
+		}
+		tiers[tier] = rule
+	}
+	for _, tier := range model.AllTiers() {
+		if _, exists := tiers[tier]; !exists {
+			tiers[tier] = DefaultRule(tier)
+		}
+	}
+	cfg.Tiers = tiers
+
+	return &Engine{
+		cfg:   cfg,
+		now:   time.Now,
+		sales: make(map[model.Tier][]time.Time),
+	}, nil
+}
+
+// Price returns the current price of a tier given its remaining inventory.
+func (e *Engine) Price(tier model.Tier, available, total uint32) uint64 {
+	rule := e.cfg.Tiers[tier]
+
+	var soldRatio float64
+	if total > 0 && available <= total {
+		soldRatio = float64(total-available) / float64(total)
+	}
+
+	velocityRatio := float64(e.salesInWindow(tier)) / float64(e.cfg.VelocityTarget)
+	if velocityRatio > 1 {
+		velocityRatio = 1
+	}
+
+	multiplier := 1 + e.cfg.ScarcityWeight*soldRatio + e.cfg.VelocityWeight*velocityRatio
+	price := uint64(math.Round(float64(rule.BaseInUSCent) * multiplier))
+
+	if price < rule.FloorInUSCent {
+		return rule.FloorInUSCent
+	}
+	if price > rule.CeilingInUSCent {
+		return rule.CeilingInUSCent
+	}
+	return price
+}
+
+// RecordSale counts a confirmed booking towards the tier's velocity.
+func (e *Engine) RecordSale(tier model.Tier) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sales[tier] = append(e.pruneSales(tier), e.now())
+}
+
+func (e *Engine) salesInWindow(tier model.Tier) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	recent := e.pruneSales(tier)
+	e.sales[tier] = recent
+	return len(recent)
+}
+
+// pruneSales drops sales older than the velocity window. Caller holds e.mu.
+func (e *Engine) pruneSales(tier model.Tier) []time.Time {
+	cutoff := e.now().Add(-time.Duration(e.cfg.VelocityWindowSeconds) * time.Second)
+	sales := e.sales[tier]
+
+	i := 0
+	for i < len(sales) && sales[i].Before(cutoff) {
+		i++
+	}
+	return sales[i:]
+}
@@ -0,0 +1,90 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func newTestEngine(t *testing.T, now *time.Time) *Engine {
+	t.Helper()
+	e, err := NewEngine(Config{
+		Tiers: map[model.Tier]TierRule{
+			model.TierVIP: {BaseInUSCent: 10000, FloorInUSCent: 8000, CeilingInUSCent: 15000},
+		},
+		ScarcityWeight:        0.5,
+		VelocityWeight:        0.5,
+		VelocityWindowSeconds: 60,
+		VelocityTarget:        2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	e.now = func() time.Time { return *now }
+	return e
+}
+
+func TestPrice(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		tier          model.Tier
+		available     uint32
+		total         uint32
+		sales         int
+		expectedPrice uint64
+	}{
+		{name: "full inventory no sales", tier: model.TierVIP, available: 30, total: 30, expectedPrice: 10000},
+		{name: "half sold", tier: model.TierVIP, available: 15, total: 30, expectedPrice: 12500},
+		{name: "velocity raises price", tier: model.TierVIP, available: 30, total: 30, sales: 1, expectedPrice: 12500},
+		{name: "clamped to ceiling", tier: model.TierVIP, available: 0, total: 30, sales: 5, expectedPrice: 15000},
+		{name: "unconfigured tier keeps fixed price", tier: model.TierGA, available: 0, total: 40, sales: 5, expectedPrice: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, &now)
+			for i := 0; i < tt.sales; i++ {
+				e.RecordSale(tt.tier)
+			}
+
+			price := e.Price(tt.tier, tt.available, tt.total)
+			if price != tt.expectedPrice {
+				t.Errorf("Expected price %d, got %d", tt.expectedPrice, price)
+			}
+		})
+	}
+}
+
+func TestPrice_VelocityWindow(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	e := newTestEngine(t, &now)
+
+	e.RecordSale(model.TierVIP)
+	e.RecordSale(model.TierVIP)
+	if price := e.Price(model.TierVIP, 30, 30); price != 15000 {
+		t.Errorf("Expected price 15000 within window, got %d", price)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if price := e.Price(model.TierVIP, 30, 30); price != 10000 {
+		t.Errorf("Expected price 10000 after window, got %d", price)
+	}
+}
+
+func TestNewEngine_InvalidConfig(t *testing.T) {
+	_, err := NewEngine(Config{
+		Tiers: map[model.Tier]TierRule{
+			model.TierVIP: {BaseInUSCent: 10000, FloorInUSCent: 12000, CeilingInUSCent: 15000},
+		},
+	})
+	if err == nil {
+		t.Error("Expected error for floor above base, got nil")
+	}
+
+	if _, err := NewEngine(Config{ScarcityWeight: -1}); err == nil {
+		t.Error("Expected error for negative weight, got nil")
+	}
+}
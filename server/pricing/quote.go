@@ -0,0 +1,94 @@
+package pricing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	ErrQuoteInvalid  = model.NewError(model.CodeQuoteInvalid, "invalid price quote")
+	ErrQuoteExpired  = model.NewError(model.CodeQuoteExpired, "price quote expired")
+	ErrQuoteMismatch = model.NewError(model.CodeQuoteMismatch, "price quote does not match booking")
+)
+
+// Quote is the signed payload of a quote ID. Tier quotes from availability
+// only carry the tier price; seat quotes from POST /quote carry the full breakdown.
+type Quote struct {
+	Tier          model.Tier `json:"tier"`
+	PriceInUSCent uint64     `json:"price"` // base price before discounts and charges
+
+	SeatNo    uint32                `json:"seat,omitempty"`
+	PromoCode string                `json:"promo,omitempty"`
+	Country   string                `json:"country,omitempty"`
+	Breakdown *model.PriceBreakdown `json:"breakdown,omitempty"`
+
+	ExpiresAt int64 `json:"exp"` // unix seconds
+}
+
+// Matches reports whether the quote was issued for this booking order.
+func (q Quote) Matches(order model.BookingOrder) bool {
+	if q.Tier != order.Tier {
+		return false
+	}
+	if q.Breakdown == nil {
+		return true
+	}
+	return q.SeatNo == order.SeatNo &&
+		q.PromoCode == model.NormalizePromoCode(order.PromoCode) &&
+		q.Country == NormalizeCode(order.Country) &&
+		q.Breakdown.Currency == NormalizeCurrency(order.Currency)
+}
+
+// QuoteSigner issues and checks quote IDs of the form base64url(payload).base64url(hmac-sha256).
+type QuoteSigner struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewQuoteSigner(secret []byte, now func() time.Time) *QuoteSigner {
+	return &QuoteSigner{secret: secret, now: now}
+}
+
+func (s *QuoteSigner) Sign(quote Quote) string {
+	payload, _ := json.Marshal(quote)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+func (s *QuoteSigner) Parse(quoteID string) (Quote, error) {
+	encoded, signature, found := strings.Cut(quoteID, ".")
+	if !found {
+		return Quote{}, ErrQuoteInvalid
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.mac(encoded)) {
+		return Quote{}, ErrQuoteInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Quote{}, ErrQuoteInvalid
+	}
+	var quote Quote
+	if err := json.Unmarshal(payload, &quote); err != nil {
+		return Quote{}, ErrQuoteInvalid
+	}
+
+	if s.now().Unix() >= quote.ExpiresAt {
+		return Quote{}, ErrQuoteExpired
+	}
+	return quote, nil
+}
+
+func (s *QuoteSigner) mac(encoded string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(encoded))
+	return h.Sum(nil)
+}
@@ -0,0 +1,93 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestQuoteSigner(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	signer := NewQuoteSigner([]byte("test-secret"), func() time.Time { return now })
+
+	quote := Quote{
+		Tier:          model.TierVIP,
+		PriceInUSCent: 12500,
+		SeatNo:        7,
+		ExpiresAt:     now.Add(30 * time.Second).Unix(),
+	}
+	quoteID := signer.Sign(quote)
+
+	parsed, err := signer.Parse(quoteID)
+	if err != nil {
+		t.Fatalf("Expected valid quote, got %v", err)
+	}
+	if parsed.PriceInUSCent != quote.PriceInUSCent || parsed.SeatNo != quote.SeatNo {
+		t.Errorf("Expected %+v, got %+v", quote, parsed)
+	}
+
+	tests := []struct {
+		name          string
+		quoteID       string
+		signer        *QuoteSigner
+		expectedError error
+	}{
+		{name: "tampered payload", quoteID: "f" + quoteID[1:], signer: signer, expectedError: ErrQuoteInvalid},
+		{name: "tampered signature", quoteID: quoteID + "A", signer: signer, expectedError: ErrQuoteInvalid},
+		{name: "no signature", quoteID: "abc", signer: signer, expectedError: ErrQuoteInvalid},
+		{
+			name:          "other secret",
+			quoteID:       quoteID,
+			signer:        NewQuoteSigner([]byte("other-secret"), func() time.Time { return now }),
+			expectedError: ErrQuoteInvalid,
+		},
+		{
+			name:          "expired",
+			quoteID:       quoteID,
+			signer:        NewQuoteSigner([]byte("test-secret"), func() time.Time { return now.Add(31 * time.Second) }),
+			expectedError: ErrQuoteExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.signer.Parse(tt.quoteID); !errors.Is(err, tt.expectedError) {
+				t.Errorf("Expected error '%v', got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestQuoteMatches(t *testing.T) {
+	seatQuote := Quote{
+		Tier:      model.TierVIP,
+		SeatNo:    7,
+		PromoCode: "SAVE10",
+		Country:   "US",
+		Breakdown: &model.PriceBreakdown{Currency: "USD"},
+	}
+	order := model.BookingOrder{Tier: model.TierVIP, SeatNo: 7, PromoCode: "save10", Country: "us"}
+
+	if !seatQuote.Matches(order) {
+		t.Error("Expected seat quote to match order")
+	}
+
+	otherSeat := order
+	otherSeat.SeatNo = 8
+	if seatQuote.Matches(otherSeat) {
+		t.Error("Expected seat quote not to match another seat")
+	}
+
+	noPromo := order
+	noPromo.PromoCode = ""
+	if seatQuote.Matches(noPromo) {
+		t.Error("Expected seat quote not to match an order without its promo code")
+	}
+
+	tierQuote := Quote{Tier: model.TierVIP}
+	if !tierQuote.Matches(otherSeat) {
+		t.Error("Expected tier quote to match any seat of the tier")
+	}
+}
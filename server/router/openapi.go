@@ -0,0 +1,142 @@
+package router
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/openapi"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+func apiVersion(version utils.APIVersion) string {
+	return strconv.Itoa(int(version)) + ".0.0"
+}
+
+// module is a route table mounted under a path prefix.
+type module struct {
+	prefix   string
+	routes   []Route
+	security []string
+	tag      string
+
+	// served once at the root rather than under each version prefix, and
+	// documented in the latest version's document only
+	unversioned bool
+}
+
+func modules() []module {
+	return []module{
+		{prefix: "/booking", routes: bookingRoutes, security: []string{"bearerAuth", "apiKey"}},
+		{prefix: "/users", routes: usersRoutes, security: []string{"bearerAuth", "apiKey"}},
+		{prefix: "/admin", routes: adminRoutes, security: []string{"bearerAuth"}},
+		{prefix: "", routes: healthRoutes, tag: "health", unversioned: true},
+	}
+}
+
+// path is where the module serves a route's path to version clients.
+func (m module) path(version utils.APIVersion, path string) string {
+	if m.unversioned {
+		return m.prefix + path
+	}
+	return "/" + version.String() + m.prefix + path
+}
+
+// Spec builds the OpenAPI document of every module's routes as served under
+// the version's path prefix (at the root when unversioned), with responses in
+// that version's shape.
+func Spec(version utils.APIVersion) *openapi.Document {
+	builder := openapi.NewBuilder("Concert Ticket Booking API", apiVersion(version), adapted(version, model.Problem{}))
+
+	builder.Enum(model.Tier(""), enumValues(model.AllTiers())...)
+	builder.Enum(model.BookingStatus(""), enumValues([]model.BookingStatus{
+		model.BookingStatusPending, model.BookingStatusConfirmed, model.BookingStatusFailed, model.BookingStatusCanceled,
+	})...)
+	builder.Enum(model.PaymentStatus(""), enumValues([]model.PaymentStatus{
+		model.PaymentStatusPending, model.PaymentStatusConfirmed, model.PaymentStatusFailed, model.PaymentStatusCanceled,
+	})...)
+	builder.Enum(model.SeatState(""), enumValues([]model.SeatState{
+		model.SeatStateBooked, model.SeatStateHeld, model.SeatStateReleased,
+	})...)
+	builder.Enum(model.SaleState(""), enumValues([]model.SaleState{
+		model.SaleStateNotOnSale, model.SaleStatePresale, model.SaleStateOnSale, model.SaleStateEnded,
+	})...)
+
+	builder.SecurityScheme("bearerAuth", openapi.SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"})
+	builder.SecurityScheme("apiKey", openapi.SecurityScheme{Type: "apiKey", Name: auth.APIKeyHeader, In: "header"})
+
+	for _, module := range modules() {
+		if module.unversioned && version != utils.LatestAPIVersion {
+			continue
+		}
+		tag := module.tag
+		if tag == "" {
+			tag = strings.TrimPrefix(module.prefix, "/")
+		}
+		for _, route := range module.routes {
+			if !route.enabled() {
+				continue
+			}
+			method, path, _ := strings.Cut(route.Pattern, " ")
+			builder.Add(openapi.Operation{
+				Method:   method,
+				Path:     module.path(version, path),
+				Summary:  route.Summary,
+				Tag:      tag,
+				Query:    route.Query,
+				Security: module.security,
+				Request:  route.Request,
+				Response: adapted(version, route.Response),
+
+				MediaType: route.MediaType,
+			})
+		}
+	}
+	return builder.Document()
+}
+
+func enumValues[T ~string](values []T) []string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+	return strs
+}
+
+// adapted returns a zero value of the type response is served as to version clients.
+func adapted(version utils.APIVersion, response any) any {
+	if response == nil {
+		return nil
+	}
+	return reflect.Zero(utils.AdaptedType(version, reflect.TypeOf(response))).Interface()
+}
+
+// OpenAPIRouter serves each version's document at /{version}/openapi.json,
+// and the latest one at /openapi.json.
+func OpenAPIRouter(mux *http.ServeMux) {
+	for _, version := range utils.APIVersions {
+		serveSpec(mux, "GET /"+version.String()+"/openapi.json", version)
+	}
+	serveSpec(mux, "GET /openapi.json", utils.LatestAPIVersion)
+}
+
+func serveSpec(mux *http.ServeMux, pattern string, version utils.APIVersion) {
+	spec := sync.OnceValue(func() []byte {
+		body, err := json.MarshalIndent(Spec(version), "", "  ")
+		if err != nil {
+			slog.Error("failed to encode OpenAPI document", "version", version, "err", err)
+		}
+		return body
+	})
+
+	mux.Handle(pattern, utils.Route(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(spec())
+	})))
+}
@@ -0,0 +1,254 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/handlers"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+var mounts = map[string]func(*http.ServeMux){
+	"/booking": BookingRouter,
+	"/users":   UsersRouter,
+	"/admin":   AdminRouter,
+	"":         HealthRouter,
+}
+
+// testServer mounts every module under each version like cmd/resolver does, without authentication.
+func testServer() *http.ServeMux {
+	mux := http.NewServeMux()
+	OpenAPIRouter(mux)
+	HealthRouter(mux)
+	for _, version := range utils.APIVersions {
+		for prefix, mount := range mounts {
+			if prefix == "" {
+				continue // the health module, unversioned
+			}
+			moduleMux := http.NewServeMux()
+			mount(moduleMux)
+			prefix = "/" + version.String() + prefix
+			mux.Handle(prefix+"/", utils.WithAPIVersion(version)(http.StripPrefix(prefix, moduleMux)))
+		}
+	}
+	return mux
+}
+
+var pathParam = regexp.MustCompile(`\{[^}]+\}`)
+
+func TestSpecMatchesRoutes(t *testing.T) {
+	doc := Spec(utils.LatestAPIVersion)
+
+	routeCount := 0
+	for _, module := range modules() {
+		routeCount += len(module.routes)
+	}
+	operationCount := 0
+	for _, item := range doc.Paths {
+		operationCount += len(*item)
+	}
+	if operationCount != routeCount {
+		t.Errorf("Expected %d operations, got %d", routeCount, operationCount)
+	}
+
+	for _, module := range modules() {
+		moduleMux := http.NewServeMux()
+		mounts[module.prefix](moduleMux)
+
+		for _, route := range module.routes {
+			method, path, _ := strings.Cut(route.Pattern, " ")
+			specPath := module.path(utils.LatestAPIVersion, path)
+			if _, exists := doc.Operation(method, pathParam.ReplaceAllString(specPath, "1")); !exists {
+				t.Errorf("Expected %s %s in the spec", method, specPath)
+			}
+
+			req := httptest.NewRequest(method, pathParam.ReplaceAllString(path, "1"), nil)
+			if _, pattern := moduleMux.Handler(req); pattern != route.Pattern {
+				t.Errorf("Expected the %s mux to serve %s, got pattern '%s'", module.prefix, route.Pattern, pattern)
+			}
+		}
+	}
+}
+
+func TestSpecEnums(t *testing.T) {
+	schemas := Spec(utils.LatestAPIVersion).Components.Schemas
+
+	valid := map[string]func(string) bool{
+		"tier":          func(v string) bool { return model.Tier(v).IsValidTier() },
+		"status":        func(v string) bool { return model.BookingStatus(v).IsValidBookingStatus() },
+		"paymentStatus": func(v string) bool { return model.PaymentStatus(v).IsValidPaymentStatus() },
+	}
+	for field, isValid := range valid {
+		enum := schemas["Booking"].Properties[field].Enum
+		if len(enum) == 0 {
+			t.Errorf("Expected Booking.%s to be an enum", field)
+		}
+		for _, value := range enum {
+			if !isValid(value) {
+				t.Errorf("Booking.%s enum value %s is rejected by the model", field, value)
+			}
+		}
+	}
+}
+
+// TestSpecDescribesResponses drives every route through the real handlers and
+// checks each response body against the schema the spec declares for it.
+func TestSpecDescribesResponses(t *testing.T) {
+	server := testServer()
+	for i, version := range utils.APIVersions {
+		t.Run(version.String(), func(t *testing.T) {
+			describeResponses(t, server, version, uint32(i)*20)
+		})
+	}
+}
+
+func describeResponses(t *testing.T, server http.Handler, version utils.APIVersion, seatOffset uint32) {
+	doc := Spec(version)
+	prefix := "/" + version.String()
+
+	callPath := func(method, path string, body any) []byte {
+		t.Helper()
+		var reader *bytes.Reader
+		if raw, isRaw := body.(string); isRaw {
+			reader = bytes.NewReader([]byte(raw))
+		} else {
+			encoded, _ := json.Marshal(body)
+			reader = bytes.NewReader(encoded)
+		}
+		if body == nil {
+			reader = bytes.NewReader(nil)
+		}
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, reader)
+		server.ServeHTTP(w, req)
+		if err := doc.ValidateResponse(method, req.URL.Path, w.Code, w.Body.Bytes()); err != nil {
+			t.Errorf("%s %s (%d): %v\n%s", method, path, w.Code, err, w.Body.String())
+		}
+		return w.Body.Bytes()
+	}
+	call := func(method, path string, body any) []byte {
+		t.Helper()
+		return callPath(method, prefix+path, body)
+	}
+
+	order := model.BookingOrder{
+		UserID:         "user-openapi",
+		Tier:           model.TierGA,
+		SeatNo:         62 + seatOffset,
+		Country:        "US",
+		ZipCode:        "10001",
+		Currency:       "USD",
+		IdempotencyKey: "key-openapi-" + version.String(),
+		PaymentID:      "pay-openapi",
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	}
+
+	call(http.MethodGet, "/booking/availability", nil)
+	call(http.MethodPost, "/booking/quote", model.QuoteRequest{Tier: model.TierGA, SeatNo: 61, Country: "US"})
+
+	var booked model.BookingResponse
+	json.Unmarshal(call(http.MethodPost, "/booking/ticket", order), &booked)
+	if booked.Booking == nil {
+		t.Fatal("Expected the booking to succeed")
+	}
+	bookingID := booked.Booking.ID.String()
+
+	call(http.MethodGet, "/booking/"+bookingID, nil)
+	call(http.MethodGet, "/users/user-openapi/bookings?limit=1", nil)
+
+	call(http.MethodGet, "/admin/bookings?tier=GA", nil)
+	call(http.MethodPost, fmt.Sprintf("/admin/seats/%d/block", 70+seatOffset), model.AdminReasonRequest{Reason: "camera"})
+	call(http.MethodGet, "/admin/seats/blocked", nil)
+	call(http.MethodDelete, fmt.Sprintf("/admin/seats/%d/block", 70+seatOffset), nil)
+	call(http.MethodPost, "/admin/comp", model.CompTicketRequest{UserID: "guest", Tier: model.TierGA, SeatNo: 71 + seatOffset, Reason: "press"})
+	call(http.MethodGet, "/admin/idempotency/"+order.IdempotencyKey, nil)
+	call(http.MethodPost, "/admin/bookings/"+bookingID+"/cancel", model.AdminReasonRequest{Reason: "refund"})
+	call(http.MethodGet, "/admin/audit", nil)
+
+	if version == utils.LatestAPIVersion {
+		callPath(http.MethodGet, "/healthz", nil)
+		callPath(http.MethodGet, "/readyz", nil) // not ready: nothing marked it
+		handlers.MarkReady(handlers.ReadyConfig)
+		handlers.MarkReady(handlers.ReadyStore)
+		callPath(http.MethodGet, "/readyz", nil)
+		callPath(http.MethodGet, "/status", nil)
+	}
+
+	// problem details
+	call(http.MethodPost, "/booking/ticket", `{"seatNo":1,"complimentary":true}`)
+	call(http.MethodGet, "/booking/not-a-uuid", nil)
+	call(http.MethodDelete, fmt.Sprintf("/admin/seats/%d/block", 70+seatOffset), nil)
+}
+
+func TestOpenAPIRouter(t *testing.T) {
+	tests := []struct {
+		path            string
+		expectedPath    string
+		expectedVersion string
+	}{
+		{path: "/openapi.json", expectedPath: "/v2/booking/{id}", expectedVersion: "2.0.0"},
+		{path: "/v1/openapi.json", expectedPath: "/v1/booking/{id}", expectedVersion: "1.0.0"},
+		{path: "/v2/openapi.json", expectedPath: "/v2/booking/{id}", expectedVersion: "2.0.0"},
+	}
+
+	server := testServer()
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			var doc struct {
+				OpenAPI string         `json:"openapi"`
+				Info    map[string]any `json:"info"`
+				Paths   map[string]any `json:"paths"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+				t.Fatalf("Failed to decode spec: %v", err)
+			}
+			if doc.OpenAPI != "3.1.0" {
+				t.Errorf("Expected openapi 3.1.0, got '%s'", doc.OpenAPI)
+			}
+			if doc.Info["version"] != tt.expectedVersion {
+				t.Errorf("Expected version %s, got %v", tt.expectedVersion, doc.Info["version"])
+			}
+			if _, exists := doc.Paths[tt.expectedPath]; !exists {
+				t.Errorf("Expected %s in paths, got %v", tt.expectedPath, doc.Paths)
+			}
+		})
+	}
+}
+
+func TestDisableFeature(t *testing.T) {
+	DisableFeature(FeatureSeatMap)
+	t.Cleanup(func() { delete(disabledFeatures, FeatureSeatMap) })
+
+	doc := Spec(utils.LatestAPIVersion)
+	seatMapPath := "/" + utils.LatestAPIVersion.String() + "/booking/seatmap"
+	if _, exists := doc.Paths[seatMapPath]; exists {
+		t.Errorf("Expected %s left out of the spec", seatMapPath)
+	}
+	if _, exists := doc.Paths["/"+utils.LatestAPIVersion.String()+"/booking/availability/stream"]; !exists {
+		t.Errorf("Expected the availability stream still documented")
+	}
+
+	// without the seat map, /seatmap is a booking lookup of an invalid ID
+	mux := http.NewServeMux()
+	BookingRouter(mux)
+	req := httptest.NewRequest(http.MethodGet, "/seatmap", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "invalid booking id") {
+		t.Errorf("Expected the booking lookup to answer, got %s", w.Body.String())
+	}
+}
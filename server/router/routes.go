@@ -2,13 +2,252 @@ package router
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/ignius299792458/techkraft-ch-svr/auth"
 	"github.com/ignius299792458/techkraft-ch-svr/handlers"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
 )
 
+// Route is one endpoint of a module. The mux and the OpenAPI document are
+// both built from the route tables, so a route can't be served undocumented.
+type Route struct {
+	Pattern string // "METHOD /path", relative to the module prefix
+	Summary string
+	Scope   model.APIScope // required of partner API keys; empty when unscoped
+	Query   []string       // optional query parameters
+	Handler http.HandlerFunc
+
+	// Request is the JSON body the handler decodes (nil for none), Response what it encodes on success
+	Request  any
+	Response any
+
+	// MediaType of the response when not JSON, e.g. text/event-stream
+	MediaType string
+
+	// Feature that serves the route, when it can be switched off
+	Feature Feature
+}
+
+// Feature is an optional part of the API, on unless disabled at startup.
+type Feature string
+
+const (
+	FeatureAvailabilityStream Feature = "availabilityStream"
+	FeatureSeatMap            Feature = "seatMap"
+)
+
+var disabledFeatures = make(map[Feature]bool)
+
+// DisableFeature stops serving and documenting the feature's routes. It must
+// be called before the routers are mounted.
+func DisableFeature(feature Feature) {
+	disabledFeatures[feature] = true
+}
+
+func (route Route) enabled() bool {
+	return route.Feature == "" || !disabledFeatures[route.Feature]
+}
+
+func (route Route) handler() http.Handler {
+	if route.Scope == "" {
+		return route.Handler
+	}
+	return auth.RequireScope(route.Scope, route.Handler)
+}
+
+var bookingRoutes = []Route{
+	{
+		Pattern:  "GET /availability",
+		Summary:  "Seats, prices and sale state per tier",
+		Scope:    model.ScopeAvailabilityRead,
+		Handler:  handlers.HandleAvailability,
+		Response: model.AvailabilityResponse{},
+	},
+	{
+		Pattern:   "GET /availability/stream",
+		Summary:   "Server-Sent Events of seat changes, after an availability snapshot",
+		Scope:     model.ScopeAvailabilityRead,
+		Query:     []string{"lastEventId"},
+		Handler:   handlers.HandleAvailabilityStream,
+		Response:  model.SeatEvent{},
+		MediaType: "text/event-stream",
+		Feature:   FeatureAvailabilityStream,
+	},
+	{
+		Pattern: "GET /seatmap",
+		Summary: "WebSocket upgrade: live seat map, with seat holds sent over the same connection",
+		Scope:   model.ScopeAvailabilityRead,
+		Query:   []string{"ticket", "userId", "lastEventId"},
+		Handler: handlers.HandleSeatMapSocket,
+		Feature: FeatureSeatMap,
+	},
+	{
+		Pattern:  "POST /seatmap/ticket",
+		Summary:  "One-time ticket to open the seat map WebSocket with, for browsers that can't send credentials on it",
+		Scope:    model.ScopeAvailabilityRead,
+		Handler:  handlers.HandleSeatMapTicket,
+		Response: model.SeatMapTicketResponse{},
+		Feature:  FeatureSeatMap,
+	},
+	{
+		Pattern:  "POST /quote",
+		Summary:  "Price a seat, with fees, tax and promo discount",
+		Scope:    model.ScopeBookingWrite,
+		Handler:  handlers.HandleQuote,
+		Request:  model.QuoteRequest{},
+		Response: model.QuoteResponse{},
+	},
+	{
+		Pattern:  "POST /ticket",
+		Summary:  "Book a seat",
+		Scope:    model.ScopeBookingWrite,
+		Handler:  handlers.HandleBooking,
+		Request:  model.BookingOrder{},
+		Response: model.BookingResponse{},
+	},
+	{
+		Pattern:  "GET /{id}",
+		Summary:  "Look up a booking by ID",
+		Scope:    model.ScopeBookingRead,
+		Handler:  handlers.HandleGetBooking,
+		Response: model.BookingResponse{},
+	},
+}
+
+var usersRoutes = []Route{
+	{
+		Pattern:  "GET /{userId}/bookings",
+		Summary:  "A user's bookings, oldest first, cursor-paginated; API keys see those their channel sold",
+		Scope:    model.ScopeBookingRead,
+		Query:    []string{"status", "cursor", "limit"},
+		Handler:  handlers.HandleListUserBookings,
+		Response: model.UserBookingsResponse{},
+	},
+}
+
+var adminRoutes = []Route{
+	{
+		Pattern:  "GET /bookings",
+		Summary:  "Search bookings",
+		Query:    []string{"userId", "status", "tier", "channel"},
+		Handler:  handlers.HandleAdminListBookings,
+		Response: model.AdminBookingsResponse{},
+	},
+	{
+		Pattern:  "POST /bookings/{id}/cancel",
+		Summary:  "Force-cancel a booking and free its seat",
+		Handler:  handlers.HandleAdminCancelBooking,
+		Request:  model.AdminReasonRequest{},
+		Response: model.BookingResponse{},
+	},
+	{
+		Pattern:  "GET /seats/blocked",
+		Summary:  "List blocked seats",
+		Handler:  handlers.HandleAdminBlockedSeats,
+		Response: model.SeatBlocksResponse{},
+	},
+	{
+		Pattern:  "POST /seats/{seatNo}/block",
+		Summary:  "Take a free seat off sale",
+		Handler:  handlers.HandleAdminBlockSeat,
+		Request:  model.AdminReasonRequest{},
+		Response: model.SeatBlocksResponse{},
+	},
+	{
+		Pattern:  "DELETE /seats/{seatNo}/block",
+		Summary:  "Put a blocked seat back on sale",
+		Handler:  handlers.HandleAdminUnblockSeat,
+		Response: model.SeatBlocksResponse{},
+	},
+	{
+		Pattern:  "POST /comp",
+		Summary:  "Issue a complimentary ticket",
+		Handler:  handlers.HandleAdminCompTicket,
+		Request:  model.CompTicketRequest{},
+		Response: model.BookingResponse{},
+	},
+	{
+		Pattern:  "GET /idempotency/{key}",
+		Summary:  "Order stored under an idempotency key",
+		Handler:  handlers.HandleAdminIdempotencyRecord,
+		Response: model.IdempotencyRecordResponse{},
+	},
+	{
+		Pattern:  "GET /audit",
+		Summary:  "The latest admin actions, oldest first",
+		Handler:  handlers.HandleAdminAudit,
+		Response: model.AdminAuditResponse{},
+	},
+	{
+		Pattern:  "GET /bookings/{id}/history",
+		Summary:  "Every transition of a booking, oldest first, from the booking audit log",
+		Handler:  handlers.HandleAdminBookingHistory,
+		Response: model.BookingHistoryResponse{},
+	},
+	{
+		Pattern:  "GET /audit/bookings/verify",
+		Summary:  "Check the booking audit log's hash chain",
+		Handler:  handlers.HandleAdminVerifyBookingAudit,
+		Response: model.AuditVerificationResponse{},
+	},
+}
+
+// healthRoutes are for load balancers, orchestrators and operators: served at
+// the root, outside the version prefixes, and never authenticated. Only the
+// latest version's document describes them.
+var healthRoutes = []Route{
+	{
+		Pattern:  "GET /healthz",
+		Summary:  "Liveness: the process serves requests",
+		Handler:  handlers.HandleHealth,
+		Response: model.HealthResponse{},
+	},
+	{
+		Pattern:  "GET /readyz",
+		Summary:  "Readiness: started, store saved and not shutting down; 503 otherwise",
+		Handler:  handlers.HandleReadiness,
+		Response: model.ReadinessResponse{},
+	},
+	{
+		Pattern:  "GET /status",
+		Summary:  "Readiness checks, uptime, build, seats per tier and idempotency records",
+		Handler:  handlers.HandleStatus,
+		Response: model.StatusResponse{},
+	},
+}
+
+// register serves the module's routes on mux, named with the module prefix in metrics, traces and logs.
+func register(mux *http.ServeMux, prefix string, routes []Route) {
+	for _, route := range routes {
+		if !route.enabled() {
+			continue
+		}
+		method, path, _ := strings.Cut(route.Pattern, " ")
+		mux.Handle(route.Pattern, utils.Route(method+" "+prefix+path, route.handler()))
+	}
+}
+
 func BookingRouter(bookingMux *http.ServeMux) {
+	register(bookingMux, "/booking", bookingRoutes)
+}
 
-	bookingMux.HandleFunc("GET /availability", handlers.HandleAvailability)
+func UsersRouter(usersMux *http.ServeMux) {
+	register(usersMux, "/users", usersRoutes)
+}
+
+func AdminRouter(adminMux *http.ServeMux) {
+	register(adminMux, "/admin", adminRoutes)
+}
 
-	bookingMux.HandleFunc("POST /ticket", handlers.HandleBooking)
+// HealthRouter serves the health routes at the root of mux, in the latest API
+// version's shape since no version prefix picks one.
+func HealthRouter(mux *http.ServeMux) {
+	healthMux := http.NewServeMux()
+	register(healthMux, "", healthRoutes)
+	latest := utils.WithAPIVersion(utils.LatestAPIVersion)(healthMux)
+	for _, route := range healthRoutes {
+		mux.Handle(route.Pattern, latest)
+	}
 }
@@ -0,0 +1,148 @@
+package sales
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	ErrNotOnSale          = model.NewError(model.CodeNotOnSale, "tier is not on sale yet")
+	ErrSaleEnded          = model.NewError(model.CodeSaleEnded, "sale has ended")
+	ErrAccessCodeRequired = model.NewError(model.CodeAccessCodeRequired, "presale access code required")
+	ErrAccessCodeInvalid  = model.NewError(model.CodeAccessCodeInvalid, "invalid presale access code")
+)
+
+/*
+* On-sale window of a tier:
+  - before PresaleStart           : NOT_ON_SALE
+  - PresaleStart..GeneralSaleStart : PRESALE (access code required)
+  - GeneralSaleStart..SaleEnd      : ON_SALE
+  - from SaleEnd                   : ENDED
+  - zero times leave that boundary open, so the zero Window is always ON_SALE
+*/
+type Window struct {
+	PresaleStart     time.Time `json:"presaleStart,omitzero"`
+	GeneralSaleStart time.Time `json:"generalSaleStart,omitzero"`
+	SaleEnd          time.Time `json:"saleEnd,omitzero"`
+
+	// Codes that unlock purchase during presale (case-insensitive)
+	AccessCodes []string `json:"accessCodes,omitempty"`
+}
+
+// Schedule holds the event-wide window, with optional per-tier overrides.
+type Schedule struct {
+	Event Window                `json:"event"`
+	Tiers map[model.Tier]Window `json:"tiers,omitempty"`
+}
+
+// LoadSchedule reads a sale schedule from a JSON file.
+func LoadSchedule(path string) (Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return Schedule{}, fmt.Errorf("parse sale schedule: %w", err)
+	}
+	return schedule, schedule.Validate()
+}
+
+func (s Schedule) Validate() error {
+	if err := s.Event.Validate(); err != nil {
+		return fmt.Errorf("event: %w", err)
+	}
+	for tier, window := range s.Tiers {
+		if !tier.IsValidTier() {
+			return fmt.Errorf("invalid tier %q", tier)
+		}
+		if err := window.Validate(); err != nil {
+			return fmt.Errorf("tier %s: %w", tier, err)
+		}
+	}
+	return nil
+}
+
+// WindowFor returns the tier's own window, falling back to the event window.
+func (s Schedule) WindowFor(tier model.Tier) Window {
+	if window, exists := s.Tiers[tier]; exists {
+		return window
+	}
+	return s.Event
+}
+
+// CheckBookable returns an error unless the tier can be booked at now with the given access code.
+func (s Schedule) CheckBookable(tier model.Tier, accessCode string, now time.Time) error {
+	return s.WindowFor(tier).CheckBookable(accessCode, now)
+}
+
+func (w Window) Validate() error {
+	if !w.PresaleStart.IsZero() && w.GeneralSaleStart.IsZero() {
+		return errors.New("generalSaleStart is required with presaleStart")
+	}
+	if !w.PresaleStart.IsZero() && !w.GeneralSaleStart.After(w.PresaleStart) {
+		return errors.New("generalSaleStart must be after presaleStart")
+	}
+	if !w.SaleEnd.IsZero() && !w.GeneralSaleStart.IsZero() && !w.SaleEnd.After(w.GeneralSaleStart) {
+		return errors.New("saleEnd must be after generalSaleStart")
+	}
+	if !w.PresaleStart.IsZero() && len(w.AccessCodes) == 0 {
+		return errors.New("accessCodes are required for a presale")
+	}
+	return nil
+}
+
+// StateAt returns the sale state at now.
+func (w Window) StateAt(now time.Time) model.SaleState {
+	switch {
+	case !w.SaleEnd.IsZero() && !now.Before(w.SaleEnd):
+		return model.SaleStateEnded
+	case w.GeneralSaleStart.IsZero() || !now.Before(w.GeneralSaleStart):
+		return model.SaleStateOnSale
+	case !w.PresaleStart.IsZero() && !now.Before(w.PresaleStart):
+		return model.SaleStatePresale
+	default:
+		return model.SaleStateNotOnSale
+	}
+}
+
+// NextChange returns when the state at now next changes, or the zero time if it won't.
+func (w Window) NextChange(now time.Time) time.Time {
+	for _, boundary := range []time.Time{w.PresaleStart, w.GeneralSaleStart, w.SaleEnd} {
+		if !boundary.IsZero() && now.Before(boundary) {
+			return boundary
+		}
+	}
+	return time.Time{}
+}
+
+func (w Window) CheckBookable(accessCode string, now time.Time) error {
+	switch w.StateAt(now) {
+	case model.SaleStateOnSale:
+		return nil
+	case model.SaleStateEnded:
+		return ErrSaleEnded
+	case model.SaleStateNotOnSale:
+		return ErrNotOnSale
+	}
+
+	// presale
+	accessCode = strings.ToUpper(strings.TrimSpace(accessCode))
+	if accessCode == "" {
+		return ErrAccessCodeRequired
+	}
+	for _, code := range w.AccessCodes {
+		if subtle.ConstantTimeCompare([]byte(strings.ToUpper(code)), []byte(accessCode)) == 1 {
+			return nil
+		}
+	}
+	return ErrAccessCodeInvalid
+}
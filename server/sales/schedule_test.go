@@ -0,0 +1,110 @@
+package sales
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	presaleStart     = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	generalSaleStart = time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)
+	saleEnd          = time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testWindow() Window {
+	return Window{
+		PresaleStart:     presaleStart,
+		GeneralSaleStart: generalSaleStart,
+		SaleEnd:          saleEnd,
+		AccessCodes:      []string{"FANCLUB"},
+	}
+}
+
+func TestWindowStateAt(t *testing.T) {
+	tests := []struct {
+		name          string
+		window        Window
+		now           time.Time
+		expectedState model.SaleState
+		expectedNext  time.Time
+	}{
+		{name: "before presale", window: testWindow(), now: presaleStart.Add(-time.Second), expectedState: model.SaleStateNotOnSale, expectedNext: presaleStart},
+		{name: "presale", window: testWindow(), now: presaleStart, expectedState: model.SaleStatePresale, expectedNext: generalSaleStart},
+		{name: "general sale", window: testWindow(), now: generalSaleStart, expectedState: model.SaleStateOnSale, expectedNext: saleEnd},
+		{name: "ended", window: testWindow(), now: saleEnd, expectedState: model.SaleStateEnded},
+		{name: "no window is always on sale", window: Window{}, now: saleEnd, expectedState: model.SaleStateOnSale},
+		{
+			name:          "general sale without presale",
+			window:        Window{GeneralSaleStart: generalSaleStart},
+			now:           presaleStart,
+			expectedState: model.SaleStateNotOnSale,
+			expectedNext:  generalSaleStart,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if state := tt.window.StateAt(tt.now); state != tt.expectedState {
+				t.Errorf("Expected state %s, got %s", tt.expectedState, state)
+			}
+			if next := tt.window.NextChange(tt.now); !next.Equal(tt.expectedNext) {
+				t.Errorf("Expected next change %v, got %v", tt.expectedNext, next)
+			}
+		})
+	}
+}
+
+func TestScheduleCheckBookable(t *testing.T) {
+	schedule := Schedule{
+		Event: Window{GeneralSaleStart: generalSaleStart},
+		Tiers: map[model.Tier]Window{model.TierVIP: testWindow()},
+	}
+	duringPresale := presaleStart.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		tier          model.Tier
+		accessCode    string
+		now           time.Time
+		expectedError error
+	}{
+		{name: "presale with access code", tier: model.TierVIP, accessCode: "fanclub", now: duringPresale},
+		{name: "presale without access code", tier: model.TierVIP, now: duringPresale, expectedError: ErrAccessCodeRequired},
+		{name: "presale with wrong access code", tier: model.TierVIP, accessCode: "GUESS", now: duringPresale, expectedError: ErrAccessCodeInvalid},
+		{name: "tier without presale falls back to event window", tier: model.TierGA, accessCode: "FANCLUB", now: duringPresale, expectedError: ErrNotOnSale},
+		{name: "general sale needs no code", tier: model.TierVIP, now: generalSaleStart},
+		{name: "after sale end", tier: model.TierVIP, now: saleEnd, expectedError: ErrSaleEnded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schedule.CheckBookable(tt.tier, tt.accessCode, tt.now)
+			if !errors.Is(err, tt.expectedError) {
+				t.Errorf("Expected error '%v', got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		window Window
+	}{
+		{name: "presale without general sale", window: Window{PresaleStart: presaleStart, AccessCodes: []string{"X"}}},
+		{name: "general sale before presale", window: Window{PresaleStart: generalSaleStart, GeneralSaleStart: presaleStart, AccessCodes: []string{"X"}}},
+		{name: "sale end before general sale", window: Window{GeneralSaleStart: saleEnd, SaleEnd: generalSaleStart}},
+		{name: "presale without access codes", window: Window{PresaleStart: presaleStart, GeneralSaleStart: generalSaleStart}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (Schedule{Event: tt.window}).Validate(); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
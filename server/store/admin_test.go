@@ -0,0 +1,221 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func registerTestBooking(t *testing.T, bs BookingStore, userID string, tier model.Tier, seatNo uint32) model.Booking {
+	t.Helper()
+
+	booking, err := bs.RegisterBooking(model.BookingOrder{
+		UserID:         userID,
+		Tier:           tier,
+		SeatNo:         seatNo,
+		Status:         model.BookingStatusPending,
+		IdempotencyKey: "key-" + userID,
+		Currency:       "USD",
+		PaymentID:      "pay-" + userID,
+		PaymentStatus:  model.PaymentStatusConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register booking: %v", err)
+	}
+	return booking
+}
+
+func TestListBookings(t *testing.T) {
+	bs := NewBookingStoreBucket()
+	registerTestBooking(t, bs, "user-1", model.TierVIP, 1)
+	registerTestBooking(t, bs, "user-2", model.TierVIP, 2)
+	registerTestBooking(t, bs, "user-1", model.TierGA, 61)
+
+	tests := []struct {
+		name          string
+		filter        model.BookingFilter
+		expectedCount int
+	}{
+		{name: "no filter", filter: model.BookingFilter{}, expectedCount: 3},
+		{name: "by user", filter: model.BookingFilter{UserID: "user-1"}, expectedCount: 2},
+		{name: "by tier", filter: model.BookingFilter{Tier: model.TierVIP}, expectedCount: 2},
+		{name: "by user and tier", filter: model.BookingFilter{UserID: "user-1", Tier: model.TierGA}, expectedCount: 1},
+		{name: "by status", filter: model.BookingFilter{Status: model.BookingStatusCanceled}, expectedCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookings := bs.ListBookings(tt.filter)
+			if len(bookings) != tt.expectedCount {
+				t.Errorf("Expected %d bookings, got %d", tt.expectedCount, len(bookings))
+			}
+		})
+	}
+}
+
+func TestCancelBooking(t *testing.T) {
+	bs := NewBookingStoreBucket()
+	booking := registerTestBooking(t, bs, "user-1", model.TierVIP, 1)
+
+	canceled, err := bs.CancelBooking(booking.ID)
+	if err != nil {
+		t.Fatalf("Failed to cancel booking: %v", err)
+	}
+	if canceled.Status != model.BookingStatusCanceled {
+		t.Errorf("Expected status CANCELED, got %s", canceled.Status)
+	}
+
+	// the seat is free again, the booking is still searchable
+	if _, err := bs.GetBooking(1); !errors.Is(err, ErrBookingNotFound) {
+		t.Errorf("Expected seat 1 to be free, got %v", err)
+	}
+	if found, err := bs.GetBookingByID(booking.ID); err != nil || found.Status != model.BookingStatusCanceled {
+		t.Errorf("Expected canceled booking by ID, got %+v, %v", found, err)
+	}
+
+	if _, err := bs.CancelBooking(booking.ID); !errors.Is(err, ErrBookingAlreadyCancel) {
+		t.Errorf("Expected ErrBookingAlreadyCancel, got %v", err)
+	}
+
+	// the freed seat can be booked again
+	registerTestBooking(t, bs, "user-2", model.TierVIP, 1)
+
+	// a booking whose payment failed is canceled already
+	failed, _ := bs.RegisterBooking(model.BookingOrder{UserID: "user-3", Tier: model.TierVIP, SeatNo: 2, PaymentID: "pay-3", PaymentStatus: model.PaymentStatusFailed})
+	var events []model.BookingEvent
+	bs.OnBookingChange(func(event model.BookingEvent, booking model.Booking) { events = append(events, event) })
+	if _, err := bs.CancelBooking(failed.ID); !errors.Is(err, ErrBookingAlreadyCancel) {
+		t.Errorf("Expected ErrBookingAlreadyCancel for a failed payment, got %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no transition for a refused cancel, got %v", events)
+	}
+}
+
+func TestBlockSeat(t *testing.T) {
+	bs := NewBookingStoreBucket()
+	registerTestBooking(t, bs, "user-1", model.TierVIP, 1)
+
+	tests := []struct {
+		name          string
+		seatNo        uint32
+		expectedError error
+	}{
+		{name: "free seat", seatNo: 2},
+		{name: "already blocked", seatNo: 2, expectedError: ErrSeatBlocked},
+		{name: "booked seat", seatNo: 1, expectedError: ErrSeatAlreadyBooked},
+		{name: "invalid seat", seatNo: 101, expectedError: ErrInvalidSeatNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, err := bs.BlockSeat(model.SeatBlock{SeatNo: tt.seatNo, Reason: "camera", BlockedBy: "admin-1"})
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected error '%v', got '%v'", tt.expectedError, err)
+			}
+			if err == nil && block.Tier != model.TierVIP {
+				t.Errorf("Expected tier VIP, got %s", block.Tier)
+			}
+		})
+	}
+
+	// blocked seats are reserved and can't be booked
+	if !contains(bs.GetReservedSeats()["VIP"], 2) {
+		t.Error("Expected blocked seat 2 to be reserved")
+	}
+	_, err := bs.RegisterBooking(model.BookingOrder{UserID: "user-2", Tier: model.TierVIP, SeatNo: 2, PaymentStatus: model.PaymentStatusPending})
+	if !errors.Is(err, ErrSeatBlocked) {
+		t.Errorf("Expected ErrSeatBlocked, got %v", err)
+	}
+
+	// a complimentary ticket takes the blocked seat
+	comp, err := bs.RegisterBooking(model.BookingOrder{UserID: "guest", Tier: model.TierVIP, SeatNo: 2, Complimentary: true, PaymentID: "comp-1", PaymentStatus: model.PaymentStatusConfirmed})
+	if err != nil {
+		t.Fatalf("Failed to comp blocked seat: %v", err)
+	}
+	if !comp.Complimentary || comp.Status != model.BookingStatusConfirmed {
+		t.Errorf("Expected confirmed complimentary booking, got %+v", comp)
+	}
+	if len(bs.GetBlockedSeats()) != 0 {
+		t.Errorf("Expected no blocked seats after comp, got %d", len(bs.GetBlockedSeats()))
+	}
+}
+
+func TestUnblockSeat(t *testing.T) {
+	bs := NewBookingStoreBucket()
+	if _, err := bs.BlockSeat(model.SeatBlock{SeatNo: 40}); err != nil {
+		t.Fatalf("Failed to block seat: %v", err)
+	}
+
+	if err := bs.UnblockSeat(40); err != nil {
+		t.Fatalf("Failed to unblock seat: %v", err)
+	}
+	if err := bs.UnblockSeat(40); !errors.Is(err, ErrSeatNotBlocked) {
+		t.Errorf("Expected ErrSeatNotBlocked, got %v", err)
+	}
+	if contains(bs.GetReservedSeats()["FRONT_ROW"], 40) {
+		t.Error("Expected unblocked seat 40 to be available")
+	}
+}
+
+func TestListUserBookings(t *testing.T) {
+	bs := NewBookingStoreBucket()
+	for seat := uint32(1); seat <= 5; seat++ {
+		registerTestBooking(t, bs, "user-1", model.TierVIP, seat)
+	}
+	registerTestBooking(t, bs, "user-2", model.TierVIP, 6)
+	second := bs.ListBookings(model.BookingFilter{UserID: "user-1"})[1]
+	bs.CancelBooking(second.ID)
+
+	// page through in twos
+	var seats []uint32
+	cursor := ""
+	pages := 0
+	for {
+		bookings, next, err := bs.ListUserBookings(model.BookingFilter{UserID: "user-1"}, cursor, 2)
+		if err != nil {
+			t.Fatalf("Failed to list bookings: %v", err)
+		}
+		pages++
+		for _, booking := range bookings {
+			seats = append(seats, booking.SeatNo)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if pages != 3 || len(seats) != 5 || seats[0] != 1 || seats[4] != 5 {
+		t.Errorf("Expected seats 1-5 over 3 pages, got %v over %d pages", seats, pages)
+	}
+
+	confirmed, next, _ := bs.ListUserBookings(model.BookingFilter{UserID: "user-1", Status: model.BookingStatusConfirmed}, "", 10)
+	if len(confirmed) != 4 || next != "" {
+		t.Errorf("Expected 4 confirmed bookings on one page, got %d (next %q)", len(confirmed), next)
+	}
+
+	if none, _, _ := bs.ListUserBookings(model.BookingFilter{UserID: "user-3"}, "", 10); len(none) != 0 {
+		t.Errorf("Expected no bookings, got %d", len(none))
+	}
+	if _, _, err := bs.ListUserBookings(model.BookingFilter{UserID: "user-1"}, "not-a-cursor", 10); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestAdminAuditKeepsLatestEntries(t *testing.T) {
+	audit := NewAdminAuditBucket()
+	for i := range MaxAdminAuditEntries + 10 {
+		audit.Record(context.Background(), model.AdminAuditEntry{Action: model.AdminActionBlockSeat, Target: strconv.Itoa(i)})
+	}
+
+	entries := audit.List()
+	if len(entries) != MaxAdminAuditEntries {
+		t.Fatalf("Expected %d entries kept, got %d", MaxAdminAuditEntries, len(entries))
+	}
+	if entries[0].Target != "10" || entries[len(entries)-1].Target != strconv.Itoa(MaxAdminAuditEntries+9) {
+		t.Errorf("Expected the oldest entries dropped, got %s to %s", entries[0].Target, entries[len(entries)-1].Target)
+	}
+}
@@ -0,0 +1,230 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	ErrAPIKeyInvalid       = model.NewError(model.CodeUnauthorized, "invalid API key")
+	ErrAPIKeyQuotaExceeded = model.NewError(model.CodeBookingQuotaExceeded, "API key booking quota exceeded")
+)
+
+type APIKEY_BUCKET struct {
+	APIKEY_STORE map[string]*apiKeyEntry // key hash -> entry
+	KEYS_BY_ID   map[string]*apiKeyEntry // key ID -> entry
+
+	// Protects both maps from concurrent access
+	mapMu sync.RWMutex
+}
+
+// apiKeyEntry keeps a key with the number of tickets sold through it.
+// The entry mutex makes the quota check-and-increment atomic per key.
+type apiKeyEntry struct {
+	mu   sync.Mutex
+	key  model.APIKey
+	sold uint32
+}
+
+type APIKeyStore interface {
+	RegisterAPIKey(key model.APIKey) error
+	Authenticate(rawKey string) (model.APIKey, error)
+	GetAPIKey(id string) (model.APIKey, bool)
+
+	// AllocationOwner returns the ID of the key the seat is allocated to, if any.
+	AllocationOwner(seatNo uint32) (string, bool)
+
+	ReserveBooking(id string) error
+	ReleaseBooking(id string)
+	GetBookingsSold(id string) uint32
+}
+
+func NewAPIKeyBucket() APIKeyStore {
+	return &APIKEY_BUCKET{
+		APIKEY_STORE: make(map[string]*apiKeyEntry),
+		KEYS_BY_ID:   make(map[string]*apiKeyEntry),
+	}
+}
+
+// HashAPIKey returns the hex SHA-256 stored for a raw API key.
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (kb *APIKEY_BUCKET) RegisterAPIKey(key model.APIKey) error {
+	key.ID = strings.TrimSpace(key.ID)
+	key.KeyHash = strings.ToLower(strings.TrimSpace(key.KeyHash))
+	if key.ID == "" {
+		return errors.New("API key id is required")
+	}
+	if hash, err := hex.DecodeString(key.KeyHash); err != nil || len(hash) != sha256.Size {
+		return errors.New("keyHash must be a hex SHA-256")
+	}
+	if len(key.Scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+	for _, scope := range key.Scopes {
+		if !scope.IsValidAPIScope() {
+			return fmt.Errorf("invalid scope %q", scope)
+		}
+	}
+	for _, seats := range key.Allocation {
+		if seats.Total() == 0 || seats.Max > 100 {
+			return fmt.Errorf("invalid allocation %d-%d", seats.Min, seats.Max)
+		}
+	}
+	if key.Channel == "" {
+		key.Channel = key.ID
+	}
+
+	kb.mapMu.Lock()
+	defer kb.mapMu.Unlock()
+
+	if _, exists := kb.KEYS_BY_ID[key.ID]; exists {
+		return errors.New("duplicate API key id")
+	}
+	if _, exists := kb.APIKEY_STORE[key.KeyHash]; exists {
+		return errors.New("duplicate API key")
+	}
+	// a seat can only be allocated to one partner
+	for _, other := range kb.KEYS_BY_ID {
+		for _, seats := range key.Allocation {
+			for _, otherSeats := range other.key.Allocation {
+				if seats.Min <= otherSeats.Max && otherSeats.Min <= seats.Max {
+					return fmt.Errorf("allocation %d-%d overlaps API key %q", seats.Min, seats.Max, other.key.ID)
+				}
+			}
+		}
+	}
+
+	entry := &apiKeyEntry{key: key}
+	kb.APIKEY_STORE[key.KeyHash] = entry
+	kb.KEYS_BY_ID[key.ID] = entry
+	return nil
+}
+
+// Authenticate returns the key matching a raw API key.
+func (kb *APIKEY_BUCKET) Authenticate(rawKey string) (model.APIKey, error) {
+	if rawKey == "" {
+		return model.APIKey{}, ErrAPIKeyInvalid
+	}
+
+	kb.mapMu.RLock()
+	defer kb.mapMu.RUnlock()
+
+	entry, exists := kb.APIKEY_STORE[HashAPIKey(rawKey)]
+	if !exists {
+		return model.APIKey{}, ErrAPIKeyInvalid
+	}
+	return entry.key, nil
+}
+
+func (kb *APIKEY_BUCKET) GetAPIKey(id string) (model.APIKey, bool) {
+	kb.mapMu.RLock()
+	defer kb.mapMu.RUnlock()
+
+	entry, exists := kb.KEYS_BY_ID[id]
+	if !exists {
+		return model.APIKey{}, false
+	}
+	return entry.key, true
+}
+
+func (kb *APIKEY_BUCKET) AllocationOwner(seatNo uint32) (string, bool) {
+	kb.mapMu.RLock()
+	defer kb.mapMu.RUnlock()
+
+	for id, entry := range kb.KEYS_BY_ID {
+		if entry.key.IsAllocated(seatNo) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// ReserveBooking counts a ticket against the key's quota, failing once it is used up.
+// Call ReleaseBooking if the booking then doesn't go through.
+func (kb *APIKEY_BUCKET) ReserveBooking(id string) error {
+	entry, err := kb.getEntry(id)
+	if err != nil {
+		return err
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	// ---- CRITICAL SECTION (key-scoped) ----
+
+	if entry.key.MaxBookings > 0 && entry.sold >= entry.key.MaxBookings {
+		return ErrAPIKeyQuotaExceeded
+	}
+	entry.sold++
+	return nil
+}
+
+// ReleaseBooking gives back a ticket reserved with ReserveBooking.
+func (kb *APIKEY_BUCKET) ReleaseBooking(id string) {
+	entry, err := kb.getEntry(id)
+	if err != nil {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.sold > 0 {
+		entry.sold--
+	}
+}
+
+func (kb *APIKEY_BUCKET) GetBookingsSold(id string) uint32 {
+	entry, err := kb.getEntry(id)
+	if err != nil {
+		return 0
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return entry.sold
+}
+
+func (kb *APIKEY_BUCKET) getEntry(id string) (*apiKeyEntry, error) {
+	kb.mapMu.RLock()
+	defer kb.mapMu.RUnlock()
+
+	entry, exists := kb.KEYS_BY_ID[id]
+	if !exists {
+		return nil, ErrAPIKeyInvalid
+	}
+	return entry, nil
+}
+
+// LoadAPIKeys registers the partner API keys defined in a JSON file.
+func LoadAPIKeys(ks APIKeyStore, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var keys []model.APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("parse API keys: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := ks.RegisterAPIKey(key); err != nil {
+			return fmt.Errorf("API key %q: %w", key.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestRegisterAPIKey(t *testing.T) {
+	valid := func() model.APIKey {
+		return model.APIKey{
+			ID:         "boxoffice-a",
+			KeyHash:    HashAPIKey("secret-a"),
+			Scopes:     []model.APIScope{model.ScopeAvailabilityRead, model.ScopeBookingWrite},
+			Allocation: []model.SeatRange{{Min: 61, Max: 70}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		key           func() model.APIKey
+		expectedError string
+	}{
+		{name: "valid key", key: valid},
+		{name: "missing id", key: func() model.APIKey { k := valid(); k.ID = ""; return k }, expectedError: "API key id is required"},
+		{name: "raw key instead of hash", key: func() model.APIKey { k := valid(); k.KeyHash = "secret-a"; return k }, expectedError: "keyHash must be a hex SHA-256"},
+		{name: "no scopes", key: func() model.APIKey { k := valid(); k.Scopes = nil; return k }, expectedError: "at least one scope is required"},
+		{name: "unknown scope", key: func() model.APIKey { k := valid(); k.Scopes = []model.APIScope{"admin"}; return k }, expectedError: `invalid scope "admin"`},
+		{name: "invalid allocation", key: func() model.APIKey { k := valid(); k.Allocation = []model.SeatRange{{Min: 90, Max: 120}}; return k }, expectedError: "invalid allocation 90-120"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks := NewAPIKeyBucket()
+			err := ks.RegisterAPIKey(tt.key())
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				key, _ := ks.GetAPIKey("boxoffice-a")
+				if key.Channel != "boxoffice-a" {
+					t.Errorf("Expected channel to default to the key id, got '%s'", key.Channel)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("Expected error '%s', got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+
+	t.Run("overlapping allocation", func(t *testing.T) {
+		ks := NewAPIKeyBucket()
+		if err := ks.RegisterAPIKey(valid()); err != nil {
+			t.Fatalf("Failed to register key: %v", err)
+		}
+		other := model.APIKey{ID: "boxoffice-b", KeyHash: HashAPIKey("secret-b"), Scopes: []model.APIScope{model.ScopeBookingWrite}, Allocation: []model.SeatRange{{Min: 70, Max: 75}}}
+		if err := ks.RegisterAPIKey(other); err == nil {
+			t.Error("Expected overlapping allocation to be rejected")
+		}
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ks := NewAPIKeyBucket()
+	ks.RegisterAPIKey(model.APIKey{ID: "boxoffice-a", KeyHash: HashAPIKey("secret-a"), Channel: "BOX_OFFICE_A", Scopes: []model.APIScope{model.ScopeBookingWrite}})
+
+	key, err := ks.Authenticate("secret-a")
+	if err != nil || key.ID != "boxoffice-a" || key.Channel != "BOX_OFFICE_A" {
+		t.Errorf("Expected boxoffice-a, got %+v, %v", key, err)
+	}
+	if _, err := ks.Authenticate("secret-b"); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Expected ErrAPIKeyInvalid, got %v", err)
+	}
+	if _, err := ks.Authenticate(""); !errors.Is(err, ErrAPIKeyInvalid) {
+		t.Errorf("Expected ErrAPIKeyInvalid, got %v", err)
+	}
+}
+
+func TestReserveBooking_Quota(t *testing.T) {
+	ks := NewAPIKeyBucket()
+	ks.RegisterAPIKey(model.APIKey{ID: "boxoffice-a", KeyHash: HashAPIKey("secret-a"), Scopes: []model.APIScope{model.ScopeBookingWrite}, MaxBookings: 10})
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int32
+	for range 25 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ks.ReserveBooking("boxoffice-a") == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved.Load() != 10 {
+		t.Errorf("Expected 10 reservations, got %d", reserved.Load())
+	}
+	if err := ks.ReserveBooking("boxoffice-a"); !errors.Is(err, ErrAPIKeyQuotaExceeded) {
+		t.Errorf("Expected ErrAPIKeyQuotaExceeded, got %v", err)
+	}
+
+	ks.ReleaseBooking("boxoffice-a")
+	if err := ks.ReserveBooking("boxoffice-a"); err != nil {
+		t.Errorf("Expected reservation after release, got %v", err)
+	}
+	if sold := ks.GetBookingsSold("boxoffice-a"); sold != 10 {
+		t.Errorf("Expected 10 bookings sold, got %d", sold)
+	}
+}
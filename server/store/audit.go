@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// MaxAdminAuditEntries is how many admin actions the in-memory log keeps.
+const MaxAdminAuditEntries = 1000
+
+/*
+* ADMIN_AUDIT_BUCKET is an in-memory log of the latest admin actions.
+  - it keeps the last MaxAdminAuditEntries, oldest dropped first, and is lost
+    on restart: it serves GET /admin/audit, for a look at recent actions
+  - the durable record is the "admin action" log line Record writes for
+    every entry, which log shipping keeps
+*/
+type ADMIN_AUDIT_BUCKET struct {
+	AUDIT_LOG []model.AdminAuditEntry
+
+	mu sync.RWMutex
+}
+
+type AdminAudit interface {
+	Record(ctx context.Context, entry model.AdminAuditEntry) model.AdminAuditEntry
+	List() []model.AdminAuditEntry
+}
+
+func NewAdminAuditBucket() AdminAudit {
+	return &ADMIN_AUDIT_BUCKET{
+		AUDIT_LOG: make([]model.AdminAuditEntry, 0),
+	}
+}
+
+// Record appends an entry, stamping its ID and time, and mirrors it to the log
+// with the request's context, so the line carries the request ID.
+func (a *ADMIN_AUDIT_BUCKET) Record(ctx context.Context, entry model.AdminAuditEntry) model.AdminAuditEntry {
+	entry.ID = uuid.New()
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+
+	a.mu.Lock()
+	if len(a.AUDIT_LOG) == MaxAdminAuditEntries {
+		a.AUDIT_LOG = a.AUDIT_LOG[1:]
+	}
+	a.AUDIT_LOG = append(a.AUDIT_LOG, entry)
+	a.mu.Unlock()
+
+	slog.InfoContext(ctx, "admin action",
+		"actor", entry.Actor,
+		"action", entry.Action,
+		"target", entry.Target,
+		"success", entry.Success,
+		"err", entry.Error)
+
+	return entry
+}
+
+// List returns a copy of the entries kept, oldest first.
+func (a *ADMIN_AUDIT_BUCKET) List() []model.AdminAuditEntry {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	entries := make([]model.AdminAuditEntry, len(a.AUDIT_LOG))
+	copy(entries, a.AUDIT_LOG)
+	return entries
+}
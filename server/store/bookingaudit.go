@@ -0,0 +1,517 @@
+package store
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// GenesisHash is the PrevHash of a booking audit log's first entry.
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+const (
+	// longest line read from an audit log file; entries are a few hundred bytes
+	maxAuditLineSize = 64 << 10
+
+	// lines queued for the file writer; Append drops entries once it falls this far behind
+	auditQueueSize = 1024
+
+	// wait before writing a line again after a failed write
+	auditRetryInterval = time.Second
+)
+
+var ErrBookingAuditClosed = errors.New("booking audit log closed")
+
+var (
+	auditErrors = metrics.Default.CounterVec("booking_audit_write_errors_total",
+		"Booking audit log entries not written: a failed write, tried again until it succeeds, or an entry dropped while the writer was behind.",
+		"reason")
+	auditWriteFailed  = auditErrors.With("write")
+	auditEntryDropped = auditErrors.With("dropped")
+)
+
+/*
+* BOOKING_AUDIT_BUCKET is an append-only, hash-chained log of booking transitions.
+  - entries are only ever appended: nothing updates or removes one
+  - each entry carries the hash of the one before, so VerifyBookingAudit finds
+    an entry changed, removed or reordered, wherever it is
+  - the log is a JSON line per entry, in memory or in a file
+    (OpenBookingAuditFile). Only BY_BOOKING, where each booking's lines start,
+    is kept besides; History and Verify read the log
+  - with a file, Append queues the line for a writer goroutine, so bookings
+    don't wait for the disk. A failed write is logged, counted and tried
+    again until it succeeds, and Err reports it meanwhile
+  - Append never blocks on the writer: once auditQueueSize lines wait, an
+    entry is dropped, logged and counted, and Err reports it from then on.
+    Its number and hash are still used, so Verify finds the gap it leaves
+  - a crash loses the queued lines and may cut the last one short, which the
+    next OpenBookingAuditFile drops
+*/
+type BOOKING_AUDIT_BUCKET struct {
+	BY_BOOKING map[uuid.UUID][]int64 // BookingID -> offsets of its lines, oldest first
+
+	mu      sync.Mutex
+	entries uint64 // in the log, written or queued
+	head    string // hash of the last entry
+	size    int64  // bytes of the log, written or queued
+	closed  bool
+
+	log    io.ReaderAt
+	memory *memoryLog // the log, without a file
+
+	file     *os.File
+	queue    chan []byte   // lines for the writer, in order
+	stop     chan struct{} // closed by Close: the writer gives up on a failing write
+	stopOnce sync.Once
+	done     chan struct{} // closed once the writer is gone
+
+	// the writer's progress
+	writeMu sync.Mutex
+	wrote   *sync.Cond
+	written int64  // bytes of the log in the file
+	lastErr error  // of the last write, cleared by the next one that succeeds
+	dropped uint64 // entries left out while the writer was behind
+}
+
+type BookingAudit interface {
+	Append(event model.BookingEvent, booking model.Booking) model.BookingAuditEntry
+	History(bookingID uuid.UUID) ([]model.BookingAuditEntry, error)
+	Entries() ([]model.BookingAuditEntry, error)
+	Verify() (model.AuditVerification, error)
+	Err() error
+	Close() error
+}
+
+func NewBookingAuditBucket() BookingAudit {
+	audit := newBookingAudit()
+	audit.memory = &memoryLog{}
+	audit.log = audit.memory
+	return audit
+}
+
+func newBookingAudit() *BOOKING_AUDIT_BUCKET {
+	audit := &BOOKING_AUDIT_BUCKET{
+		BY_BOOKING: make(map[uuid.UUID][]int64),
+		head:       GenesisHash,
+	}
+	audit.wrote = sync.NewCond(&audit.writeMu)
+	return audit
+}
+
+// OpenBookingAuditFile loads the audit log at path, creating it if missing,
+// and appends new entries to it. A log whose chain is broken still opens, to
+// keep recording; Verify reports where it broke. A last line cut short by a
+// crash is dropped.
+func OpenBookingAuditFile(path string) (BookingAudit, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newBookingAudit()
+	size, err := scanBookingAudit(file, func(entry model.BookingAuditEntry, offset int64) bool {
+		audit.index(entry, offset)
+		return true
+	})
+	if err == nil {
+		err = truncateTornLine(file, path, size)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	audit.size, audit.written = size, size
+	audit.log = file
+	audit.file = file
+	audit.queue = make(chan []byte, auditQueueSize)
+	audit.stop = make(chan struct{})
+	audit.done = make(chan struct{})
+	go audit.writeLines()
+	return audit, nil
+}
+
+// truncateTornLine cuts the file back to its whole lines.
+func truncateTornLine(file *os.File, path string, size int64) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == size {
+		return err
+	}
+	slog.Warn("booking audit log ends in an entry cut short, dropping it", "path", path, "bytes", info.Size()-size)
+	return file.Truncate(size)
+}
+
+// ReadBookingAuditFile reads the entries of an audit log file, oldest first.
+func ReadBookingAuditFile(path string) ([]model.BookingAuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadBookingAudit(file)
+}
+
+// ReadBookingAudit reads audit log entries, a JSON object per line. A last
+// line without its newline was cut short by a crash and is left out.
+func ReadBookingAudit(r io.Reader) ([]model.BookingAuditEntry, error) {
+	entries := make([]model.BookingAuditEntry, 0)
+	_, err := scanBookingAudit(r, func(entry model.BookingAuditEntry, _ int64) bool {
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// scanBookingAudit calls visit with every entry of a log and the offset of
+// its line, oldest first, until visit returns false. It returns the size of
+// the whole lines read, leaving out a last line without its newline.
+func scanBookingAudit(r io.Reader, visit func(entry model.BookingAuditEntry, offset int64) bool) (int64, error) {
+	reader := bufio.NewReaderSize(r, maxAuditLineSize)
+	var offset int64
+	for line := 1; ; line++ {
+		data, err := reader.ReadSlice('\n')
+		switch {
+		case errors.Is(err, io.EOF):
+			return offset, nil
+		case errors.Is(err, bufio.ErrBufferFull):
+			return offset, fmt.Errorf("audit log line %d: longer than %d bytes", line, maxAuditLineSize)
+		case err != nil:
+			return offset, err
+		}
+
+		if len(data) > 1 {
+			var entry model.BookingAuditEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return offset, fmt.Errorf("audit log line %d: %w", line, err)
+			}
+			if !visit(entry, offset) {
+				return offset + int64(len(data)), nil
+			}
+		}
+		offset += int64(len(data))
+	}
+}
+
+// Append records a transition of booking, chaining it to the last entry.
+func (a *BOOKING_AUDIT_BUCKET) Append(event model.BookingEvent, booking model.Booking) model.BookingAuditEntry {
+	entry := model.BookingAuditEntry{
+		At:               time.Now().UTC(),
+		Event:            event,
+		BookingID:        booking.ID,
+		UserID:           booking.UserID,
+		SeatNo:           booking.SeatNo,
+		Tier:             booking.Tier,
+		Status:           booking.Status,
+		PaymentID:        booking.PaymentID,
+		PaymentStatus:    booking.PaymentStatus,
+		TotalAmtInUSCent: booking.TotalAmtInUSCent,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry.Seq = a.entries + 1
+	entry.PrevHash = a.head
+	entry.Hash = HashBookingAuditEntry(entry)
+	if a.closed {
+		slog.Error("booking audit log closed, entry dropped", "seq", entry.Seq, "event", entry.Event, "booking_id", entry.BookingID)
+		return entry
+	}
+
+	line, _ := json.Marshal(entry) // no field fails to encode
+	line = append(line, '\n')
+	if a.queue == nil {
+		a.index(entry, a.size)
+		a.size += int64(len(line))
+		a.memory.append(line)
+		a.wroteLine(len(line))
+		return entry
+	}
+
+	// queued in order under mu, so the lines reach the file in the chain's order;
+	// a stuck writer must not hold up bookings, so a full queue drops the entry
+	select {
+	case a.queue <- line:
+		a.index(entry, a.size)
+		a.size += int64(len(line))
+	default:
+		a.entries++
+		a.head = entry.Hash
+		auditEntryDropped.Inc()
+		slog.Error("booking audit log writer behind, entry dropped", "seq", entry.Seq, "event", entry.Event, "booking_id", entry.BookingID)
+		a.droppedEntry()
+	}
+	return entry
+}
+
+// index adds an entry at offset to the chain. Callers hold mu, or own the bucket.
+func (a *BOOKING_AUDIT_BUCKET) index(entry model.BookingAuditEntry, offset int64) {
+	a.BY_BOOKING[entry.BookingID] = append(a.BY_BOOKING[entry.BookingID], offset)
+	a.entries++
+	a.head = entry.Hash
+}
+
+// writeLines writes the queued lines to the file until Close.
+func (a *BOOKING_AUDIT_BUCKET) writeLines() {
+	defer close(a.done)
+
+	for line := range a.queue {
+		size := len(line)
+		for len(line) > 0 {
+			n, err := a.file.Write(line)
+			line = line[n:]
+			if err == nil {
+				continue
+			}
+
+			auditWriteFailed.Inc()
+			slog.Error("failed to write the booking audit log, retrying", "path", a.file.Name(), "err", err)
+			a.failedWrite(err)
+			select {
+			case <-time.After(auditRetryInterval):
+			case <-a.stop:
+				return
+			}
+		}
+		a.wroteLine(size)
+	}
+}
+
+// wroteLine records a line in the log.
+func (a *BOOKING_AUDIT_BUCKET) wroteLine(size int) {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	a.written += int64(size)
+	a.lastErr = nil
+	a.wrote.Broadcast()
+}
+
+func (a *BOOKING_AUDIT_BUCKET) failedWrite(err error) {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	a.lastErr = err
+	a.wrote.Broadcast()
+}
+
+func (a *BOOKING_AUDIT_BUCKET) droppedEntry() {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	a.dropped++
+}
+
+// readable returns the log up to its last entry, once it is written.
+func (a *BOOKING_AUDIT_BUCKET) readable() (*io.SectionReader, error) {
+	a.mu.Lock()
+	size := a.size
+	a.mu.Unlock()
+
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	for a.written < size {
+		if a.lastErr != nil {
+			return nil, fmt.Errorf("booking audit log %d bytes behind: %w", size-a.written, a.lastErr)
+		}
+		a.wrote.Wait()
+	}
+	return io.NewSectionReader(a.log, 0, size), nil
+}
+
+// History returns a booking's entries, oldest first.
+func (a *BOOKING_AUDIT_BUCKET) History(bookingID uuid.UUID) ([]model.BookingAuditEntry, error) {
+	a.mu.Lock()
+	offsets := append([]int64(nil), a.BY_BOOKING[bookingID]...)
+	a.mu.Unlock()
+
+	log, err := a.readable()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]model.BookingAuditEntry, 0, len(offsets))
+	for _, offset := range offsets {
+		_, err := scanBookingAudit(io.NewSectionReader(log, offset, log.Size()-offset), func(entry model.BookingAuditEntry, _ int64) bool {
+			entries = append(entries, entry)
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Entries returns every entry, oldest first.
+func (a *BOOKING_AUDIT_BUCKET) Entries() ([]model.BookingAuditEntry, error) {
+	log, err := a.readable()
+	if err != nil {
+		return nil, err
+	}
+	return ReadBookingAudit(log)
+}
+
+// Verify checks the whole chain, reading it from the log.
+func (a *BOOKING_AUDIT_BUCKET) Verify() (model.AuditVerification, error) {
+	log, err := a.readable()
+	if err != nil {
+		return model.AuditVerification{}, err
+	}
+	verifier := newAuditVerifier()
+	_, err = scanBookingAudit(log, func(entry model.BookingAuditEntry, _ int64) bool {
+		return verifier.check(entry)
+	})
+	return verifier.verification, err
+}
+
+// Err returns the error of the last write to the file while it is being
+// retried, and from the first dropped entry on, that entries were dropped;
+// nil in memory.
+func (a *BOOKING_AUDIT_BUCKET) Err() error {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	if a.dropped > 0 {
+		return errors.Join(a.lastErr, fmt.Errorf("%d entries dropped while the writer was behind", a.dropped))
+	}
+	return a.lastErr
+}
+
+// Close writes the queued lines, then syncs and closes the file. A write
+// failing by then isn't retried, and the lines after it are lost; entries
+// appended afterwards aren't recorded.
+func (a *BOOKING_AUDIT_BUCKET) Close() error {
+	if a.file == nil {
+		a.mu.Lock()
+		a.closed = true
+		a.mu.Unlock()
+		return nil
+	}
+
+	// the writer gives up on a failing write first, so nothing holds mu waiting on it
+	a.stopOnce.Do(func() { close(a.stop) })
+
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return nil
+	}
+	a.closed = true
+	close(a.queue)
+	a.mu.Unlock()
+
+	<-a.done
+
+	a.writeMu.Lock()
+	lost := a.size - a.written
+	writeErr := a.lastErr
+	if lost == 0 {
+		writeErr = nil
+	}
+	a.lastErr = errors.Join(writeErr, ErrBookingAuditClosed)
+	dropped := a.dropped
+	a.wrote.Broadcast()
+	a.writeMu.Unlock()
+
+	if writeErr != nil {
+		writeErr = fmt.Errorf("%d bytes of entries not written: %w", lost, writeErr)
+	}
+	var droppedErr error
+	if dropped > 0 {
+		droppedErr = fmt.Errorf("%d entries dropped while the writer was behind", dropped)
+	}
+	return errors.Join(writeErr, droppedErr, a.file.Sync(), a.file.Close())
+}
+
+// HashBookingAuditEntry returns the hash of an entry: the SHA-256 of its JSON
+// without Hash, in hex. PrevHash is part of it, which chains the entries.
+func HashBookingAuditEntry(entry model.BookingAuditEntry) string {
+	entry.Hash = ""
+	data, _ := json.Marshal(entry) // no field fails to encode
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyBookingAudit checks that entries are numbered from 1 without gaps, and
+// that each one's hash matches its content and chains to the one before.
+func VerifyBookingAudit(entries []model.BookingAuditEntry) model.AuditVerification {
+	verifier := newAuditVerifier()
+	for _, entry := range entries {
+		if !verifier.check(entry) {
+			break
+		}
+	}
+	return verifier.verification
+}
+
+// auditVerifier checks a chain an entry at a time, so a log can be verified without loading it.
+type auditVerifier struct {
+	verification model.AuditVerification
+	prev         string
+}
+
+func newAuditVerifier() *auditVerifier {
+	return &auditVerifier{verification: model.AuditVerification{Valid: true}, prev: GenesisHash}
+}
+
+// check adds the next entry to the verification and reports whether the chain still holds.
+func (v *auditVerifier) check(entry model.BookingAuditEntry) bool {
+	position := v.verification.Entries + 1
+
+	var problem string
+	switch {
+	case entry.Seq != position:
+		problem = fmt.Sprintf("entry %d is numbered %d: entries are missing or out of order", position, entry.Seq)
+	case entry.PrevHash != v.prev:
+		problem = "previous hash doesn't match the entry before: an entry before it was changed or removed"
+	case HashBookingAuditEntry(entry) != entry.Hash:
+		problem = "hash doesn't match the entry: it was changed"
+	}
+	if problem != "" {
+		v.verification.Valid = false
+		v.verification.BrokenAt = position
+		v.verification.Problem = problem
+		return false
+	}
+	v.verification.Entries++
+	v.verification.Head = entry.Hash
+	v.prev = entry.Hash
+	return true
+}
+
+// memoryLog is the log of an audit kept in memory.
+type memoryLog struct {
+	mu   sync.RWMutex
+	data []byte
+}
+
+func (m *memoryLog) append(line []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = append(m.data, line...)
+}
+
+func (m *memoryLog) ReadAt(p []byte, offset int64) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if offset >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[offset:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,331 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// auditedStore returns a booking store whose transitions are recorded in audit.
+func auditedStore(audit BookingAudit) BookingStore {
+	bs := NewBookingStoreBucket()
+	bs.OnBookingChange(func(event model.BookingEvent, booking model.Booking) {
+		audit.Append(event, booking)
+	})
+	return bs
+}
+
+// history returns a booking's audit entries, failing the test if the log can't be read.
+func history(t *testing.T, audit BookingAudit, bookingID uuid.UUID) []model.BookingAuditEntry {
+	t.Helper()
+	entries, err := audit.History(bookingID)
+	if err != nil {
+		t.Fatalf("Expected to read the audit log, got %v", err)
+	}
+	return entries
+}
+
+func TestBookingAudit_Transitions(t *testing.T) {
+	audit := NewBookingAuditBucket()
+	bs := auditedStore(audit)
+
+	confirmed := registerTestBooking(t, bs, "user-1", model.TierVIP, 1)
+	pending, _ := bs.RegisterBooking(model.BookingOrder{UserID: "user-2", Tier: model.TierVIP, SeatNo: 2, Status: model.BookingStatusPending})
+	failed, _ := bs.RegisterBooking(model.BookingOrder{UserID: "user-3", Tier: model.TierVIP, SeatNo: 3, PaymentID: "pay-3", PaymentStatus: model.PaymentStatusFailed})
+	if _, err := bs.CancelBooking(confirmed.ID); err != nil {
+		t.Fatalf("Expected cancel, got %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		history        []model.BookingAuditEntry
+		expectedEvents []model.BookingEvent
+		expectedStatus model.BookingStatus // after the last event
+	}{
+		{
+			name:           "confirmed, then canceled",
+			history:        history(t, audit, confirmed.ID),
+			expectedEvents: []model.BookingEvent{model.BookingEventCreated, model.BookingEventConfirmed, model.BookingEventCanceled},
+			expectedStatus: model.BookingStatusCanceled,
+		},
+		{
+			name:           "pending payment",
+			history:        history(t, audit, pending.ID),
+			expectedEvents: []model.BookingEvent{model.BookingEventCreated},
+			expectedStatus: model.BookingStatusPending,
+		},
+		{
+			name:           "failed payment",
+			history:        history(t, audit, failed.ID),
+			expectedEvents: []model.BookingEvent{model.BookingEventCreated, model.BookingEventCanceled},
+			expectedStatus: model.BookingStatusCanceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.history) != len(tt.expectedEvents) {
+				t.Fatalf("Expected %d entries, got %+v", len(tt.expectedEvents), tt.history)
+			}
+			for i, entry := range tt.history {
+				if entry.Event != tt.expectedEvents[i] {
+					t.Errorf("Expected entry %d to be %s, got %s", i, tt.expectedEvents[i], entry.Event)
+				}
+			}
+			if last := tt.history[len(tt.history)-1]; last.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s after the last entry, got %s", tt.expectedStatus, last.Status)
+			}
+			if first := tt.history[0]; first.Status != model.BookingStatusPending {
+				t.Errorf("Expected a booking to be created pending, got %s", first.Status)
+			}
+		})
+	}
+
+	if verification, err := audit.Verify(); err != nil || !verification.Valid || verification.Entries != 6 {
+		t.Errorf("Expected an intact chain of 6 entries, got %+v", verification)
+	}
+}
+
+func TestVerifyBookingAudit(t *testing.T) {
+	audit := NewBookingAuditBucket()
+	bs := auditedStore(audit)
+	for seatNo := uint32(1); seatNo <= 4; seatNo++ {
+		registerTestBooking(t, bs, "user-"+strconv.Itoa(int(seatNo)), model.TierVIP, seatNo)
+	}
+	entries, err := audit.Entries() // 8: created and confirmed per booking
+	if err != nil {
+		t.Fatalf("Expected to read the audit log, got %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		tamper           func(entries []model.BookingAuditEntry) []model.BookingAuditEntry
+		expectedBrokenAt uint64
+		expectedProblem  string
+	}{
+		{
+			name:   "intact",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry { return entries },
+		},
+		{
+			name: "entry changed",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry {
+				entries[3].Status = model.BookingStatusCanceled
+				return entries
+			},
+			expectedBrokenAt: 4,
+			expectedProblem:  "it was changed",
+		},
+		{
+			name: "entry changed, its hash recomputed",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry {
+				entries[3].SeatNo = 30
+				entries[3].Hash = HashBookingAuditEntry(entries[3])
+				return entries
+			},
+			expectedBrokenAt: 5,
+			expectedProblem:  "previous hash",
+		},
+		{
+			name: "entry removed",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry {
+				return append(entries[:2], entries[3:]...)
+			},
+			expectedBrokenAt: 3,
+			expectedProblem:  "missing or out of order",
+		},
+		{
+			name: "last entry removed",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry {
+				return entries[:len(entries)-1]
+			},
+		},
+		{
+			name: "entries swapped",
+			tamper: func(entries []model.BookingAuditEntry) []model.BookingAuditEntry {
+				entries[4], entries[5] = entries[5], entries[4]
+				return entries
+			},
+			expectedBrokenAt: 5,
+			expectedProblem:  "missing or out of order",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := tt.tamper(append([]model.BookingAuditEntry(nil), entries...))
+			verification := VerifyBookingAudit(tampered)
+
+			if verification.Valid != (tt.expectedBrokenAt == 0) {
+				t.Fatalf("Expected valid %v, got %+v", tt.expectedBrokenAt == 0, verification)
+			}
+			if verification.BrokenAt != tt.expectedBrokenAt {
+				t.Errorf("Expected the chain broken at %d, got %d", tt.expectedBrokenAt, verification.BrokenAt)
+			}
+			if !strings.Contains(verification.Problem, tt.expectedProblem) {
+				t.Errorf("Expected problem %q, got %q", tt.expectedProblem, verification.Problem)
+			}
+		})
+	}
+}
+
+func TestBookingAuditFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.audit.jsonl")
+
+	audit, err := OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected a new audit log, got %v", err)
+	}
+	booking := registerTestBooking(t, auditedStore(audit), "user-1", model.TierVIP, 1)
+	if err := audit.Close(); err != nil {
+		t.Fatalf("Expected close, got %v", err)
+	}
+
+	// reopened, the log goes on from its last entry
+	audit, err = OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected to reopen the audit log, got %v", err)
+	}
+	bs := auditedStore(audit)
+	bs.Restore(Snapshot{Bookings: []model.Booking{booking}})
+	if _, err := bs.CancelBooking(booking.ID); err != nil {
+		t.Fatalf("Expected cancel, got %v", err)
+	}
+	if history := history(t, audit, booking.ID); len(history) != 3 {
+		t.Errorf("Expected the reopened log to index loaded entries, got %d", len(history))
+	}
+	audit.Close()
+
+	entries, err := ReadBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected to read the file, got %v", err)
+	}
+	if len(entries) != 3 || entries[2].Seq != 3 || entries[2].Event != model.BookingEventCanceled {
+		t.Fatalf("Expected created, confirmed and canceled, got %+v", entries)
+	}
+	if verification := VerifyBookingAudit(entries); !verification.Valid {
+		t.Errorf("Expected the chain to go on across restarts, got %+v", verification)
+	}
+}
+
+func TestBookingAuditFile_Unreadable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.audit.jsonl")
+	os.WriteFile(path, []byte(`{"seq":1,"event":"CREATED"`+"\n"), 0o600)
+
+	if _, err := OpenBookingAuditFile(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected the bad line reported, got %v", err)
+	}
+}
+
+func TestBookingAuditFile_TornLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.audit.jsonl")
+	audit, err := OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected a new audit log, got %v", err)
+	}
+	booking := registerTestBooking(t, auditedStore(audit), "user-1", model.TierVIP, 1)
+	audit.Close()
+
+	// a crash in the middle of writing the next entry
+	whole, _ := os.ReadFile(path)
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	file.WriteString(`{"seq":3,"event":"CANCE`)
+	file.Close()
+
+	if entries, err := ReadBookingAuditFile(path); err != nil || len(entries) != 2 {
+		t.Fatalf("Expected the 2 whole entries read, got %d, %v", len(entries), err)
+	}
+
+	audit, err = OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected the audit log to open past the torn line, got %v", err)
+	}
+	defer audit.Close()
+	if data, _ := os.ReadFile(path); string(data) != string(whole) {
+		t.Errorf("Expected the torn line cut from the file, got %q", data[len(whole):])
+	}
+
+	bs := auditedStore(audit)
+	bs.Restore(Snapshot{Bookings: []model.Booking{booking}})
+	if _, err := bs.CancelBooking(booking.ID); err != nil {
+		t.Fatalf("Expected cancel, got %v", err)
+	}
+	verification, err := audit.Verify()
+	if err != nil || !verification.Valid || verification.Entries != 3 {
+		t.Errorf("Expected an intact chain of 3 entries, got %+v, %v", verification, err)
+	}
+}
+
+func TestBookingAuditFile_WriteFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.audit.jsonl")
+	opened, err := OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected a new audit log, got %v", err)
+	}
+	audit := opened.(*BOOKING_AUDIT_BUCKET)
+	audit.file.Close() // every write fails from here
+
+	registerTestBooking(t, auditedStore(audit), "user-1", model.TierVIP, 1)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for audit.Err() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if audit.Err() == nil {
+		t.Fatal("Expected the failing write reported by Err")
+	}
+	if _, err := audit.Verify(); err == nil {
+		t.Error("Expected Verify to fail while entries aren't written")
+	}
+	if err := audit.Close(); err == nil || !strings.Contains(err.Error(), "not written") {
+		t.Errorf("Expected Close to report the lost entries, got %v", err)
+	}
+}
+
+func TestBookingAuditFile_WriterStuck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.audit.jsonl")
+	opened, err := OpenBookingAuditFile(path)
+	if err != nil {
+		t.Fatalf("Expected a new audit log, got %v", err)
+	}
+	audit := opened.(*BOOKING_AUDIT_BUCKET)
+	audit.file.Close() // every write fails from here, and the queue fills up
+
+	// bookings go on past a full queue instead of waiting on the writer
+	appended := make(chan struct{})
+	go func() {
+		defer close(appended)
+		for range auditQueueSize + 10 {
+			audit.Append(model.BookingEventCreated, model.Booking{ID: uuid.New()})
+		}
+	}()
+	select {
+	case <-appended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Append not to block on a stuck writer")
+	}
+
+	if err := audit.Err(); err == nil || !strings.Contains(err.Error(), "entries dropped") {
+		t.Errorf("Expected Err to report the dropped entries, got %v", err)
+	}
+	if dropped := auditEntryDropped.Value(); dropped == 0 {
+		t.Error("Expected the dropped entries counted")
+	}
+
+	closed := make(chan error)
+	go func() { closed <- audit.Close() }()
+	select {
+	case err := <-closed:
+		if err == nil || !strings.Contains(err.Error(), "entries dropped") {
+			t.Errorf("Expected Close to report the dropped entries, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Close not to wait on a failing writer")
+	}
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+const bookingIndexShards = 32
+
+// bookingIndex maps booking IDs to bookings, split in shards by the ID's last
+// byte so writers of different bookings rarely share a lock. Bookings are
+// stored by pointer and never modified, only replaced.
+type bookingIndex struct {
+	shards [bookingIndexShards]bookingIndexShard
+}
+
+type bookingIndexShard struct {
+	mu       sync.RWMutex
+	bookings map[uuid.UUID]*model.Booking
+}
+
+func (i *bookingIndex) shard(id uuid.UUID) *bookingIndexShard {
+	return &i.shards[id[len(id)-1]%bookingIndexShards]
+}
+
+func (i *bookingIndex) load(id uuid.UUID) (*model.Booking, bool) {
+	shard := i.shard(id)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	booking, exists := shard.bookings[id]
+	return booking, exists
+}
+
+func (i *bookingIndex) store(booking *model.Booking) {
+	shard := i.shard(booking.ID)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if shard.bookings == nil {
+		shard.bookings = make(map[uuid.UUID]*model.Booking)
+	}
+	shard.bookings[booking.ID] = booking
+}
+
+// each calls fn for every booking, a shard at a time, in no particular order.
+func (i *bookingIndex) each(fn func(booking *model.Booking)) {
+	for n := range i.shards {
+		shard := &i.shards[n]
+		shard.mu.RLock()
+		for _, booking := range shard.bookings {
+			fn(booking)
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// len counts the bookings, a shard at a time.
+func (i *bookingIndex) len() int {
+	n := 0
+	for s := range i.shards {
+		shard := &i.shards[s]
+		shard.mu.RLock()
+		n += len(shard.bookings)
+		shard.mu.RUnlock()
+	}
+	return n
+}
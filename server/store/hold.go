@@ -0,0 +1,153 @@
+package store
+
+import (
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// MaxHoldsPerHolder caps the seats one shopper can keep off sale at a time.
+const MaxHoldsPerHolder = 4
+
+var (
+	ErrSeatHeld         = model.NewError(model.CodeSeatHeld, "seat is held by another shopper")
+	ErrHoldNotFound     = model.NewError(model.CodeHoldNotFound, "seat is not held by this shopper")
+	ErrHoldLimitReached = model.NewError(model.CodeHoldLimitReached, "too many seats held")
+)
+
+/*
+* Holds keep a seat for one shopper for a short time (seat selection).
+  - a held seat is reserved for everyone but the holder, whose booking uses up the hold
+  - holding the same seat again extends the hold
+  - a hold ends when released, booked, or when its timer fires; expired holds
+    still in their slot (timer pending) are ignored, see activeHold
+  - holdCounts counts a hold until it ends, so an expired hold still counts
+    against its holder until its timer fires; holders without holds aren't kept
+*/
+
+// HoldSeat holds a free seat for holderID for ttl.
+func (b *BOOKING_STORE_BUCKET) HoldSeat(seatNo uint32, holderID string, ttl time.Duration) (model.SeatHold, error) {
+	tier, valid := model.TierForSeat(seatNo)
+	if !valid || seatNo > b.TOTAL_SEAT {
+		return model.SeatHold{}, ErrInvalidSeatNumber
+	}
+
+	// acquire seat-level lock
+	slot := b.lockSeat(seatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	// ---- CRITICAL SECTION (seat-scoped) ----
+
+	if slot.booking != nil {
+		return model.SeatHold{}, ErrSeatAlreadyBooked
+	}
+	if slot.block != nil {
+		return model.SeatHold{}, ErrSeatBlocked
+	}
+
+	existing, held := activeHold(slot)
+	if held && existing.HolderID != holderID {
+		return model.SeatHold{}, ErrSeatHeld
+	}
+	if !held {
+		b.endHold(slot) // an expired one
+		if !b.countHold(holderID) {
+			return model.SeatHold{}, ErrHoldLimitReached
+		}
+	}
+
+	hold := model.SeatHold{
+		SeatNo:    seatNo,
+		Tier:      tier,
+		HolderID:  holderID,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	slot.hold = &hold
+	b.syncTaken(seatNo, slot)
+	time.AfterFunc(ttl, func() { b.expireHold(hold) })
+
+	// an extension doesn't change the seat
+	if !held {
+		b.seatChanged(&changes, seatNo, tier, model.SeatStateHeld)
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold gives a held seat back before its hold expires.
+func (b *BOOKING_STORE_BUCKET) ReleaseHold(seatNo uint32, holderID string) error {
+	if seatNo == 0 || seatNo > b.TOTAL_SEAT {
+		return ErrHoldNotFound
+	}
+
+	slot := b.lockSeat(seatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	hold, held := activeHold(slot)
+	if !held || hold.HolderID != holderID {
+		return ErrHoldNotFound
+	}
+	b.endHold(slot)
+	b.syncTaken(seatNo, slot)
+
+	b.seatChanged(&changes, seatNo, hold.Tier, model.SeatStateReleased)
+
+	return nil
+}
+
+// expireHold ends the hold once its time is up, unless it was since extended, released or booked.
+func (b *BOOKING_STORE_BUCKET) expireHold(hold model.SeatHold) {
+	slot := b.lockSeat(hold.SeatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	if slot.hold == nil || *slot.hold != hold {
+		return
+	}
+	b.endHold(slot)
+	b.syncTaken(hold.SeatNo, slot)
+
+	b.seatChanged(&changes, hold.SeatNo, hold.Tier, model.SeatStateReleased)
+}
+
+// activeHold returns the seat's hold unless it has expired. Callers hold the seat's lock.
+func activeHold(slot *seatSlot) (model.SeatHold, bool) {
+	if slot.hold == nil || !time.Now().Before(slot.hold.ExpiresAt) {
+		return model.SeatHold{}, false
+	}
+	return *slot.hold, true
+}
+
+// endHold drops the seat's hold, expired or not, and stops counting it.
+// Callers hold the seat's lock and sync TAKEN afterwards.
+func (b *BOOKING_STORE_BUCKET) endHold(slot *seatSlot) {
+	if slot.hold == nil {
+		return
+	}
+	b.uncountHold(slot.hold.HolderID)
+	slot.hold = nil
+}
+
+// countHold counts a new hold of the holder, unless it already has MaxHoldsPerHolder.
+func (b *BOOKING_STORE_BUCKET) countHold(holderID string) bool {
+	b.holdsMu.Lock()
+	defer b.holdsMu.Unlock()
+	if b.holdCounts[holderID] >= MaxHoldsPerHolder {
+		return false
+	}
+	b.holdCounts[holderID]++
+	return true
+}
+
+// uncountHold stops counting one of the holder's holds, forgetting the holder after its last.
+func (b *BOOKING_STORE_BUCKET) uncountHold(holderID string) {
+	b.holdsMu.Lock()
+	defer b.holdsMu.Unlock()
+	if b.holdCounts[holderID] <= 1 {
+		delete(b.holdCounts, holderID)
+		return
+	}
+	b.holdCounts[holderID]--
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestHoldSeat(t *testing.T) {
+	tests := []struct {
+		name          string
+		seatNo        uint32
+		holderID      string
+		setupFunc     func(BookingStore)
+		expectedError error
+	}{
+		{name: "free seat", seatNo: 61, holderID: "user-1"},
+		{
+			name: "extend own hold", seatNo: 61, holderID: "user-1",
+			setupFunc: func(s BookingStore) { s.HoldSeat(61, "user-1", time.Minute) },
+		},
+		{
+			name: "held by another shopper", seatNo: 61, holderID: "user-2",
+			setupFunc:     func(s BookingStore) { s.HoldSeat(61, "user-1", time.Minute) },
+			expectedError: ErrSeatHeld,
+		},
+		{
+			name: "hold of another shopper expired", seatNo: 61, holderID: "user-2",
+			setupFunc: func(s BookingStore) { s.HoldSeat(61, "user-1", -time.Second) },
+		},
+		{
+			name: "booked seat", seatNo: 61, holderID: "user-1",
+			setupFunc: func(s BookingStore) {
+				s.RegisterBooking(model.BookingOrder{UserID: "user-2", Tier: model.TierGA, SeatNo: 61, IdempotencyKey: "key-1"})
+			},
+			expectedError: ErrSeatAlreadyBooked,
+		},
+		{
+			name: "blocked seat", seatNo: 61, holderID: "user-1",
+			setupFunc:     func(s BookingStore) { s.BlockSeat(model.SeatBlock{SeatNo: 61}) },
+			expectedError: ErrSeatBlocked,
+		},
+		{
+			name: "hold limit", seatNo: 61, holderID: "user-1",
+			setupFunc: func(s BookingStore) {
+				for seat := uint32(1); seat <= MaxHoldsPerHolder; seat++ {
+					s.HoldSeat(seat, "user-1", time.Minute)
+				}
+			},
+			expectedError: ErrHoldLimitReached,
+		},
+		{name: "invalid seat", seatNo: 101, holderID: "user-1", expectedError: ErrInvalidSeatNumber},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookingStore := NewBookingStoreBucket()
+			if tt.setupFunc != nil {
+				tt.setupFunc(bookingStore)
+			}
+
+			hold, err := bookingStore.HoldSeat(tt.seatNo, tt.holderID, time.Minute)
+			if !errors.Is(err, tt.expectedError) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedError, err)
+			}
+			if err == nil && (hold.HolderID != tt.holderID || hold.Tier != model.TierGA) {
+				t.Errorf("Expected a GA hold for %s, got %+v", tt.holderID, hold)
+			}
+		})
+	}
+}
+
+func TestHoldBlocksOtherBookings(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+	bookingStore.HoldSeat(61, "user-1", time.Minute)
+
+	if reserved := bookingStore.GetReservedSeats()["GA"]; len(reserved) != 1 || reserved[0] != 61 {
+		t.Errorf("Expected the held seat to be reserved, got %v", reserved)
+	}
+	if _, err := bookingStore.BlockSeat(model.SeatBlock{SeatNo: 61}); !errors.Is(err, ErrSeatHeld) {
+		t.Errorf("Expected blocking a held seat to fail with %v, got %v", ErrSeatHeld, err)
+	}
+
+	order := model.BookingOrder{UserID: "user-2", Tier: model.TierGA, SeatNo: 61, IdempotencyKey: "key-2"}
+	if _, err := bookingStore.RegisterBooking(order); !errors.Is(err, ErrSeatHeld) {
+		t.Errorf("Expected another shopper's booking to fail with %v, got %v", ErrSeatHeld, err)
+	}
+
+	order.UserID = "user-1"
+	if _, err := bookingStore.RegisterBooking(order); err != nil {
+		t.Fatalf("Expected the holder's booking to succeed, got %v", err)
+	}
+	if err := bookingStore.ReleaseHold(61, "user-1"); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("Expected the booking to use up the hold, got %v", err)
+	}
+}
+
+func TestReleaseHold(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+	var events []model.SeatEvent
+	bookingStore.OnSeatChange(func(event model.SeatEvent) { events = append(events, event) })
+
+	bookingStore.HoldSeat(61, "user-1", time.Minute)
+	bookingStore.HoldSeat(61, "user-1", time.Minute) // extension: no event
+
+	if err := bookingStore.ReleaseHold(61, "user-2"); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("Expected another shopper's release to fail with %v, got %v", ErrHoldNotFound, err)
+	}
+	if err := bookingStore.ReleaseHold(61, "user-1"); err != nil {
+		t.Errorf("Expected the release to succeed, got %v", err)
+	}
+
+	if len(events) != 2 || events[0].State != model.SeatStateHeld || events[1].State != model.SeatStateReleased {
+		t.Errorf("Expected HELD then RELEASED, got %v", events)
+	}
+}
+
+func TestHoldExpires(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+	released := make(chan model.SeatEvent, 1)
+	bookingStore.OnSeatChange(func(event model.SeatEvent) {
+		if event.State == model.SeatStateReleased {
+			released <- event
+		}
+	})
+
+	bookingStore.HoldSeat(61, "user-1", 10*time.Millisecond)
+
+	select {
+	case event := <-released:
+		if event.SeatNo != 61 {
+			t.Errorf("Expected seat 61 to be released, got %d", event.SeatNo)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the hold to expire")
+	}
+	if _, err := bookingStore.HoldSeat(61, "user-2", time.Minute); err != nil {
+		t.Errorf("Expected the seat to be free again, got %v", err)
+	}
+}
+
+func TestHoldCountsForgetHolders(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+
+	// a client cycling holder IDs leaves nothing behind once its holds end
+	for i := range 100 {
+		holderID := "holder-" + strconv.Itoa(i)
+		if _, err := bookingStore.HoldSeat(61, holderID, time.Minute); err != nil {
+			t.Fatalf("Expected %s to hold the seat, got %v", holderID, err)
+		}
+		if err := bookingStore.ReleaseHold(61, holderID); err != nil {
+			t.Fatalf("Expected %s to release the seat, got %v", holderID, err)
+		}
+	}
+	bookingStore.HoldSeat(62, "user-1", time.Minute)
+	registerTestBooking(t, bookingStore, "user-1", model.TierGA, 62)
+
+	bucket := bookingStore.(*BOOKING_STORE_BUCKET)
+	bucket.holdsMu.Lock()
+	defer bucket.holdsMu.Unlock()
+	if len(bucket.holdCounts) != 0 {
+		t.Errorf("Expected no holders counted once their holds ended, got %v", bucket.holdCounts)
+	}
+}
@@ -1,44 +1,67 @@
 package store
 
 import (
+	"context"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type IDEMPOTENCY_BUCKET struct {
 	IDEMPOTENCY_STORE sync.Map // map[string]model.BookingOrder - IdempotencyKey -> BookingOrder
 
-	// idempotency-level locks (idempotency key as key)
-	idempotencyLocks sync.Map // map[string]*sync.Mutex
+	// idempotency-level locks (idempotency key as key), striped so random keys can't grow them
+	idempotencyLocks *keyLocks
+
+	// records in IDEMPOTENCY_STORE, which sync.Map doesn't count
+	entries atomic.Int64
 }
 
 type Idempotency interface {
 	HandleIdempotency(bookingOrderData model.BookingOrder) model.BookingOrder
+	HandleIdempotencyContext(ctx context.Context, bookingOrderData model.BookingOrder) model.BookingOrder
+	GetIdempotencyRecord(idempotencyKey string) (model.BookingOrder, bool)
 	getIdempotencyKeyLock(idempotencyKey string) *sync.Mutex
+	Len() int
 }
 
 func NewIdempotencyBucket() Idempotency {
 	return &IDEMPOTENCY_BUCKET{
 		IDEMPOTENCY_STORE: sync.Map{},
+		idempotencyLocks:  newKeyLocks(),
 	}
 }
 
-// getIdempotencyKeyLock returns a mutex dedicated to a single idempotency key.
+// getIdempotencyKeyLock returns the mutex guarding an idempotency key, which other keys may share.
 func (ib *IDEMPOTENCY_BUCKET) getIdempotencyKeyLock(idempotencyKey string) *sync.Mutex {
-	lock, _ := ib.idempotencyLocks.LoadOrStore(idempotencyKey, &sync.Mutex{})
-	return lock.(*sync.Mutex)
+	return ib.idempotencyLocks.lock(idempotencyKey)
 }
 
 // HandleIdempotency attempts.
 func (ib *IDEMPOTENCY_BUCKET) HandleIdempotency(
 	bookingOrderData model.BookingOrder,
 ) model.BookingOrder {
+	return ib.HandleIdempotencyContext(context.Background(), bookingOrderData)
+}
+
+// HandleIdempotencyContext is HandleIdempotency, traced in the request's
+// trace: the span records whether the key was seen before and how long its
+// lock was waited for.
+func (ib *IDEMPOTENCY_BUCKET) HandleIdempotencyContext(
+	ctx context.Context,
+	bookingOrderData model.BookingOrder,
+) model.BookingOrder {
+	ctx, span := tracing.Start(ctx, "idempotency.HandleIdempotency")
+	defer span.End()
 
 	// acquire idempotency key-level lock
 	idempotencyKeyLock := ib.getIdempotencyKeyLock(bookingOrderData.IdempotencyKey)
-	idempotencyKeyLock.Lock()
+	waited := lockTimed(ctx, idempotencyKeyLock, idempotencyLockWait, idempotencyLockSpan)
 	defer idempotencyKeyLock.Unlock()
+	span.SetAttributes(attribute.Float64("lock.wait_ms", float64(waited.Microseconds())/1000))
 
 	// ---- CRITICAL SECTION (idempotency key-scoped) ----
 
@@ -49,12 +72,31 @@ func (ib *IDEMPOTENCY_BUCKET) HandleIdempotency(
 			// update the stored booking status to confirmed
 			bookingOrder.Status = bookingOrderData.Status
 			ib.IDEMPOTENCY_STORE.Store(bookingOrderData.IdempotencyKey, bookingOrder)
+			span.SetAttributes(attribute.String("booking.status", string(bookingOrder.Status)))
+			return bookingOrder
 		}
+		span.SetAttributes(attribute.Bool("booking.idempotent_replay", true))
 		return bookingOrder
 	}
 
 	// idempotency-key : bookingOrderData the booking
 	ib.IDEMPOTENCY_STORE.Store(bookingOrderData.IdempotencyKey, bookingOrderData)
+	ib.entries.Add(1)
+	span.SetAttributes(attribute.Bool("booking.idempotent_replay", false))
 
 	return bookingOrderData
 }
+
+// GetIdempotencyRecord returns the order stored under an idempotency key.
+func (ib *IDEMPOTENCY_BUCKET) GetIdempotencyRecord(idempotencyKey string) (model.BookingOrder, bool) {
+	bookingOrderInterface, exists := ib.IDEMPOTENCY_STORE.Load(idempotencyKey)
+	if !exists {
+		return model.BookingOrder{}, false
+	}
+	return bookingOrderInterface.(model.BookingOrder), true
+}
+
+// Len returns the number of idempotency records.
+func (ib *IDEMPOTENCY_BUCKET) Len() int {
+	return int(ib.entries.Load())
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// keyLockStripes is the number of mutexes a keyLocks holds, whatever the number of keys.
+const keyLockStripes = 1024
+
+/*
+* keyLocks gives per-key mutual exclusion from a fixed set of mutexes.
+  - a key always maps to the same stripe, so two callers locking the same key
+    exclude each other
+  - different keys may share a stripe and then wait for each other, which
+    costs some concurrency but never correctness
+  - memory is bounded by keyLockStripes, so clients can't grow it by sending
+    new keys (idempotency keys are client-chosen)
+  - a caller must not hold two keys' locks at once: both may share a stripe
+*/
+type keyLocks struct {
+	seed    maphash.Seed
+	stripes [keyLockStripes]sync.Mutex
+}
+
+func newKeyLocks() *keyLocks {
+	return &keyLocks{seed: maphash.MakeSeed()}
+}
+
+// lock returns the mutex guarding key.
+func (l *keyLocks) lock(key string) *sync.Mutex {
+	return &l.stripes[maphash.String(l.seed, key)%keyLockStripes]
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestKeyLocks_SameKeySameLock(t *testing.T) {
+	locks := newKeyLocks()
+
+	for _, key := range []string{"", "key-1", "key-2", "a-much-longer-idempotency-key"} {
+		if locks.lock(key) != locks.lock(key) {
+			t.Errorf("Expected the same lock for key %q", key)
+		}
+	}
+}
+
+func TestKeyLocks_Bounded(t *testing.T) {
+	locks := newKeyLocks()
+
+	seen := make(map[*sync.Mutex]bool)
+	for i := 0; i < 100_000; i++ {
+		seen[locks.lock("random-key-"+strconv.Itoa(i))] = true
+	}
+
+	if len(seen) > keyLockStripes {
+		t.Errorf("Expected at most %d locks, got %d", keyLockStripes, len(seen))
+	}
+	// keys should spread over the stripes, not pile onto a few
+	if len(seen) < keyLockStripes/2 {
+		t.Errorf("Expected keys spread over at least %d locks, got %d", keyLockStripes/2, len(seen))
+	}
+}
+
+func TestKeyLocks_MutualExclusion(t *testing.T) {
+	locks := newKeyLocks()
+	numGoroutines := 20
+	increments := 1000
+
+	// the map itself is only read concurrently, the counters are guarded by the key locks
+	counters := make(map[string]*int)
+	keys := []string{"key-a", "key-b", "key-c"}
+	for _, key := range keys {
+		counters[key] = new(int)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(idx int) {
+			defer wg.Done()
+			key := keys[idx%len(keys)]
+			for j := 0; j < increments; j++ {
+				lock := locks.lock(key)
+				lock.Lock()
+				*counters[key]++
+				lock.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, count := range counters {
+		total += *count
+	}
+	if total != numGoroutines*increments {
+		t.Errorf("Expected %d increments, got %d", numGoroutines*increments, total)
+	}
+}
@@ -0,0 +1,46 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/metrics"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// lock wait buckets, in seconds: most locks are free, a contended seat waits microseconds
+var lockWaitBuckets = []float64{0.000001, 0.00001, 0.0001, 0.001, 0.01, 0.1, 1}
+
+var (
+	lockWait = metrics.Default.HistogramVec("booking_lock_wait_seconds",
+		"Time spent waiting for a seat or idempotency key lock; 0 when it was free.",
+		lockWaitBuckets, "lock")
+	seatLockWait        = lockWait.With("seat")
+	idempotencyLockWait = lockWait.With("idempotency_key")
+)
+
+// spans of contended lock waits
+const (
+	seatLockSpan        = "seat lock wait"
+	idempotencyLockSpan = "idempotency key lock wait"
+)
+
+// lockTimed locks mu, records how long that took and returns it. A free lock
+// is taken without reading the clock; a contended one is waited for in a span
+// named spanName.
+func lockTimed(ctx context.Context, mu *sync.Mutex, wait *metrics.Histogram, spanName string) time.Duration {
+	if mu.TryLock() {
+		wait.Observe(0)
+		return 0
+	}
+	_, span := tracing.Start(ctx, spanName)
+	start := time.Now()
+	mu.Lock()
+	waited := time.Since(start)
+	wait.Observe(waited.Seconds())
+	span.SetAttributes(attribute.Float64("lock.wait_ms", float64(waited.Microseconds())/1000))
+	span.End()
+	return waited
+}
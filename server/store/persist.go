@@ -1,79 +1,297 @@
 package store
 
 import (
-	"errors"
+	"context"
+	"encoding/base64"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/ignius299792458/techkraft-ch-svr/model"
+	"github.com/ignius299792458/techkraft-ch-svr/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 /*
 * VIP, FRONT_ROW, GA
   - seats can be set manual arr, but for now range is used
+  - ranges come from the seat layout (model.SetSeatLayout), by default:
 
 * VIP 	:- first 30 (seats 1-30)
 * FRONT_ROW 	:- 31 to 60 (seats 31-60)
 * GA 		:- 61 to 100 (seats 61-100)
-* TOTAL_SEAT = 100
+* TOTAL_SEAT = 100, the layout's highest seat
+
+  - There is no store-wide lock: every seat is a SEATS slot with its own mutex,
+    and TAKEN mirrors the slots as a bitset readers scan without locking.
+  - Listeners hear of a change once its seat is unlocked, so a slow listener
+    holds up the caller, not the next booking of the seat. Bookings of
+    different seats only meet in the listeners themselves: the seat feed and
+    the booking audit each take a lock of their own.
 */
 type BOOKING_STORE_BUCKET struct {
-	BOOKING_STORE map[uint32]model.Booking // SeatNo -> Booking
+	SEATS         []seatSlot   // SeatNo -> seat state, index 0 unused
+	TAKEN         seatSet      // seats booked, blocked or held; written under the seat's lock
+	BOOKING_INDEX bookingIndex // BookingID -> booking, kept after the seat is freed
+	USER_INDEX    sync.Map     // UserID -> *userBookings
 	TOTAL_SEAT    uint32
 
-	// Protects the BOOKING_STORE map structure from concurrent access
-	mapMu sync.RWMutex
+	// active holds per holder, for MaxHoldsPerHolder; a holder is
+	// forgotten when its last hold ends, as holder IDs come from clients
+	holdsMu    sync.Mutex
+	holdCounts map[string]int
+
+	// notified of every seat change, see OnSeatChange
+	listenersMu sync.RWMutex
+	listeners   []func(model.SeatEvent)
+
+	// notified of every booking transition, see OnBookingChange
+	bookingListeners []func(model.BookingEvent, model.Booking)
 
-	// seat-level locks (seat number as key)
-	seatLocks sync.Map // map[uint32]*sync.Mutex
+	// inventory version, bumped on every seat change
+	version atomic.Uint64
+}
+
+// seatSlot is one seat. At most one of booking, block and hold is set; the
+// booking is shared with BOOKING_INDEX.
+type seatSlot struct {
+	mu      sync.Mutex
+	booking *model.Booking
+	block   *model.SeatBlock
+	hold    *model.SeatHold
+
+	// held from the end of a critical section until its changes are told,
+	// so the seat's changes reach the listeners in order
+	notifyMu sync.Mutex
+}
+
+// seatChanges collects what one critical section changed, to tell the
+// listeners once the seat is unlocked. A critical section changes its seat at
+// most once and a booking at most twice (created, then the payment's outcome),
+// so the changes fit in place, without allocating.
+type seatChanges struct {
+	seat         model.SeatEvent
+	seatChanged  bool
+	bookings     [2]bookingChange
+	bookingCount int
+}
+
+type bookingChange struct {
+	event   model.BookingEvent
+	booking model.Booking
+}
+
+// userBookings lists a user's booking IDs, oldest first (append-only).
+type userBookings struct {
+	mu  sync.RWMutex
+	ids []uuid.UUID
 }
 
 type BookingStore interface {
 	RegisterBooking(bookingOrderData model.BookingOrder) (model.Booking, error)
+	RegisterBookingContext(ctx context.Context, bookingOrderData model.BookingOrder) (model.Booking, error)
 	GetBooking(seatNo uint32) (model.Booking, error)
-	getSeatLock(seatNo uint32) *sync.Mutex
 	GetReservedSeats() map[string][]uint32
+
+	GetBookingByID(id uuid.UUID) (model.Booking, error)
+	ListUserBookings(filter model.BookingFilter, cursor string, limit int) ([]model.Booking, string, error)
+	ListBookings(filter model.BookingFilter) []model.Booking
+	CancelBooking(id uuid.UUID) (model.Booking, error)
+
+	BlockSeat(block model.SeatBlock) (model.SeatBlock, error)
+	UnblockSeat(seatNo uint32) error
+	GetBlockedSeats() []model.SeatBlock
+
+	HoldSeat(seatNo uint32, holderID string, ttl time.Duration) (model.SeatHold, error)
+	ReleaseHold(seatNo uint32, holderID string) error
+
+	OnSeatChange(listener func(model.SeatEvent))
+	OnBookingChange(listener func(event model.BookingEvent, booking model.Booking))
+	Version() uint64
+	Stats() model.StoreStats
+
+	Snapshot() Snapshot
+	Restore(snapshot Snapshot) error
 }
 
+var (
+	ErrBookingNotFound      = model.NewError(model.CodeBookingNotFound, "booking not found")
+	ErrBookingAlreadyCancel = model.NewError(model.CodeBookingAlreadyCanceled, "booking already canceled")
+	ErrSeatAlreadyBooked    = model.NewError(model.CodeSeatTaken, "seat already booked")
+	ErrSeatBlocked          = model.NewError(model.CodeSeatBlocked, "seat is blocked")
+	ErrSeatNotBlocked       = model.NewError(model.CodeSeatNotBlocked, "seat is not blocked")
+	ErrInvalidSeatNumber    = model.NewError(model.CodeInvalidSeat, "invalid seat number")
+	ErrSeatNotInTier        = model.NewError(model.CodeInvalidSeat, "seat is not in the tier")
+	ErrInvalidCursor        = model.NewError(model.CodeInvalidCursor, "invalid cursor")
+)
+
 func NewBookingStoreBucket() BookingStore {
+	totalSeat := model.TotalSeats()
 	return &BOOKING_STORE_BUCKET{
-		BOOKING_STORE: make(map[uint32]model.Booking),
-		TOTAL_SEAT:    100,
+		SEATS:      make([]seatSlot, totalSeat+1),
+		TAKEN:      newSeatSet(totalSeat),
+		TOTAL_SEAT: totalSeat,
+		holdCounts: make(map[string]int),
+	}
+}
+
+// lockSeat locks a single seat and returns it; callers unlock slot.mu, or unlockSeat once they changed it.
+func (b *BOOKING_STORE_BUCKET) lockSeat(seatNo uint32) *seatSlot {
+	slot := &b.SEATS[seatNo]
+	lockTimed(context.Background(), &slot.mu, seatLockWait, seatLockSpan)
+	return slot
+}
+
+// syncTaken mirrors the slot into TAKEN. Callers hold the seat's lock.
+func (b *BOOKING_STORE_BUCKET) syncTaken(seatNo uint32, slot *seatSlot) {
+	if slot.booking != nil || slot.block != nil || slot.hold != nil {
+		b.TAKEN.add(seatNo)
+	} else {
+		b.TAKEN.remove(seatNo)
 	}
 }
 
-// getSeatLock returns a mutex dedicated to a single seat.
-func (b *BOOKING_STORE_BUCKET) getSeatLock(seatNo uint32) *sync.Mutex {
-	lock, _ := b.seatLocks.LoadOrStore(seatNo, &sync.Mutex{})
-	return lock.(*sync.Mutex)
+// OnSeatChange registers a listener for seat changes. Listeners run after the
+// seat is unlocked, but before its next change is told, so events of a seat
+// arrive in order; they must not block or call back into the store.
+func (b *BOOKING_STORE_BUCKET) OnSeatChange(listener func(model.SeatEvent)) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.listeners = append(b.listeners, listener)
+}
+
+// OnBookingChange registers a listener for booking transitions, called with
+// the booking as the transition left it. Like seat listeners, they run once the
+// seat is unlocked, one change of the seat at a time, so transitions of a
+// booking arrive in order.
+func (b *BOOKING_STORE_BUCKET) OnBookingChange(listener func(event model.BookingEvent, booking model.Booking)) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.bookingListeners = append(b.bookingListeners, listener)
+}
+
+// bookingChanged records a booking transition for the listeners. Callers hold the seat's lock.
+func (c *seatChanges) bookingChanged(event model.BookingEvent, booking model.Booking) {
+	c.bookings[c.bookingCount] = bookingChange{event: event, booking: booking}
+	c.bookingCount++
+}
+
+// Version identifies the current seat inventory: it increases with every
+// booking, cancellation, block and hold, so equal versions mean equal seats.
+func (b *BOOKING_STORE_BUCKET) Version() uint64 {
+	return b.version.Load()
+}
+
+// seatChanged bumps the inventory version and records the change for the listeners. Callers hold the seat's lock.
+func (b *BOOKING_STORE_BUCKET) seatChanged(changes *seatChanges, seatNo uint32, tier model.Tier, state model.SeatState) {
+	b.version.Add(1)
+	changes.seat = model.SeatEvent{SeatNo: seatNo, Tier: tier, State: state, At: time.Now()}
+	changes.seatChanged = true
+}
+
+// unlockSeat ends a critical section and tells the listeners what it changed.
+// The seat's notify lock is taken before the seat is unlocked, so the next
+// change of the seat waits for this one to be told before it is told itself.
+func (b *BOOKING_STORE_BUCKET) unlockSeat(slot *seatSlot, changes *seatChanges) {
+	if !changes.seatChanged && changes.bookingCount == 0 {
+		slot.mu.Unlock()
+		return
+	}
+	slot.notifyMu.Lock()
+	slot.mu.Unlock()
+	defer slot.notifyMu.Unlock()
+
+	b.listenersMu.RLock()
+	defer b.listenersMu.RUnlock()
+
+	if changes.seatChanged {
+		for _, listener := range b.listeners {
+			listener(changes.seat)
+		}
+	}
+	for _, change := range changes.bookings[:changes.bookingCount] {
+		for _, listener := range b.bookingListeners {
+			listener(change.event, change.booking)
+		}
+	}
 }
 
 // RegisterBooking attempts to register a new booking.
 func (b *BOOKING_STORE_BUCKET) RegisterBooking(
 	bookingOrderData model.BookingOrder,
 ) (model.Booking, error) {
+	return b.RegisterBookingContext(context.Background(), bookingOrderData)
+}
+
+// RegisterBookingContext is RegisterBooking, traced in the request's trace:
+// the span records the seat, the tier, how long the seat lock was waited for
+// and the booking's status or error.
+func (b *BOOKING_STORE_BUCKET) RegisterBookingContext(
+	ctx context.Context,
+	bookingOrderData model.BookingOrder,
+) (model.Booking, error) {
+	ctx, span := tracing.Start(ctx, "store.RegisterBooking",
+		attribute.Int64("booking.seat_no", int64(bookingOrderData.SeatNo)),
+		attribute.String("booking.tier", string(bookingOrderData.Tier)),
+	)
+	defer span.End()
+
+	booking, err := b.registerBooking(ctx, span, bookingOrderData)
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(attribute.String("booking.status", string(booking.Status)))
+	}
+	return booking, err
+}
+
+func (b *BOOKING_STORE_BUCKET) registerBooking(
+	ctx context.Context,
+	span trace.Span,
+	bookingOrderData model.BookingOrder,
+) (model.Booking, error) {
 
 	// basic validation (cheap checks first)
 	if bookingOrderData.SeatNo == 0 || bookingOrderData.SeatNo > b.TOTAL_SEAT {
-		return model.Booking{}, errors.New("invalid seat number")
+		return model.Booking{}, ErrInvalidSeatNumber
+	}
+	// the tier sets the price, so it must be the seat's
+	if tier, _ := model.TierForSeat(bookingOrderData.SeatNo); tier != bookingOrderData.Tier {
+		return model.Booking{}, ErrSeatNotInTier
 	}
 
 	// acquire seat-level lock
-	seatBookingLock := b.getSeatLock(bookingOrderData.SeatNo)
-	seatBookingLock.Lock()
-	defer seatBookingLock.Unlock()
+	slot := &b.SEATS[bookingOrderData.SeatNo]
+	waited := lockTimed(ctx, &slot.mu, seatLockWait, seatLockSpan)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+	span.SetAttributes(attribute.Float64("lock.wait_ms", float64(waited.Microseconds())/1000))
 
 	// ---- CRITICAL SECTION (seat-scoped) ----
 
-	// Acquire map write lock before accessing the map
-	b.mapMu.Lock()
-	defer b.mapMu.Unlock()
-
 	// prevent double booking
-	if _, exists := b.BOOKING_STORE[bookingOrderData.SeatNo]; exists {
-		return model.Booking{}, errors.New("seat already booked")
+	if slot.booking != nil {
+		return model.Booking{}, ErrSeatAlreadyBooked
+	}
+
+	// blocked seats can only be issued as complimentary tickets, which lifts the block
+	if slot.block != nil {
+		if !bookingOrderData.Complimentary {
+			return model.Booking{}, ErrSeatBlocked
+		}
+		slot.block = nil
+	}
+
+	// held seats are kept for their holder, whose booking uses up the hold
+	if hold, held := activeHold(slot); held && hold.HolderID != bookingOrderData.UserID {
+		return model.Booking{}, ErrSeatHeld
 	}
+	b.endHold(slot)
 
 	newBooking := model.Booking{
 		ID:     uuid.New(),
@@ -89,6 +307,15 @@ func (b *BOOKING_STORE_BUCKET) RegisterBooking(
 		ZipCode:  bookingOrderData.ZipCode,
 		Currency: bookingOrderData.Currency,
 
+		Complimentary: bookingOrderData.Complimentary,
+		Channel:       bookingOrderData.Channel,
+		APIKeyID:      bookingOrderData.APIKeyID,
+
+		PromoCode:        bookingOrderData.PromoCode,
+		DiscountInUSCent: bookingOrderData.DiscountInUSCent,
+		FeesInUSCent:     bookingOrderData.FeesInUSCent,
+		TaxInUSCent:      bookingOrderData.TaxInUSCent,
+
 		TotalAmtInUSCent: bookingOrderData.TotalAmtInUSCent,
 		PaymentID:        bookingOrderData.PaymentID,
 		PaymentStatus:    bookingOrderData.PaymentStatus,
@@ -107,45 +334,249 @@ func (b *BOOKING_STORE_BUCKET) RegisterBooking(
 		newBooking.Status = model.BookingStatusCanceled
 	}
 
-	b.BOOKING_STORE[newBooking.SeatNo] = newBooking
+	slot.booking = &newBooking
+	b.syncTaken(newBooking.SeatNo, slot)
+
+	// indexed before it's listed for the user, so listings never miss it
+	b.BOOKING_INDEX.store(&newBooking)
+	b.userBookings(newBooking.UserID).append(newBooking.ID)
+
+	b.seatChanged(&changes, newBooking.SeatNo, newBooking.Tier, model.SeatStateBooked)
+
+	// created pending, then the payment's outcome
+	created := newBooking
+	created.Status = model.BookingStatusPending
+	changes.bookingChanged(model.BookingEventCreated, created)
+	switch newBooking.Status {
+	case model.BookingStatusConfirmed:
+		changes.bookingChanged(model.BookingEventConfirmed, newBooking)
+	case model.BookingStatusCanceled:
+		changes.bookingChanged(model.BookingEventCanceled, newBooking)
+	}
 
 	return newBooking, nil
 }
 
-// GetReservedSeats returns a map of reserved seat numbers categorized by tier.
-func (b *BOOKING_STORE_BUCKET) GetReservedSeats() map[string][]uint32 {
-	b.mapMu.RLock()
-	defer b.mapMu.RUnlock()
-
-	VIPReservedSeats := make([]uint32, 0)
-	FRONTROWReservedSeats := make([]uint32, 0)
-	GAReservedSeats := make([]uint32, 0)
-
-	for seatNo, booking := range b.BOOKING_STORE {
-		switch booking.Tier {
-		case model.TierVIP:
-			VIPReservedSeats = append(VIPReservedSeats, seatNo)
-		case model.TierFrontRow:
-			FRONTROWReservedSeats = append(FRONTROWReservedSeats, seatNo)
-		case model.TierGA:
-			GAReservedSeats = append(GAReservedSeats, seatNo)
-		}
+func (b *BOOKING_STORE_BUCKET) userBookings(userID string) *userBookings {
+	if index, exists := b.USER_INDEX.Load(userID); exists {
+		return index.(*userBookings)
 	}
+	index, _ := b.USER_INDEX.LoadOrStore(userID, &userBookings{})
+	return index.(*userBookings)
+}
+
+func (u *userBookings) append(id uuid.UUID) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.ids = append(u.ids, id)
+}
 
-	return map[string][]uint32{
-		"VIP":       VIPReservedSeats,
-		"FRONT_ROW": FRONTROWReservedSeats,
-		"GA":        GAReservedSeats,
+// GetReservedSeats returns the reserved (booked, blocked or held) seat numbers
+// of each tier, in order. It reads TAKEN a word at a time and takes no lock.
+func (b *BOOKING_STORE_BUCKET) GetReservedSeats() map[string][]uint32 {
+	reserved := make(map[string][]uint32, len(model.AllTiers()))
+	for _, tier := range model.AllTiers() {
+		seatRange := tier.SeatRange()
+		reserved[string(tier)] = b.TAKEN.appendRange(make([]uint32, 0), seatRange.Min, min(seatRange.Max, b.TOTAL_SEAT))
 	}
+	return reserved
 }
 
 func (b *BOOKING_STORE_BUCKET) GetBooking(seatNo uint32) (model.Booking, error) {
-	b.mapMu.RLock()
-	defer b.mapMu.RUnlock()
+	if seatNo == 0 || seatNo > b.TOTAL_SEAT {
+		return model.Booking{}, ErrBookingNotFound
+	}
+
+	slot := b.lockSeat(seatNo)
+	defer slot.mu.Unlock()
+
+	if slot.booking == nil {
+		return model.Booking{}, ErrBookingNotFound
+	}
+	return *slot.booking, nil
+}
 
-	booking, exists := b.BOOKING_STORE[seatNo]
+func (b *BOOKING_STORE_BUCKET) GetBookingByID(id uuid.UUID) (model.Booking, error) {
+	booking, exists := b.BOOKING_INDEX.load(id)
 	if !exists {
-		return model.Booking{}, errors.New("booking not found")
+		return model.Booking{}, ErrBookingNotFound
+	}
+	return *booking, nil
+}
+
+// ListUserBookings returns up to limit of filter.UserID's bookings, oldest first,
+// only those passing the rest of the filter. The returned cursor fetches the
+// next page; it is empty on the last page.
+func (b *BOOKING_STORE_BUCKET) ListUserBookings(
+	filter model.BookingFilter,
+	cursor string,
+	limit int,
+) ([]model.Booking, string, error) {
+	offset, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var ids []uuid.UUID
+	if index, exists := b.USER_INDEX.Load(filter.UserID); exists {
+		index := index.(*userBookings)
+		index.mu.RLock()
+		ids = index.ids[:len(index.ids):len(index.ids)] // append-only: the prefix never changes
+		index.mu.RUnlock()
+	}
+	if offset > len(ids) {
+		return nil, "", ErrInvalidCursor
+	}
+
+	bookings := make([]model.Booking, 0, min(limit, len(ids)-offset))
+	next := offset
+	for ; next < len(ids) && len(bookings) < limit; next++ {
+		booking, _ := b.GetBookingByID(ids[next])
+		if filter.Matches(booking) {
+			bookings = append(bookings, booking)
+		}
+	}
+
+	if next >= len(ids) {
+		return bookings, "", nil
+	}
+	return bookings, encodeCursor(next), nil
+}
+
+// Cursors are opaque to clients: the position in the user's append-only index.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// ListBookings returns every booking matching the filter, oldest first.
+func (b *BOOKING_STORE_BUCKET) ListBookings(filter model.BookingFilter) []model.Booking {
+	bookings := make([]model.Booking, 0)
+	b.BOOKING_INDEX.each(func(booking *model.Booking) {
+		if filter.Matches(*booking) {
+			bookings = append(bookings, *booking)
+		}
+	})
+	slices.SortFunc(bookings, func(a, b model.Booking) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return bookings
+}
+
+// CancelBooking marks a booking canceled and frees its seat. A booking already
+// canceled, by an admin or by its failed payment, is left as it is.
+func (b *BOOKING_STORE_BUCKET) CancelBooking(id uuid.UUID) (model.Booking, error) {
+	booking, err := b.GetBookingByID(id)
+	if err != nil {
+		return model.Booking{}, err
 	}
+
+	// acquire seat-level lock
+	slot := b.lockSeat(booking.SeatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	// ---- CRITICAL SECTION (seat-scoped) ----
+
+	// re-read under the lock, the booking may have changed since; canceling
+	// again would record a second transition and give its promo code back twice
+	booking, _ = b.GetBookingByID(id)
+	if booking.Status == model.BookingStatusCanceled {
+		return model.Booking{}, ErrBookingAlreadyCancel
+	}
+
+	booking.Status = model.BookingStatusCanceled
+	booking.UpdatedAt = time.Now()
+	b.BOOKING_INDEX.store(&booking)
+	if slot.booking != nil && slot.booking.ID == id {
+		slot.booking = nil
+		b.syncTaken(booking.SeatNo, slot)
+		b.seatChanged(&changes, booking.SeatNo, booking.Tier, model.SeatStateReleased)
+	}
+	changes.bookingChanged(model.BookingEventCanceled, booking)
+
 	return booking, nil
 }
+
+// BlockSeat takes a free seat off sale.
+func (b *BOOKING_STORE_BUCKET) BlockSeat(block model.SeatBlock) (model.SeatBlock, error) {
+	tier, valid := model.TierForSeat(block.SeatNo)
+	if !valid || block.SeatNo > b.TOTAL_SEAT {
+		return model.SeatBlock{}, ErrInvalidSeatNumber
+	}
+
+	// acquire seat-level lock
+	slot := b.lockSeat(block.SeatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	if slot.booking != nil {
+		return model.SeatBlock{}, ErrSeatAlreadyBooked
+	}
+	if slot.block != nil {
+		return model.SeatBlock{}, ErrSeatBlocked
+	}
+	if _, held := activeHold(slot); held {
+		return model.SeatBlock{}, ErrSeatHeld
+	}
+	b.endHold(slot) // an expired one
+
+	block.Tier = tier
+	block.BlockedAt = time.Now()
+	slot.block = &block
+	b.syncTaken(block.SeatNo, slot)
+
+	b.seatChanged(&changes, block.SeatNo, block.Tier, model.SeatStateHeld)
+
+	return block, nil
+}
+
+// UnblockSeat puts a blocked seat back on sale.
+func (b *BOOKING_STORE_BUCKET) UnblockSeat(seatNo uint32) error {
+	if seatNo == 0 || seatNo > b.TOTAL_SEAT {
+		return ErrSeatNotBlocked
+	}
+
+	slot := b.lockSeat(seatNo)
+	var changes seatChanges
+	defer b.unlockSeat(slot, &changes)
+
+	if slot.block == nil {
+		return ErrSeatNotBlocked
+	}
+	block := *slot.block
+	slot.block = nil
+	b.syncTaken(seatNo, slot)
+
+	b.seatChanged(&changes, seatNo, block.Tier, model.SeatStateReleased)
+
+	return nil
+}
+
+// GetBlockedSeats returns every blocked seat ordered by seat number.
+func (b *BOOKING_STORE_BUCKET) GetBlockedSeats() []model.SeatBlock {
+	blocks := make([]model.SeatBlock, 0)
+	// only taken seats can be blocked
+	for _, seatNo := range b.TAKEN.appendRange(nil, 1, b.TOTAL_SEAT) {
+		slot := b.lockSeat(seatNo)
+		if slot.block != nil {
+			blocks = append(blocks, *slot.block)
+		}
+		slot.mu.Unlock()
+	}
+	return blocks
+}
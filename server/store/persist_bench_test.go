@@ -2,16 +2,29 @@ package store
 
 import (
 	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/ignius299792458/techkraft-ch-svr/model"
 )
 
+// withListeners registers the listeners the server runs with, the seat feed and
+// the booking audit, so the benchmarks pay for telling them
+func withListeners(bucket BookingStore) *BOOKING_STORE_BUCKET {
+	bucket.OnSeatChange(NewSeatFeedBucket().Publish)
+	audit := NewBookingAuditBucket()
+	bucket.OnBookingChange(func(event model.BookingEvent, booking model.Booking) {
+		audit.Append(event, booking)
+	})
+	return bucket.(*BOOKING_STORE_BUCKET)
+}
+
 // BenchmarkRegisterBooking benchmarks RegisterBooking function
 func BenchmarkRegisterBooking(b *testing.B) {
-	bucket := NewBookingStoreBucket().(*BOOKING_STORE_BUCKET)
+	bucket := withListeners(NewBookingStoreBucket())
 
 	bookingOrder := model.BookingOrder{
 		UserID:           "bench-user",
@@ -19,7 +32,7 @@ func BenchmarkRegisterBooking(b *testing.B) {
 		SeatNo:           1,
 		Status:           model.BookingStatusPending,
 		IdempotencyKey:   "bench-key",
-		Country:          "USA",
+		Country:          "US",
 		ZipCode:          "10001",
 		Currency:         "USD",
 		TotalAmtInUSCent: 10000,
@@ -32,6 +45,7 @@ func BenchmarkRegisterBooking(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		order := bookingOrder
 		order.SeatNo = uint32(i%100 + 1)
+		order.Tier, _ = model.TierForSeat(order.SeatNo)
 		order.IdempotencyKey = "bench-key-" + string(rune(i))
 
 		_, err := bucket.RegisterBooking(order)
@@ -41,14 +55,14 @@ func BenchmarkRegisterBooking(b *testing.B) {
 
 		// Reset bucket periodically to avoid all seats being booked
 		if i%50 == 0 && i > 0 {
-			bucket = NewBookingStoreBucket().(*BOOKING_STORE_BUCKET)
+			bucket = withListeners(NewBookingStoreBucket())
 		}
 	}
 }
 
 // BenchmarkRegisterBooking_Parallel benchmarks RegisterBooking with parallel requests
 func BenchmarkRegisterBooking_Parallel(b *testing.B) {
-	bucket := NewBookingStoreBucket().(*BOOKING_STORE_BUCKET)
+	bucket := withListeners(NewBookingStoreBucket())
 
 	bookingOrder := model.BookingOrder{
 		UserID:           "bench-user",
@@ -56,7 +70,7 @@ func BenchmarkRegisterBooking_Parallel(b *testing.B) {
 		SeatNo:           1,
 		Status:           model.BookingStatusPending,
 		IdempotencyKey:   "bench-key",
-		Country:          "USA",
+		Country:          "US",
 		ZipCode:          "10001",
 		Currency:         "USD",
 		TotalAmtInUSCent: 10000,
@@ -81,6 +95,7 @@ func BenchmarkRegisterBooking_Parallel(b *testing.B) {
 
 			order := bookingOrder
 			order.SeatNo = seatNo
+			order.Tier, _ = model.TierForSeat(seatNo)
 			order.IdempotencyKey = "bench-key-" + string(rune(seatNo))
 
 			_, err := bucket.RegisterBooking(order)
@@ -93,7 +108,7 @@ func BenchmarkRegisterBooking_Parallel(b *testing.B) {
 
 // BenchmarkRegisterBooking_P95 measures p95 latency for RegisterBooking
 func BenchmarkRegisterBooking_P95(b *testing.B) {
-	bucket := NewBookingStoreBucket().(*BOOKING_STORE_BUCKET)
+	bucket := withListeners(NewBookingStoreBucket())
 
 	bookingOrder := model.BookingOrder{
 		UserID:           "p95-user",
@@ -101,7 +116,7 @@ func BenchmarkRegisterBooking_P95(b *testing.B) {
 		SeatNo:           1,
 		Status:           model.BookingStatusPending,
 		IdempotencyKey:   "p95-key",
-		Country:          "USA",
+		Country:          "US",
 		ZipCode:          "10001",
 		Currency:         "USD",
 		TotalAmtInUSCent: 10000,
@@ -118,6 +133,7 @@ func BenchmarkRegisterBooking_P95(b *testing.B) {
 	for i := 0; i < iterations; i++ {
 		order := bookingOrder
 		order.SeatNo = uint32(i%100 + 1)
+		order.Tier, _ = model.TierForSeat(order.SeatNo)
 		order.IdempotencyKey = "p95-key-" + string(rune(i))
 
 		start := time.Now()
@@ -133,7 +149,7 @@ func BenchmarkRegisterBooking_P95(b *testing.B) {
 
 		// Reset bucket periodically
 		if i%100 == 0 && i > 0 {
-			bucket = NewBookingStoreBucket().(*BOOKING_STORE_BUCKET)
+			bucket = withListeners(NewBookingStoreBucket())
 		}
 	}
 
@@ -163,9 +179,10 @@ func BenchmarkGetReservedSeats(b *testing.B) {
 
 	// Pre-populate with bookings
 	for i := uint32(1); i <= 50; i++ {
+		tier, _ := model.TierForSeat(i)
 		bucket.RegisterBooking(model.BookingOrder{
 			UserID:         "user-bench",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-bench",
 			PaymentID:      "pay-bench",
@@ -186,9 +203,10 @@ func BenchmarkGetReservedSeats_Parallel(b *testing.B) {
 
 	// Pre-populate with bookings
 	for i := uint32(1); i <= 50; i++ {
+		tier, _ := model.TierForSeat(i)
 		bucket.RegisterBooking(model.BookingOrder{
 			UserID:         "user-bench",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-bench",
 			PaymentID:      "pay-bench",
@@ -211,9 +229,10 @@ func BenchmarkGetReservedSeats_P95(b *testing.B) {
 
 	// Pre-populate with varying number of bookings
 	for i := uint32(1); i <= 50; i++ {
+		tier, _ := model.TierForSeat(i)
 		bucket.RegisterBooking(model.BookingOrder{
 			UserID:         "user-p95",
-			Tier:           model.TierVIP,
+			Tier:           tier,
 			SeatNo:         i,
 			IdempotencyKey: "key-p95",
 			PaymentID:      "pay-p95",
@@ -303,3 +322,80 @@ func BenchmarkGetBooking_Parallel(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkGetReservedSeats_SoldOut reads a sold-out venue, the most seats there are to collect
+func BenchmarkGetReservedSeats_SoldOut(b *testing.B) {
+	bucket := NewBookingStoreBucket()
+	for seatNo := uint32(1); seatNo <= 100; seatNo++ {
+		tier, _ := model.TierForSeat(seatNo)
+		bucket.RegisterBooking(model.BookingOrder{
+			UserID:         "user-bench",
+			Tier:           tier,
+			SeatNo:         seatNo,
+			IdempotencyKey: "key-bench",
+			PaymentID:      "pay-bench",
+			PaymentStatus:  model.PaymentStatusConfirmed,
+		})
+	}
+
+	for b.Loop() {
+		_ = bucket.GetReservedSeats()
+	}
+}
+
+// BenchmarkBookAndCancel_DistinctSeats_Parallel books and cancels a different
+// seat in every goroutine, so any contention comes from store-wide locks
+func BenchmarkBookAndCancel_DistinctSeats_Parallel(b *testing.B) {
+	bucket := withListeners(NewBookingStoreBucket())
+	var nextSeat atomic.Uint32
+
+	b.RunParallel(func(pb *testing.PB) {
+		seatNo := nextSeat.Add(1)%100 + 1
+		tier, _ := model.TierForSeat(seatNo)
+		order := model.BookingOrder{
+			UserID:         "user-bench-" + strconv.Itoa(int(seatNo)),
+			Tier:           tier,
+			SeatNo:         seatNo,
+			IdempotencyKey: "key-bench",
+			PaymentID:      "pay-bench",
+			PaymentStatus:  model.PaymentStatusConfirmed,
+		}
+		for pb.Next() {
+			if booking, err := bucket.RegisterBooking(order); err == nil {
+				bucket.CancelBooking(booking.ID)
+			}
+		}
+	})
+}
+
+// BenchmarkBookingWhilePolling_Parallel mixes availability reads with bookings
+// and cancellations, one write in ten operations
+func BenchmarkBookingWhilePolling_Parallel(b *testing.B) {
+	bucket := withListeners(NewBookingStoreBucket())
+	for seatNo := uint32(1); seatNo <= 50; seatNo++ {
+		tier, _ := model.TierForSeat(seatNo)
+		bucket.RegisterBooking(model.BookingOrder{
+			UserID: "user-bench", Tier: tier, SeatNo: seatNo, IdempotencyKey: "key-bench",
+			PaymentID: "pay-bench", PaymentStatus: model.PaymentStatusConfirmed,
+		})
+	}
+	var nextSeat atomic.Uint32
+
+	b.RunParallel(func(pb *testing.PB) {
+		seatNo := nextSeat.Add(1)%50 + 51
+		tier, _ := model.TierForSeat(seatNo)
+		order := model.BookingOrder{
+			UserID: "user-bench", Tier: tier, SeatNo: seatNo, IdempotencyKey: "key-bench",
+			PaymentID: "pay-bench", PaymentStatus: model.PaymentStatusConfirmed,
+		}
+		for i := 0; pb.Next(); i++ {
+			if i%10 != 0 {
+				_ = bucket.GetReservedSeats()
+				continue
+			}
+			if booking, err := bucket.RegisterBooking(order); err == nil {
+				bucket.CancelBooking(booking.ID)
+			}
+		}
+	})
+}
@@ -22,7 +22,7 @@ func TestRegisterBooking(t *testing.T) {
 				SeatNo:           1,
 				Status:           model.BookingStatusPending,
 				IdempotencyKey:   "key-1",
-				Country:          "USA",
+				Country:          "US",
 				ZipCode:          "10001",
 				Currency:         "USD",
 				TotalAmtInUSCent: 10000,
@@ -39,8 +39,8 @@ func TestRegisterBooking(t *testing.T) {
 				SeatNo:           31,
 				Status:           model.BookingStatusPending,
 				IdempotencyKey:   "key-2",
-				Country:          "UK",
-				ZipCode:          "SW1A",
+				Country:          "GB",
+				ZipCode:          "SW1A 1AA",
 				Currency:         "GBP",
 				TotalAmtInUSCent: 5000,
 				PaymentID:        "pay-2",
@@ -56,8 +56,8 @@ func TestRegisterBooking(t *testing.T) {
 				SeatNo:           61,
 				Status:           model.BookingStatusPending,
 				IdempotencyKey:   "key-3",
-				Country:          "Canada",
-				ZipCode:          "M5H",
+				Country:          "CA",
+				ZipCode:          "M5H 2N2",
 				Currency:         "CAD",
 				TotalAmtInUSCent: 1000,
 				PaymentID:        "pay-3",
@@ -87,6 +87,17 @@ func TestRegisterBooking(t *testing.T) {
 			},
 			expectedError: "invalid seat number",
 		},
+		{
+			name: "seat of another tier",
+			bookingOrder: model.BookingOrder{
+				UserID:         "user-5",
+				Tier:           model.TierGA,
+				SeatNo:         1, // a VIP seat at the GA price
+				IdempotencyKey: "key-5",
+				PaymentStatus:  model.PaymentStatusPending,
+			},
+			expectedError: "seat is not in the tier",
+		},
 		{
 			name: "double booking - seat already booked",
 			bookingOrder: model.BookingOrder{
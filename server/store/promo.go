@@ -0,0 +1,217 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+var (
+	ErrPromoNotFound          = model.NewError(model.CodePromoNotFound, "promo code not found")
+	ErrPromoNotActive         = model.NewError(model.CodePromoNotActive, "promo code is not active")
+	ErrPromoTierNotApplicable = model.NewError(model.CodePromoNotApplicable, "promo code not applicable to tier")
+	ErrPromoUsageLimit        = model.NewError(model.CodePromoUsageLimit, "promo code usage limit reached")
+	ErrPromoUserUsageLimit    = model.NewError(model.CodePromoUsageLimit, "promo code usage limit reached for user")
+)
+
+type PROMO_BUCKET struct {
+	PROMO_STORE map[string]*promoEntry // normalized code -> promo entry
+
+	// Protects the PROMO_STORE map structure from concurrent access
+	mapMu sync.RWMutex
+}
+
+// promoEntry keeps a promo code with its redemption counters.
+// The entry mutex makes check-and-increment atomic per code.
+type promoEntry struct {
+	mu         sync.Mutex
+	promo      model.PromoCode
+	uses       uint32
+	usesByUser map[string]uint32
+}
+
+type PromoStore interface {
+	RegisterPromo(promo model.PromoCode) error
+	RedeemPromo(code, userID string, tier model.Tier, amountInUSCent uint64, now time.Time) (uint64, error)
+	PreviewPromo(code, userID string, tier model.Tier, amountInUSCent uint64, now time.Time) (uint64, error)
+	ReleasePromo(code, userID string)
+	GetPromoUsage(code string) (uint32, error)
+}
+
+func NewPromoBucket() PromoStore {
+	return &PROMO_BUCKET{
+		PROMO_STORE: make(map[string]*promoEntry),
+	}
+}
+
+// RegisterPromo adds or replaces a promo code definition. Replacing keeps existing usage counters.
+func (pb *PROMO_BUCKET) RegisterPromo(promo model.PromoCode) error {
+	promo.Code = model.NormalizePromoCode(promo.Code)
+	if promo.Code == "" {
+		return errors.New("promo code is required")
+	}
+	if !promo.DiscountType.IsValidDiscountType() {
+		return errors.New("invalid discount type")
+	}
+	if promo.DiscountType == model.DiscountTypePercent && (promo.PercentOff == 0 || promo.PercentOff > 100) {
+		return errors.New("percent_off must be between 1 and 100")
+	}
+	if promo.DiscountType == model.DiscountTypeFixed && promo.AmountOffInUSCent == 0 {
+		return errors.New("amount_off must be greater than 0")
+	}
+	if !promo.ValidFrom.IsZero() && !promo.ValidUntil.IsZero() && !promo.ValidUntil.After(promo.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+
+	pb.mapMu.Lock()
+	defer pb.mapMu.Unlock()
+
+	if entry, exists := pb.PROMO_STORE[promo.Code]; exists {
+		entry.mu.Lock()
+		entry.promo = promo
+		entry.mu.Unlock()
+		return nil
+	}
+
+	pb.PROMO_STORE[promo.Code] = &promoEntry{
+		promo:      promo,
+		usesByUser: make(map[string]uint32),
+	}
+	return nil
+}
+
+func (pb *PROMO_BUCKET) getEntry(code string) (*promoEntry, bool) {
+	pb.mapMu.RLock()
+	defer pb.mapMu.RUnlock()
+
+	entry, exists := pb.PROMO_STORE[model.NormalizePromoCode(code)]
+	return entry, exists
+}
+
+// RedeemPromo validates the code for this user and tier, counts one redemption
+// and returns the discount in cents for the given amount.
+func (pb *PROMO_BUCKET) RedeemPromo(
+	code, userID string,
+	tier model.Tier,
+	amountInUSCent uint64,
+	now time.Time,
+) (uint64, error) {
+
+	entry, exists := pb.getEntry(code)
+	if !exists {
+		return 0, ErrPromoNotFound
+	}
+
+	// acquire promo-level lock
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	// ---- CRITICAL SECTION (promo-scoped) ----
+
+	if err := entry.checkRedeemable(userID, tier, now); err != nil {
+		return 0, err
+	}
+
+	entry.uses++
+	entry.usesByUser[userID]++
+
+	return entry.promo.DiscountFor(amountInUSCent), nil
+}
+
+// PreviewPromo is RedeemPromo without counting a redemption, for quoting.
+func (pb *PROMO_BUCKET) PreviewPromo(
+	code, userID string,
+	tier model.Tier,
+	amountInUSCent uint64,
+	now time.Time,
+) (uint64, error) {
+
+	entry, exists := pb.getEntry(code)
+	if !exists {
+		return 0, ErrPromoNotFound
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if err := entry.checkRedeemable(userID, tier, now); err != nil {
+		return 0, err
+	}
+	return entry.promo.DiscountFor(amountInUSCent), nil
+}
+
+// checkRedeemable applies the promo rules. Caller holds entry.mu.
+func (entry *promoEntry) checkRedeemable(userID string, tier model.Tier, now time.Time) error {
+	if !entry.promo.IsActiveAt(now) {
+		return ErrPromoNotActive
+	}
+	if !entry.promo.AppliesToTier(tier) {
+		return ErrPromoTierNotApplicable
+	}
+	if entry.promo.MaxUses > 0 && entry.uses >= entry.promo.MaxUses {
+		return ErrPromoUsageLimit
+	}
+	if entry.promo.MaxUsesPerUser > 0 && entry.usesByUser[userID] >= entry.promo.MaxUsesPerUser {
+		return ErrPromoUserUsageLimit
+	}
+	return nil
+}
+
+// ReleasePromo gives back a redemption when the booking it was counted for did not go through.
+func (pb *PROMO_BUCKET) ReleasePromo(code, userID string) {
+	entry, exists := pb.getEntry(code)
+	if !exists {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.uses > 0 {
+		entry.uses--
+	}
+	if entry.usesByUser[userID] > 0 {
+		entry.usesByUser[userID]--
+	}
+	if entry.usesByUser[userID] == 0 {
+		delete(entry.usesByUser, userID)
+	}
+}
+
+// GetPromoUsage returns how many times the code has been redeemed.
+func (pb *PROMO_BUCKET) GetPromoUsage(code string) (uint32, error) {
+	entry, exists := pb.getEntry(code)
+	if !exists {
+		return 0, ErrPromoNotFound
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	return entry.uses, nil
+}
+
+// LoadPromoCodes registers every promo code found in a JSON array file.
+func LoadPromoCodes(ps PromoStore, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var promos []model.PromoCode
+	if err := json.Unmarshal(data, &promos); err != nil {
+		return fmt.Errorf("parse promo codes: %w", err)
+	}
+
+	for _, promo := range promos {
+		if err := ps.RegisterPromo(promo); err != nil {
+			return fmt.Errorf("promo code %q: %w", promo.Code, err)
+		}
+	}
+	return nil
+}
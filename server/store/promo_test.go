@@ -0,0 +1,231 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestRedeemPromo(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		promo            model.PromoCode
+		code             string
+		userID           string
+		tier             model.Tier
+		amount           uint64
+		setupFunc        func(*PROMO_BUCKET)
+		expectedDiscount uint64
+		expectedError    error
+	}{
+		{
+			name:             "percent discount",
+			promo:            model.PromoCode{Code: "SAVE10", DiscountType: model.DiscountTypePercent, PercentOff: 10},
+			code:             "SAVE10",
+			userID:           "user-1",
+			tier:             model.TierVIP,
+			amount:           10000,
+			expectedDiscount: 1000,
+		},
+		{
+			name:             "fixed discount",
+			promo:            model.PromoCode{Code: "FIVEOFF", DiscountType: model.DiscountTypeFixed, AmountOffInUSCent: 500},
+			code:             "FIVEOFF",
+			userID:           "user-1",
+			tier:             model.TierGA,
+			amount:           1000,
+			expectedDiscount: 500,
+		},
+		{
+			name:             "fixed discount capped at amount",
+			promo:            model.PromoCode{Code: "BIGOFF", DiscountType: model.DiscountTypeFixed, AmountOffInUSCent: 5000},
+			code:             "BIGOFF",
+			userID:           "user-1",
+			tier:             model.TierGA,
+			amount:           1000,
+			expectedDiscount: 1000,
+		},
+		{
+			name:             "code is case-insensitive",
+			promo:            model.PromoCode{Code: "presale", DiscountType: model.DiscountTypePercent, PercentOff: 50},
+			code:             " PreSale ",
+			userID:           "user-1",
+			tier:             model.TierFrontRow,
+			amount:           5000,
+			expectedDiscount: 2500,
+		},
+		{
+			name:          "unknown code",
+			promo:         model.PromoCode{Code: "SAVE10", DiscountType: model.DiscountTypePercent, PercentOff: 10},
+			code:          "NOPE",
+			userID:        "user-1",
+			tier:          model.TierVIP,
+			amount:        10000,
+			expectedError: ErrPromoNotFound,
+		},
+		{
+			name:          "tier restriction",
+			promo:         model.PromoCode{Code: "VIPONLY", DiscountType: model.DiscountTypePercent, PercentOff: 10, Tiers: []model.Tier{model.TierVIP}},
+			code:          "VIPONLY",
+			userID:        "user-1",
+			tier:          model.TierGA,
+			amount:        1000,
+			expectedError: ErrPromoTierNotApplicable,
+		},
+		{
+			name:          "not yet valid",
+			promo:         model.PromoCode{Code: "LATER", DiscountType: model.DiscountTypePercent, PercentOff: 10, ValidFrom: now.Add(time.Hour)},
+			code:          "LATER",
+			userID:        "user-1",
+			tier:          model.TierVIP,
+			amount:        10000,
+			expectedError: ErrPromoNotActive,
+		},
+		{
+			name:          "expired",
+			promo:         model.PromoCode{Code: "OLD", DiscountType: model.DiscountTypePercent, PercentOff: 10, ValidUntil: now},
+			code:          "OLD",
+			userID:        "user-1",
+			tier:          model.TierVIP,
+			amount:        10000,
+			expectedError: ErrPromoNotActive,
+		},
+		{
+			name:   "global usage cap reached",
+			promo:  model.PromoCode{Code: "ONCE", DiscountType: model.DiscountTypePercent, PercentOff: 10, MaxUses: 1},
+			code:   "ONCE",
+			userID: "user-2",
+			tier:   model.TierVIP,
+			amount: 10000,
+			setupFunc: func(pb *PROMO_BUCKET) {
+				pb.RedeemPromo("ONCE", "user-1", model.TierVIP, 10000, now)
+			},
+			expectedError: ErrPromoUsageLimit,
+		},
+		{
+			name:   "per-user usage cap reached",
+			promo:  model.PromoCode{Code: "PERUSER", DiscountType: model.DiscountTypePercent, PercentOff: 10, MaxUsesPerUser: 1},
+			code:   "PERUSER",
+			userID: "user-1",
+			tier:   model.TierVIP,
+			amount: 10000,
+			setupFunc: func(pb *PROMO_BUCKET) {
+				pb.RedeemPromo("PERUSER", "user-1", model.TierVIP, 10000, now)
+			},
+			expectedError: ErrPromoUserUsageLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := NewPromoBucket().(*PROMO_BUCKET)
+			if err := pb.RegisterPromo(tt.promo); err != nil {
+				t.Fatalf("Failed to register promo: %v", err)
+			}
+			if tt.setupFunc != nil {
+				tt.setupFunc(pb)
+			}
+
+			discount, err := pb.RedeemPromo(tt.code, tt.userID, tt.tier, tt.amount, now)
+
+			if tt.expectedError != nil {
+				if !errors.Is(err, tt.expectedError) {
+					t.Errorf("Expected error '%v', got '%v'", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got '%s'", err.Error())
+			}
+			if discount != tt.expectedDiscount {
+				t.Errorf("Expected discount %d, got %d", tt.expectedDiscount, discount)
+			}
+		})
+	}
+}
+
+func TestRegisterPromo_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		promo model.PromoCode
+	}{
+		{name: "missing code", promo: model.PromoCode{DiscountType: model.DiscountTypePercent, PercentOff: 10}},
+		{name: "invalid discount type", promo: model.PromoCode{Code: "X", DiscountType: "BOGO"}},
+		{name: "percent over 100", promo: model.PromoCode{Code: "X", DiscountType: model.DiscountTypePercent, PercentOff: 101}},
+		{name: "fixed zero", promo: model.PromoCode{Code: "X", DiscountType: model.DiscountTypeFixed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := NewPromoBucket()
+			if err := pb.RegisterPromo(tt.promo); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRedeemPromo_Concurrency(t *testing.T) {
+	pb := NewPromoBucket().(*PROMO_BUCKET)
+	pb.RegisterPromo(model.PromoCode{
+		Code:         "CAPPED",
+		DiscountType: model.DiscountTypePercent,
+		PercentOff:   10,
+		MaxUses:      100,
+	})
+
+	numGoroutines := 101
+	var wg sync.WaitGroup
+	errs := make([]error, numGoroutines)
+
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(idx int) {
+			defer wg.Done()
+			_, errs[idx] = pb.RedeemPromo("CAPPED", "user-concurrent", model.TierVIP, 10000, time.Now())
+		}(i)
+	}
+	wg.Wait()
+
+	successCount := 0
+	for _, err := range errs {
+		if err == nil {
+			successCount++
+		} else if !errors.Is(err, ErrPromoUsageLimit) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if successCount != 100 {
+		t.Errorf("Expected exactly 100 redemptions, got %d", successCount)
+	}
+
+	uses, _ := pb.GetPromoUsage("CAPPED")
+	if uses != 100 {
+		t.Errorf("Expected usage 100, got %d", uses)
+	}
+}
+
+func TestReleasePromo(t *testing.T) {
+	pb := NewPromoBucket().(*PROMO_BUCKET)
+	pb.RegisterPromo(model.PromoCode{
+		Code:              "ONCE",
+		DiscountType:      model.DiscountTypeFixed,
+		AmountOffInUSCent: 100,
+		MaxUses:           1,
+		MaxUsesPerUser:    1,
+	})
+
+	if _, err := pb.RedeemPromo("ONCE", "user-1", model.TierGA, 1000, time.Now()); err != nil {
+		t.Fatalf("Expected first redemption to succeed, got %v", err)
+	}
+	pb.ReleasePromo("ONCE", "user-1")
+
+	if _, err := pb.RedeemPromo("ONCE", "user-1", model.TierGA, 1000, time.Now()); err != nil {
+		t.Errorf("Expected redemption after release to succeed, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+const (
+	// events kept for Last-Event-ID resumption
+	seatFeedHistory = 4096
+
+	// events buffered per subscriber before it is dropped as too slow
+	seatFeedBuffer = 256
+)
+
+/*
+* SEAT_FEED fans seat events out to live subscribers (SSE viewers).
+  - event IDs are "<epoch>-<seq>"; the epoch changes on restart, so IDs from
+    an earlier process are never mistaken for current ones
+  - the last seatFeedHistory events are kept so reconnecting clients can resume
+  - a subscriber whose buffer fills up is dropped; it reconnects and resumes
+*/
+type SEAT_FEED_BUCKET struct {
+	epoch string
+
+	mu          sync.Mutex
+	seq         uint64
+	history     []model.SeatEvent // ring buffer, oldest at historyHead once full
+	historyHead int
+	subscribers map[chan model.SeatEvent]struct{}
+}
+
+type SeatFeed interface {
+	Publish(event model.SeatEvent)
+
+	// Subscribe streams events published after lastEventID. It returns the events
+	// to replay first and whether lastEventID could be resumed; when it can't
+	// (empty, unknown or too old) the caller should send a full snapshot.
+	// cursor is the ID of the latest event at subscription time.
+	Subscribe(lastEventID string) (replay []model.SeatEvent, resumed bool, cursor string, events <-chan model.SeatEvent, cancel func())
+}
+
+func NewSeatFeedBucket() SeatFeed {
+	return &SEAT_FEED_BUCKET{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		history:     make([]model.SeatEvent, 0, seatFeedHistory),
+		subscribers: make(map[chan model.SeatEvent]struct{}),
+	}
+}
+
+// Publish stamps the event with the next ID and hands it to every subscriber without blocking.
+func (f *SEAT_FEED_BUCKET) Publish(event model.SeatEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.seq++
+	event.ID = f.eventID(f.seq)
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	if len(f.history) < seatFeedHistory {
+		f.history = append(f.history, event)
+	} else {
+		f.history[f.historyHead] = event
+		f.historyHead = (f.historyHead + 1) % seatFeedHistory
+	}
+
+	for subscriber := range f.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			// too slow; closing makes it reconnect and resume from history
+			delete(f.subscribers, subscriber)
+			close(subscriber)
+		}
+	}
+}
+
+func (f *SEAT_FEED_BUCKET) Subscribe(lastEventID string) ([]model.SeatEvent, bool, string, <-chan model.SeatEvent, func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	replay, resumed := f.since(lastEventID)
+
+	events := make(chan model.SeatEvent, seatFeedBuffer)
+	f.subscribers[events] = struct{}{}
+
+	cancel := func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, subscribed := f.subscribers[events]; subscribed {
+			delete(f.subscribers, events)
+			close(events)
+		}
+	}
+	return replay, resumed, f.eventID(f.seq), events, cancel
+}
+
+// since returns the kept events after lastEventID. Callers hold mu.
+func (f *SEAT_FEED_BUCKET) since(lastEventID string) ([]model.SeatEvent, bool) {
+	epoch, seqText, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != f.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > f.seq {
+		return nil, false
+	}
+
+	oldest := f.seq - uint64(len(f.history)) // seq of the newest event no longer kept
+	if seq < oldest {
+		return nil, false
+	}
+
+	replay := make([]model.SeatEvent, 0, f.seq-seq)
+	for i := seq - oldest; i < uint64(len(f.history)); i++ {
+		replay = append(replay, f.history[(f.historyHead+int(i))%len(f.history)])
+	}
+	return replay, true
+}
+
+func (f *SEAT_FEED_BUCKET) eventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", f.epoch, seq)
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestSeatFeedSubscribe(t *testing.T) {
+	feed := NewSeatFeedBucket().(*SEAT_FEED_BUCKET)
+	for seat := uint32(1); seat <= 3; seat++ {
+		feed.Publish(model.SeatEvent{SeatNo: seat, Tier: model.TierVIP, State: model.SeatStateBooked})
+	}
+	first := feed.history[0].ID
+
+	tests := []struct {
+		name            string
+		lastEventID     string
+		expectedResumed bool
+		expectedSeats   []uint32
+	}{
+		{name: "no last event", lastEventID: "", expectedResumed: false},
+		{name: "resume after the first event", lastEventID: first, expectedResumed: true, expectedSeats: []uint32{2, 3}},
+		{name: "resume at the latest event", lastEventID: feed.eventID(3), expectedResumed: true},
+		{name: "resume from before the first event", lastEventID: feed.eventID(0), expectedResumed: true, expectedSeats: []uint32{1, 2, 3}},
+		{name: "event from a previous process", lastEventID: "0-2", expectedResumed: false},
+		{name: "event not published yet", lastEventID: feed.eventID(9), expectedResumed: false},
+		{name: "malformed", lastEventID: "seat-1", expectedResumed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, resumed, cursor, _, cancel := feed.Subscribe(tt.lastEventID)
+			defer cancel()
+
+			if resumed != tt.expectedResumed {
+				t.Errorf("Expected resumed %v, got %v", tt.expectedResumed, resumed)
+			}
+			if cursor != feed.eventID(3) {
+				t.Errorf("Expected cursor %s, got %s", feed.eventID(3), cursor)
+			}
+			if len(replay) != len(tt.expectedSeats) {
+				t.Fatalf("Expected %d replayed events, got %d", len(tt.expectedSeats), len(replay))
+			}
+			for i, event := range replay {
+				if event.SeatNo != tt.expectedSeats[i] {
+					t.Errorf("Expected replayed seat %d, got %d", tt.expectedSeats[i], event.SeatNo)
+				}
+			}
+		})
+	}
+}
+
+func TestSeatFeedHistoryLimit(t *testing.T) {
+	feed := NewSeatFeedBucket().(*SEAT_FEED_BUCKET)
+	for i := 0; i < seatFeedHistory+10; i++ {
+		feed.Publish(model.SeatEvent{SeatNo: uint32(i%100) + 1, State: model.SeatStateBooked})
+	}
+
+	if _, resumed, _, _, cancel := feed.Subscribe(feed.eventID(5)); resumed {
+		t.Error("Expected an event older than the history not to resume")
+	} else {
+		cancel()
+	}
+
+	replay, resumed, _, _, cancel := feed.Subscribe(feed.eventID(10))
+	defer cancel()
+	if !resumed {
+		t.Fatal("Expected the oldest kept event to resume")
+	}
+	if len(replay) != seatFeedHistory {
+		t.Errorf("Expected %d replayed events, got %d", seatFeedHistory, len(replay))
+	}
+	if replay[len(replay)-1].ID != feed.eventID(seatFeedHistory+10) {
+		t.Errorf("Expected the replay to end at the latest event, got %s", replay[len(replay)-1].ID)
+	}
+}
+
+func TestSeatFeedDropsSlowSubscribers(t *testing.T) {
+	feed := NewSeatFeedBucket()
+	_, _, _, slow, cancel := feed.Subscribe("")
+	defer cancel()
+
+	for i := 0; i <= seatFeedBuffer; i++ {
+		feed.Publish(model.SeatEvent{SeatNo: 1, State: model.SeatStateHeld})
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != seatFeedBuffer {
+		t.Errorf("Expected %d buffered events before the drop, got %d", seatFeedBuffer, received)
+	}
+}
+
+func TestBookingStoreSeatChanges(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+	var events []model.SeatEvent
+	bookingStore.OnSeatChange(func(event model.SeatEvent) { events = append(events, event) })
+
+	booking, err := bookingStore.RegisterBooking(model.BookingOrder{
+		UserID: "user-1", Tier: model.TierGA, SeatNo: 61, IdempotencyKey: "key-1",
+		PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+	})
+	if err != nil {
+		t.Fatalf("Expected the booking to succeed, got %v", err)
+	}
+	bookingStore.CancelBooking(booking.ID)
+	bookingStore.BlockSeat(model.SeatBlock{SeatNo: 62, Tier: model.TierGA, Reason: "camera"})
+	bookingStore.UnblockSeat(62)
+	bookingStore.UnblockSeat(62) // not blocked: no event
+
+	expected := []model.SeatEvent{
+		{SeatNo: 61, Tier: model.TierGA, State: model.SeatStateBooked},
+		{SeatNo: 61, Tier: model.TierGA, State: model.SeatStateReleased},
+		{SeatNo: 62, Tier: model.TierGA, State: model.SeatStateHeld},
+		{SeatNo: 62, Tier: model.TierGA, State: model.SeatStateReleased},
+	}
+	if bookingStore.Version() != uint64(len(expected)) {
+		t.Errorf("Expected inventory version %d, got %d", len(expected), bookingStore.Version())
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d seat events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, event := range events {
+		if event.SeatNo != expected[i].SeatNo || event.Tier != expected[i].Tier || event.State != expected[i].State {
+			t.Errorf("Expected event %d to be %v, got %v", i, expected[i], event)
+		}
+	}
+}
+
+func TestBookingStoreSeatChanges_ToldOutsideTheSeatLock(t *testing.T) {
+	bookingStore := NewBookingStoreBucket()
+	entered := make(chan struct{})
+	proceed := make(chan struct{})
+	var mu sync.Mutex
+	var events []model.SeatEvent
+	bookingStore.OnSeatChange(func(event model.SeatEvent) {
+		if event.SeatNo == 61 && event.State == model.SeatStateBooked {
+			close(entered)
+			<-proceed
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+
+	booked := make(chan struct{})
+	go func() {
+		defer close(booked)
+		bookingStore.RegisterBooking(model.BookingOrder{
+			UserID: "user-1", Tier: model.TierGA, SeatNo: 61, IdempotencyKey: "key-1",
+			PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed,
+		})
+	}()
+	<-entered
+
+	// the seat is unlocked while its listener is still busy
+	booking, err := bookingStore.GetBooking(61)
+	if err != nil {
+		t.Fatalf("Expected to read seat 61 while its change is told, got %v", err)
+	}
+	canceled := make(chan struct{})
+	go func() {
+		defer close(canceled)
+		bookingStore.CancelBooking(booking.ID)
+	}()
+
+	// another seat doesn't wait for it either
+	if _, err := bookingStore.HoldSeat(62, "user-2", time.Minute); err != nil {
+		t.Fatalf("Expected to hold seat 62 while seat 61's change is told, got %v", err)
+	}
+
+	close(proceed)
+	<-booked
+	<-canceled
+
+	var seat61 []model.SeatState
+	for _, event := range events {
+		if event.SeatNo == 61 {
+			seat61 = append(seat61, event.State)
+		}
+	}
+	if !slices.Equal(seat61, []model.SeatState{model.SeatStateBooked, model.SeatStateReleased}) {
+		t.Errorf("Expected seat 61 BOOKED then RELEASED, got %v", seat61)
+	}
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"math/bits"
+	"sync/atomic"
+)
+
+// seatSet is a bitset of seat numbers: bit n%64 of word n/64 is seat n. Bits
+// are set and cleared atomically, so seats sharing a word never contend on a
+// lock, and readers take no lock at all.
+type seatSet []atomic.Uint64
+
+func newSeatSet(maxSeat uint32) seatSet {
+	return make(seatSet, maxSeat/64+1)
+}
+
+func (s seatSet) add(seatNo uint32) {
+	s[seatNo/64].Or(1 << (seatNo % 64))
+}
+
+func (s seatSet) remove(seatNo uint32) {
+	s[seatNo/64].And(^uint64(1 << (seatNo % 64)))
+}
+
+func (s seatSet) has(seatNo uint32) bool {
+	return s[seatNo/64].Load()&(1<<(seatNo%64)) != 0
+}
+
+// appendRange appends the seats of min..max in the set, in order. It reads a
+// word at a time, so its cost is the range's words plus the seats it finds.
+func (s seatSet) appendRange(seats []uint32, min, max uint32) []uint32 {
+	for word := min / 64; word <= max/64; word++ {
+		bitset := s[word].Load()
+
+		// mask off the seats outside the range
+		if word == min/64 {
+			bitset &= ^uint64(0) << (min % 64)
+		}
+		if word == max/64 && max%64 < 63 {
+			bitset &= 1<<(max%64+1) - 1
+		}
+
+		for bitset != 0 {
+			bit := uint32(bits.TrailingZeros64(bitset))
+			seats = append(seats, word*64+bit)
+			bitset &= bitset - 1
+		}
+	}
+	return seats
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+/*
+* Snapshot is the seat inventory the file storage backend saves.
+  - Bookings lists every booking, canceled ones included, oldest first
+  - Seated lists the bookings still holding their seat; a canceled booking
+    gives its seat up
+  - seat holds live for minutes and are not saved, nor are idempotency
+    records and promo redemption counts
+*/
+type Snapshot struct {
+	Bookings []model.Booking   `json:"bookings"`
+	Seated   []uuid.UUID       `json:"seated"`
+	Blocks   []model.SeatBlock `json:"blocks"`
+}
+
+// Snapshot copies the bookings and blocked seats. Seats changing meanwhile may
+// or may not be in it; FileStorage saves again after every change.
+func (b *BOOKING_STORE_BUCKET) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Bookings: b.ListBookings(model.BookingFilter{}),
+		Seated:   make([]uuid.UUID, 0),
+		Blocks:   make([]model.SeatBlock, 0),
+	}
+	for _, seatNo := range b.TAKEN.appendRange(nil, 1, b.TOTAL_SEAT) {
+		slot := b.lockSeat(seatNo)
+		if slot.booking != nil {
+			snapshot.Seated = append(snapshot.Seated, slot.booking.ID)
+		}
+		if slot.block != nil {
+			snapshot.Blocks = append(snapshot.Blocks, *slot.block)
+		}
+		slot.mu.Unlock()
+	}
+	return snapshot
+}
+
+// Restore loads a snapshot into an empty store, keeping booking IDs and times.
+func (b *BOOKING_STORE_BUCKET) Restore(snapshot Snapshot) error {
+	seated := make(map[uuid.UUID]bool, len(snapshot.Seated))
+	for _, id := range snapshot.Seated {
+		seated[id] = true
+	}
+
+	for _, booking := range snapshot.Bookings {
+		if booking.SeatNo == 0 || booking.SeatNo > b.TOTAL_SEAT {
+			return fmt.Errorf("booking %s: %w", booking.ID, ErrInvalidSeatNumber)
+		}
+		if _, exists := b.BOOKING_INDEX.load(booking.ID); exists {
+			return fmt.Errorf("booking %s: duplicate", booking.ID)
+		}
+
+		if seated[booking.ID] {
+			if err := b.restoreSeat(booking.SeatNo, func(slot *seatSlot) { slot.booking = &booking }); err != nil {
+				return fmt.Errorf("booking %s: %w", booking.ID, err)
+			}
+			delete(seated, booking.ID)
+		}
+		b.BOOKING_INDEX.store(&booking)
+		b.userBookings(booking.UserID).append(booking.ID)
+	}
+	for id := range seated {
+		return fmt.Errorf("seated booking %s: %w", id, ErrBookingNotFound)
+	}
+
+	for _, block := range snapshot.Blocks {
+		if _, valid := model.TierForSeat(block.SeatNo); !valid || block.SeatNo > b.TOTAL_SEAT {
+			return fmt.Errorf("blocked seat %d: %w", block.SeatNo, ErrInvalidSeatNumber)
+		}
+		if err := b.restoreSeat(block.SeatNo, func(slot *seatSlot) { slot.block = &block }); err != nil {
+			return fmt.Errorf("blocked seat %d: %w", block.SeatNo, err)
+		}
+	}
+	return nil
+}
+
+// restoreSeat fills a free seat.
+func (b *BOOKING_STORE_BUCKET) restoreSeat(seatNo uint32, fill func(slot *seatSlot)) error {
+	slot := b.lockSeat(seatNo)
+	defer slot.mu.Unlock()
+
+	if slot.booking != nil || slot.block != nil {
+		return ErrSeatAlreadyBooked
+	}
+	fill(slot)
+	b.syncTaken(seatNo, slot)
+	b.version.Add(1)
+	return nil
+}
+
+// LoadSnapshot reads a snapshot file. A missing file is an empty snapshot.
+func LoadSnapshot(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, nil
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("parse snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// SaveSnapshot writes a snapshot file. It writes a temporary file next to it
+// and renames it into place, so a crash never leaves a half-written snapshot.
+func SaveSnapshot(path string, snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+/*
+* FileStorage keeps a booking store saved to a snapshot file (storage backend "file").
+  - opening it restores the file's snapshot into the store
+  - every seat change marks the store dirty; a goroutine saves it, so changes
+    made while a save runs are saved together by the next one
+  - Close stops the goroutine and saves a last time
+*/
+type FileStorage struct {
+	bookingStore BookingStore
+	path         string
+
+	dirty chan struct{}
+	stop  chan struct{}
+	done  sync.WaitGroup
+
+	errMu   sync.Mutex
+	lastErr error // of the last save
+}
+
+// OpenFileStorage restores path's snapshot into an empty bookingStore and keeps it saved there.
+func OpenFileStorage(bookingStore BookingStore, path string) (*FileStorage, error) {
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := bookingStore.Restore(snapshot); err != nil {
+		return nil, fmt.Errorf("restore snapshot: %w", err)
+	}
+
+	storage := &FileStorage{
+		bookingStore: bookingStore,
+		path:         path,
+		dirty:        make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+	bookingStore.OnSeatChange(func(model.SeatEvent) {
+		select {
+		case storage.dirty <- struct{}{}:
+		default: // a save is pending already
+		}
+	})
+
+	// fail now, not on the first booking, when the file can't be written
+	if err := storage.Save(); err != nil {
+		return nil, err
+	}
+
+	storage.done.Add(1)
+	go storage.run()
+	return storage, nil
+}
+
+func (s *FileStorage) run() {
+	defer s.done.Done()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.dirty:
+			if err := s.Save(); err != nil {
+				slog.Error("failed to save snapshot", "path", s.path, "err", err)
+			}
+		}
+	}
+}
+
+// Save writes the store's current snapshot.
+func (s *FileStorage) Save() error {
+	err := SaveSnapshot(s.path, s.bookingStore.Snapshot())
+
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.lastErr = err
+	return err
+}
+
+// Err returns the error of the last save, nil once a save succeeds again.
+func (s *FileStorage) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.lastErr
+}
+
+// Close stops saving on change and saves a last time.
+func (s *FileStorage) Close() error {
+	close(s.stop)
+	s.done.Wait()
+	return s.Save()
+}
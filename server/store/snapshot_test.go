@@ -0,0 +1,186 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// inventoryStore books seat 1, books and cancels seat 2, books seat 2 again and blocks seat 61.
+func inventoryStore(t *testing.T) BookingStore {
+	t.Helper()
+	bookingStore := NewBookingStoreBucket()
+
+	for _, order := range []model.BookingOrder{
+		{UserID: "user-1", Tier: model.TierVIP, SeatNo: 1, IdempotencyKey: "key-1", PaymentID: "pay-1", PaymentStatus: model.PaymentStatusConfirmed},
+		{UserID: "user-1", Tier: model.TierVIP, SeatNo: 2, IdempotencyKey: "key-2"},
+	} {
+		if _, err := bookingStore.RegisterBooking(order); err != nil {
+			t.Fatal(err)
+		}
+	}
+	canceled, _ := bookingStore.GetBooking(2)
+	if _, err := bookingStore.CancelBooking(canceled.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-2", Tier: model.TierVIP, SeatNo: 2, IdempotencyKey: "key-3"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bookingStore.BlockSeat(model.SeatBlock{SeatNo: 61, Reason: "camera"}); err != nil {
+		t.Fatal(err)
+	}
+	return bookingStore
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	original := inventoryStore(t)
+	path := filepath.Join(t.TempDir(), "bookings.json")
+	if err := SaveSnapshot(path, original.Snapshot()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	snapshot, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	restored := NewBookingStoreBucket()
+	if err := restored.Restore(snapshot); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantReserved := original.GetReservedSeats()
+	gotReserved := restored.GetReservedSeats()
+	for _, tier := range model.AllTiers() {
+		if len(gotReserved[string(tier)]) != len(wantReserved[string(tier)]) {
+			t.Errorf("%s: Expected reserved seats %v, got %v", tier, wantReserved[string(tier)], gotReserved[string(tier)])
+		}
+	}
+
+	all := original.ListBookings(model.BookingFilter{})
+	if got := restored.ListBookings(model.BookingFilter{}); len(got) != len(all) {
+		t.Fatalf("Expected %d bookings, got %d", len(all), len(got))
+	}
+	for _, booking := range all {
+		got, err := restored.GetBookingByID(booking.ID)
+		if err != nil || got.Status != booking.Status || !got.CreatedAt.Equal(booking.CreatedAt) {
+			t.Errorf("Expected booking %s restored as %s, got %+v (%v)", booking.ID, booking.Status, got, err)
+		}
+	}
+
+	seat2, _ := restored.GetBooking(2)
+	if seat2.UserID != "user-2" {
+		t.Errorf("Expected seat 2 held by the rebooking, got %s", seat2.UserID)
+	}
+	if blocks := restored.GetBlockedSeats(); len(blocks) != 1 || blocks[0].Reason != "camera" {
+		t.Errorf("Expected seat 61 blocked, got %+v", blocks)
+	}
+	if bookings, _, _ := restored.ListUserBookings(model.BookingFilter{UserID: "user-1"}, "", 10); len(bookings) != 2 {
+		t.Errorf("Expected 2 bookings of user-1, got %d", len(bookings))
+	}
+
+	// the restored store keeps working: taken seats stay taken
+	if _, err := restored.RegisterBooking(model.BookingOrder{UserID: "user-3", Tier: model.TierVIP, SeatNo: 1, IdempotencyKey: "key-4"}); !errors.Is(err, ErrSeatAlreadyBooked) {
+		t.Errorf("Expected %v, got %v", ErrSeatAlreadyBooked, err)
+	}
+	if restored.Version() == 0 {
+		t.Errorf("Expected the restore to move the inventory version")
+	}
+}
+
+func TestRestore_Invalid(t *testing.T) {
+	booking := model.Booking{ID: uuid.New(), UserID: "user-1", Tier: model.TierVIP, SeatNo: 1}
+	other := model.Booking{ID: uuid.New(), UserID: "user-2", Tier: model.TierVIP, SeatNo: 1}
+
+	tests := []struct {
+		name     string
+		snapshot Snapshot
+		wantErr  error
+	}{
+		{"seat out of range", Snapshot{Bookings: []model.Booking{{ID: uuid.New(), SeatNo: 101}}}, ErrInvalidSeatNumber},
+		{"two bookings on a seat", Snapshot{Bookings: []model.Booking{booking, other}, Seated: []uuid.UUID{booking.ID, other.ID}}, ErrSeatAlreadyBooked},
+		{"seated booking missing", Snapshot{Seated: []uuid.UUID{booking.ID}}, ErrBookingNotFound},
+		{"booked seat blocked", Snapshot{Bookings: []model.Booking{booking}, Seated: []uuid.UUID{booking.ID}, Blocks: []model.SeatBlock{{SeatNo: 1}}}, ErrSeatAlreadyBooked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := NewBookingStoreBucket().Restore(tt.snapshot); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadSnapshot_MissingFile(t *testing.T) {
+	snapshot, err := LoadSnapshot(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(snapshot.Bookings) != 0 {
+		t.Errorf("Expected an empty snapshot, got %d bookings", len(snapshot.Bookings))
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.json")
+
+	bookingStore := NewBookingStoreBucket()
+	storage, err := OpenFileStorage(bookingStore, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("Expected the snapshot written on open, got %v", err)
+	}
+
+	booking, err := bookingStore.RegisterBooking(model.BookingOrder{UserID: "user-1", Tier: model.TierGA, SeatNo: 70, IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// saved in the background soon after the change
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		snapshot, _ := LoadSnapshot(path)
+		if len(snapshot.Seated) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the booking saved, got %+v", snapshot)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	bookingStore.BlockSeat(model.SeatBlock{SeatNo: 71})
+	if err := storage.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// a restart picks up where the last run stopped
+	reopened := NewBookingStoreBucket()
+	reopenedStorage, err := OpenFileStorage(reopened, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { reopenedStorage.Close() })
+	if got, err := reopened.GetBooking(70); err != nil || got.ID != booking.ID {
+		t.Errorf("Expected booking %s on seat 70, got %+v (%v)", booking.ID, got, err)
+	}
+	if blocks := reopened.GetBlockedSeats(); len(blocks) != 1 {
+		t.Errorf("Expected seat 71 blocked, got %+v", blocks)
+	}
+}
+
+func TestFileStorage_CorruptSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.json")
+	os.WriteFile(path, []byte("{not json"), 0o600)
+
+	if _, err := OpenFileStorage(NewBookingStoreBucket(), path); err == nil {
+		t.Errorf("Expected a corrupt snapshot to fail")
+	}
+}
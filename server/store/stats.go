@@ -0,0 +1,37 @@
+package store
+
+import "github.com/ignius299792458/techkraft-ch-svr/model"
+
+// Stats counts the bookings and every tier's seats by state. Free seats aren't
+// locked, so the counts are exact per seat but not a snapshot of the whole store.
+func (b *BOOKING_STORE_BUCKET) Stats() model.StoreStats {
+	stats := model.StoreStats{
+		Bookings: b.BOOKING_INDEX.len(),
+		Version:  b.Version(),
+		Seats:    make(map[model.Tier]model.TierSeatStats),
+	}
+
+	for _, tier := range model.AllTiers() {
+		seatRange := tier.SeatRange()
+		tierStats := model.TierSeatStats{Total: seatRange.Max - seatRange.Min + 1}
+
+		for _, seatNo := range b.TAKEN.appendRange(nil, seatRange.Min, seatRange.Max) {
+			slot := b.lockSeat(seatNo)
+			switch {
+			case slot.booking != nil:
+				tierStats.Booked++
+			case slot.block != nil:
+				tierStats.Blocked++
+			default:
+				if _, held := activeHold(slot); held {
+					tierStats.Held++
+				}
+			}
+			slot.mu.Unlock()
+		}
+
+		tierStats.Available = tierStats.Total - tierStats.Booked - tierStats.Blocked - tierStats.Held
+		stats.Seats[tier] = tierStats
+	}
+	return stats
+}
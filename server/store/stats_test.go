@@ -0,0 +1,34 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestStats(t *testing.T) {
+	bookingStore := inventoryStore(t)
+	if _, err := bookingStore.HoldSeat(62, "shopper-1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := bookingStore.Stats()
+	if stats.Bookings != 3 {
+		t.Errorf("Expected 3 bookings, canceled one included, got %d", stats.Bookings)
+	}
+	if stats.Version != bookingStore.Version() {
+		t.Errorf("Expected version %d, got %d", bookingStore.Version(), stats.Version)
+	}
+
+	expected := map[model.Tier]model.TierSeatStats{
+		model.TierVIP:      {Total: 30, Booked: 2, Available: 28},
+		model.TierFrontRow: {Total: 30, Available: 30},
+		model.TierGA:       {Total: 40, Blocked: 1, Held: 1, Available: 38},
+	}
+	for tier, want := range expected {
+		if got := stats.Seats[tier]; got != want {
+			t.Errorf("%s: Expected %+v, got %+v", tier, want, got)
+		}
+	}
+}
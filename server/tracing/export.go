@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// NewOTLPExporter posts spans to an OpenTelemetry collector over OTLP/HTTP,
+// protobuf-encoded. endpoint is the collector's base URL
+// (http://localhost:4318), to which /v1/traces is added, or a full traces URL.
+func NewOTLPExporter(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("otlp endpoint: want http(s)://host[:port], got %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(u.String()),
+		otlptracehttp.WithTimeout(exportTimeout),
+	)
+}
+
+// OpenFileExporter appends spans to the file at path, or writes them to stdout for "-".
+func OpenFileExporter(path string) (sdktrace.SpanExporter, error) {
+	if path == "-" {
+		return NewFileExporter(os.Stdout, nil)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := NewFileExporter(file, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return exporter, nil
+}
+
+// NewFileExporter writes spans to w as JSON lines, one per span, in the
+// OpenTelemetry stdout exporter's format; Shutdown closes closer unless nil.
+func NewFileExporter(w io.Writer, closer io.Closer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, err
+	}
+	return &fileExporter{Exporter: exporter, closer: closer}, nil
+}
+
+type fileExporter struct {
+	*stdouttrace.Exporter
+	closer io.Closer
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	if e.closer != nil {
+		err = errors.Join(err, e.closer.Close())
+	}
+	return err
+}
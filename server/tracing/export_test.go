@@ -0,0 +1,139 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var request coltracepb.ExportTraceServiceRequest
+	var path, contentType string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(body, &request); err != nil {
+			t.Errorf("Expected an OTLP protobuf request, got %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(context.Background(), collector.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider := NewProvider(exporter, 1, "techkraft-ch-svr")
+	_, span := provider.Tracer(scopeName).Start(context.Background(), "store.RegisterBooking")
+	span.SetAttributes(attribute.Int64("booking.seat_no", 12))
+	span.SetStatus(codes.Error, "seat already booked")
+	span.End()
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected the span exported on shutdown, got %v", err)
+	}
+
+	if path != "/v1/traces" || contentType != "application/x-protobuf" {
+		t.Errorf("Expected protobuf posted to /v1/traces, got %s %s", contentType, path)
+	}
+	if len(request.ResourceSpans) != 1 || len(request.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Expected one resource and scope, got %v", request.ResourceSpans)
+	}
+
+	resource := request.ResourceSpans[0].Resource
+	if len(resource.Attributes) != 1 || resource.Attributes[0].Key != "service.name" || resource.Attributes[0].Value.GetStringValue() != "techkraft-ch-svr" {
+		t.Errorf("Expected the service name resource attribute, got %v", resource.Attributes)
+	}
+
+	scope := request.ResourceSpans[0].ScopeSpans[0]
+	if scope.Scope.Name != scopeName || len(scope.Spans) != 1 {
+		t.Fatalf("Expected one span in the server's scope, got %v", scope)
+	}
+	exported := scope.Spans[0]
+	if exported.Name != "store.RegisterBooking" || exported.Status.Message != "seat already booked" {
+		t.Errorf("Expected the span's name and status, got %v", exported)
+	}
+	if len(exported.Attributes) != 1 || exported.Attributes[0].Value.GetIntValue() != 12 {
+		t.Errorf("Expected the seat attribute, got %v", exported.Attributes)
+	}
+}
+
+func TestOTLPExporter_Endpoint(t *testing.T) {
+	if _, err := NewOTLPExporter(context.Background(), "collector:4318"); err == nil {
+		t.Errorf("Expected an endpoint without scheme to be rejected")
+	}
+
+	var path string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	defer collector.Close()
+
+	exporter, err := NewOTLPExporter(context.Background(), collector.URL+"/otlp/v1/traces")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider := NewProvider(exporter, 1, "techkraft-ch-svr")
+	_, span := provider.Tracer(scopeName).Start(context.Background(), "booking.decode")
+	span.End()
+	provider.Shutdown(context.Background())
+
+	if path != "/otlp/v1/traces" {
+		t.Errorf("Expected a full traces URL kept, got %s", path)
+	}
+}
+
+type closeRecorder struct{ closed bool }
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestFileExporter(t *testing.T) {
+	var out bytes.Buffer
+	closer := &closeRecorder{}
+	exporter, err := NewFileExporter(&out, closer)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	provider := NewProvider(exporter, 1, "techkraft-ch-svr")
+	for range 2 {
+		_, span := provider.Tracer(scopeName).Start(context.Background(), "store.RegisterBooking")
+		span.SetAttributes(attribute.String("booking.tier", "VIP"))
+		span.End()
+	}
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected a line per span, got %q", out.String())
+	}
+
+	var span struct {
+		Name       string
+		Attributes []struct {
+			Key   string
+			Value struct{ Value any }
+		}
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &span); err != nil {
+		t.Fatalf("Expected a JSON line, got %v", err)
+	}
+	if span.Name != "store.RegisterBooking" || len(span.Attributes) != 1 || span.Attributes[0].Value.Value != "VIP" {
+		t.Errorf("Expected the span's name and attributes, got %+v", span)
+	}
+	if !closer.closed {
+		t.Errorf("Expected shutdown to close the file")
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+* Middleware starts a server span for every request, from provider.
+  - a request's traceparent and tracestate headers make the span a child of
+    the caller's, so the trace spans both services; its baggage header is
+    kept in the request's context (baggage.FromContext)
+  - the span is named by the route that served the request ("POST
+    /booking/"), which is only known once it's served, like metrics' labels
+  - 5xx responses mark the span failed; a nil provider traces nothing
+*/
+func Middleware(provider *sdktrace.TracerProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if provider == nil {
+			return next
+		}
+		tracer := provider.Tracer(scopeName)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			r, route := utils.TrackRoute(r.WithContext(ctx))
+			recorder := utils.RecordResponse(w)
+
+			next.ServeHTTP(recorder, r)
+
+			status := recorder.Status()
+			if route.Pattern == utils.UnmatchedRoute {
+				span.SetName(route.Method)
+			} else {
+				span.SetName(route.Method + " " + route.Pattern)
+				span.SetAttributes(attribute.String("http.route", route.Pattern))
+			}
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+			}
+		})
+	}
+}
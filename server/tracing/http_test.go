@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ignius299792458/techkraft-ch-svr/utils"
+)
+
+// newTestProvider returns a provider keeping its spans in memory, exported as they end.
+func newTestProvider(t *testing.T, sampleRatio float64) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithSyncer(exporter),
+	)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return provider, exporter
+}
+
+// spansByName returns the ended spans by name.
+func spansByName(exporter *tracetest.InMemoryExporter) map[string]tracetest.SpanStub {
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	return spans
+}
+
+func attributes(kvs []attribute.KeyValue) map[string]any {
+	attrs := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		attrs[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return attrs
+}
+
+func TestMiddleware(t *testing.T) {
+	provider, exporter := newTestProvider(t, 1)
+
+	var handlerSpan trace.SpanContext
+	var handlerBaggage baggage.Baggage
+	mux := http.NewServeMux()
+	mux.Handle("POST /booking/", utils.Route("POST /booking/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		handlerBaggage = baggage.FromContext(r.Context())
+		_, child := Start(r.Context(), "store.RegisterBooking", attribute.Int64("booking.seat_no", 12))
+		child.End()
+		w.WriteHeader(http.StatusInternalServerError)
+	})))
+	handler := Middleware(provider)(mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/booking/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=1")
+	req.Header.Set("baggage", "tenant=acme")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/wp-login.php", nil))
+
+	spans := spansByName(exporter)
+
+	span, ok := spans["POST /booking/"]
+	if !ok {
+		t.Fatalf("Expected a span named by the route, got %v", spans)
+	}
+	if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("Expected the caller's trace, got %s parent %s", span.SpanContext.TraceID(), span.Parent.SpanID())
+	}
+	if span.SpanKind != trace.SpanKindServer {
+		t.Errorf("Expected a server span, got %s", span.SpanKind)
+	}
+	if span.SpanContext.TraceState().String() != "vendor=1" {
+		t.Errorf("Expected the tracestate passed on, got %q", span.SpanContext.TraceState())
+	}
+	if !handlerSpan.Equal(span.SpanContext) {
+		t.Errorf("Expected the handler to see the server span in its context")
+	}
+	if handlerBaggage.Member("tenant").Value() != "acme" {
+		t.Errorf("Expected the caller's baggage in the handler's context, got %q", handlerBaggage)
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected a 5xx to mark the span failed, got %s", span.Status.Code)
+	}
+
+	attrs := attributes(span.Attributes)
+	if attrs["http.route"] != "/booking/" || attrs["http.response.status_code"] != int64(500) || attrs["url.path"] != "/booking/" {
+		t.Errorf("Expected route, status and path attributes, got %v", attrs)
+	}
+
+	store := spans["store.RegisterBooking"]
+	if store.Parent.SpanID() != span.SpanContext.SpanID() || store.SpanKind != trace.SpanKindInternal {
+		t.Errorf("Expected the store span to be the server span's child")
+	}
+	if attributes(store.Attributes)["booking.seat_no"] != int64(12) {
+		t.Errorf("Expected the seat attribute, got %v", store.Attributes)
+	}
+
+	unmatched, ok := spans[http.MethodGet]
+	if !ok {
+		t.Fatalf("Expected an unmatched request's span named by its method, got %v", spans)
+	}
+	if unmatched.Parent.IsValid() {
+		t.Errorf("Expected a request without traceparent to start a trace")
+	}
+}
+
+func TestMiddleware_Sampling(t *testing.T) {
+	tests := []struct {
+		name          string
+		sampleRatio   float64
+		traceparent   string
+		expectedTrace bool
+	}{
+		{name: "new trace sampled", sampleRatio: 1, expectedTrace: true},
+		{name: "new trace not sampled", sampleRatio: 0},
+		{name: "caller sampled", sampleRatio: 0, traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", expectedTrace: true},
+		{name: "caller not sampled", sampleRatio: 1, traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, exporter := newTestProvider(t, tt.sampleRatio)
+			handler := Middleware(provider)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, child := Start(r.Context(), "booking.decode")
+				child.End()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/booking/", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			if traced := len(spans) > 0; traced != tt.expectedTrace {
+				t.Fatalf("Expected traced %v, got %d spans", tt.expectedTrace, len(spans))
+			}
+			if tt.expectedTrace && len(spans) != 2 {
+				t.Errorf("Expected the server span and its child, got %d spans", len(spans))
+			}
+		})
+	}
+}
+
+func TestMiddleware_NilProvider(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if trace.SpanFromContext(r.Context()).IsRecording() {
+			t.Errorf("Expected no span without a provider")
+		}
+	})
+	Middleware(nil)(next).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestStart_Untraced(t *testing.T) {
+	ctx, span := Start(context.Background(), "store.RegisterBooking")
+	if span.IsRecording() || span.SpanContext().IsValid() {
+		t.Errorf("Expected a span recording nothing without a traced request")
+	}
+	span.SetAttributes(attribute.Bool("booking.idempotent_replay", true))
+	span.End()
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		t.Errorf("Expected no span IDs in the context")
+	}
+}
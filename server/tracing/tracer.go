@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the server's spans.
+const scopeName = "github.com/ignius299792458/techkraft-ch-svr"
+
+const (
+	queueSize      = 2048 // spans waiting to be exported; more are dropped
+	maxBatchSize   = 256
+	exportInterval = 2 * time.Second
+	exportTimeout  = 10 * time.Second
+)
+
+// Propagator reads and writes the W3C traceparent, tracestate and baggage headers.
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+/*
+* NewProvider returns an OpenTelemetry tracer provider exporting to exporter.
+  - a request carrying a traceparent follows its caller's sampling decision;
+    others are sampled at sampleRatio, by trace ID, so every service sampling
+    the same trace agrees
+  - ended spans go through a bounded queue to a goroutine exporting them, so a
+    slow or unreachable backend drops spans instead of slowing bookings
+  - Shutdown exports what is queued, then shuts the exporter down
+*/
+func NewProvider(exporter sdktrace.SpanExporter, sampleRatio float64, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+		sdktrace.WithBatcher(exporter,
+			sdktrace.WithMaxQueueSize(queueSize),
+			sdktrace.WithMaxExportBatchSize(maxBatchSize),
+			sdktrace.WithBatchTimeout(exportInterval),
+			sdktrace.WithExportTimeout(exportTimeout),
+		),
+	)
+}
+
+// Install makes provider and Propagator the process-wide OpenTelemetry ones,
+// so libraries using the OpenTelemetry API join the server's traces, and logs
+// the errors OpenTelemetry reports, such as failed exports.
+func Install(provider trace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("tracing", "err", err)
+	}))
+}
+
+// Start starts a span as a child of the one in ctx, from the same provider.
+// Outside a traced request the span records nothing and costs next to nothing.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(scopeName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,15 @@
+package utils
+
+import "time"
+
+// Clock tells the current time; inject a fixed clock in tests to move through sale windows.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the real wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
@@ -1,21 +1,44 @@
 package utils
 
-import "net/http"
+import (
+	"net/http"
+	"slices"
+)
 
-// CORS middleware to allow cross-origin requests from the frontend
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// CORS middleware to allow cross-origin requests from the frontend.
+// allowedOrigins lists the origins allowed, e.g. "https://tickets.example.com",
+// or "*" for any; other origins get no CORS headers, so browsers block them.
+func CORS(allowedOrigins []string) func(http.Handler) http.Handler {
+	anyOrigin := slices.Contains(allowedOrigins, "*")
 
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
 
-		next.ServeHTTP(w, r)
-	})
+			// Set CORS headers
+			switch {
+			case anyOrigin:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case origin != "" && slices.Contains(allowedOrigins, origin):
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if !anyOrigin {
+				// the response depends on the origin, caches must keep them apart
+				w.Header().Add("Vary", "Origin")
+			}
+			if w.Header().Get("Access-Control-Allow-Origin") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, If-None-Match, X-Request-ID")
+				w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Sunset, Link, ETag, X-Request-ID, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset")
+			}
+
+			// Handle preflight requests
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name           string
+		allowedOrigins []string
+		origin         string
+		wantOrigin     string
+		wantVary       bool
+	}{
+		{"any origin", []string{"*"}, "https://evil.example", "*", false},
+		{"listed origin", []string{"https://tickets.example", "http://localhost:3000"}, "http://localhost:3000", "http://localhost:3000", true},
+		{"unlisted origin", []string{"https://tickets.example"}, "https://evil.example", "", true},
+		{"no origin", []string{"https://tickets.example"}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := CORS(tt.allowedOrigins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+
+			for _, method := range []string{http.MethodGet, http.MethodOptions} {
+				req := httptest.NewRequest(method, "/booking/availability", nil)
+				if tt.origin != "" {
+					req.Header.Set("Origin", tt.origin)
+				}
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, req)
+
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("%s: Expected Access-Control-Allow-Origin %q, got %q", method, tt.wantOrigin, got)
+				}
+				if got := w.Header().Get("Vary") == "Origin"; got != tt.wantVary {
+					t.Errorf("%s: Expected Vary: Origin %v, got %v", method, tt.wantVary, got)
+				}
+				if (w.Header().Get("Access-Control-Allow-Methods") != "") != (tt.wantOrigin != "") {
+					t.Errorf("%s: Expected Access-Control-Allow-Methods only for allowed origins", method)
+				}
+				// browsers only let clients read the back-off headers when they're exposed
+				exposed := w.Header().Get("Access-Control-Expose-Headers")
+				for _, header := range []string{"Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"} {
+					if tt.wantOrigin != "" && !strings.Contains(exposed, header) {
+						t.Errorf("%s: Expected %s in Access-Control-Expose-Headers, got %q", method, header, exposed)
+					}
+				}
+				// admins unblock seats with DELETE
+				if methods := w.Header().Get("Access-Control-Allow-Methods"); tt.wantOrigin != "" && !strings.Contains(methods, http.MethodDelete) {
+					t.Errorf("%s: Expected DELETE in Access-Control-Allow-Methods, got %q", method, methods)
+				}
+
+				wantStatus := http.StatusTeapot
+				if method == http.MethodOptions {
+					wantStatus = http.StatusOK
+				}
+				if w.Code != wantStatus {
+					t.Errorf("%s: Expected status %d, got %d", method, wantStatus, w.Code)
+				}
+			}
+		})
+	}
+}
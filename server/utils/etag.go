@@ -0,0 +1,21 @@
+package utils
+
+import "strings"
+
+// ETagMatches reports whether an If-None-Match header lists etag, using the
+// weak comparison RFC 9110 §13.1.2 prescribes for it: W/ prefixes are ignored.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
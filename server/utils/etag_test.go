@@ -0,0 +1,28 @@
+package utils
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		etag        string
+		expected    bool
+	}{
+		{name: "no header", ifNoneMatch: "", etag: `"3-0a1b2c3d"`, expected: false},
+		{name: "same tag", ifNoneMatch: `"3-0a1b2c3d"`, etag: `"3-0a1b2c3d"`, expected: true},
+		{name: "older tag", ifNoneMatch: `"2-0a1b2c3d"`, etag: `"3-0a1b2c3d"`, expected: false},
+		{name: "one of a list", ifNoneMatch: `"1-ffffffff", "3-0a1b2c3d"`, etag: `"3-0a1b2c3d"`, expected: true},
+		{name: "weak tag", ifNoneMatch: `W/"3-0a1b2c3d"`, etag: `"3-0a1b2c3d"`, expected: true},
+		{name: "any", ifNoneMatch: "*", etag: `"3-0a1b2c3d"`, expected: true},
+		{name: "unquoted", ifNoneMatch: `3-0a1b2c3d`, etag: `"3-0a1b2c3d"`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ETagMatches(tt.ifNoneMatch, tt.etag); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
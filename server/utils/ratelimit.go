@@ -0,0 +1,256 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// RateLimit is a token bucket: Burst requests at once, refilled at RequestsPerSecond.
+type RateLimit struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	Burst             int     `json:"burst"`
+}
+
+type RateLimitConfig struct {
+	Availability RateLimit `json:"availability"` // GET /availability
+	Booking      RateLimit `json:"booking"`      // POST /ticket
+
+	// Proxies (IPs or CIDRs) whose X-Forwarded-For is trusted
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+}
+
+var (
+	DefaultAvailabilityLimit = RateLimit{RequestsPerSecond: 10, Burst: 20}
+	DefaultBookingLimit      = RateLimit{RequestsPerSecond: 1, Burst: 5}
+)
+
+var ErrRateLimited = model.NewError(model.CodeRateLimited, "rate limit exceeded")
+
+// how often idle, refilled buckets are dropped
+const rateLimitSweepInterval = time.Minute
+
+// LoadRateLimitConfig reads rate limits from a JSON file.
+func LoadRateLimitConfig(path string) (RateLimitConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RateLimitConfig{}, err
+	}
+
+	var cfg RateLimitConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return RateLimitConfig{}, fmt.Errorf("parse rate limits: %w", err)
+	}
+	return cfg, nil
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter limits requests per route and per caller. Callers are the
+// identity returned by identify (authenticated user or API key), else the client IP.
+type RateLimiter struct {
+	rules    map[string]RateLimit // "METHOD /path" -> limit
+	trusted  []netip.Prefix
+	identify func(*http.Request) (string, bool)
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket // rule + caller -> bucket
+	lastSweep time.Time
+}
+
+func NewRateLimiter(cfg RateLimitConfig, identify func(*http.Request) (string, bool)) (*RateLimiter, error) {
+	if cfg.Availability == (RateLimit{}) {
+		cfg.Availability = DefaultAvailabilityLimit
+	}
+	if cfg.Booking == (RateLimit{}) {
+		cfg.Booking = DefaultBookingLimit
+	}
+	for name, limit := range map[string]RateLimit{"availability": cfg.Availability, "booking": cfg.Booking} {
+		if limit.RequestsPerSecond <= 0 || limit.Burst < 1 {
+			return nil, fmt.Errorf("%s: requestsPerSecond and burst must be positive", name)
+		}
+	}
+
+	trusted, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimiter{
+		rules: map[string]RateLimit{
+			"GET /availability": cfg.Availability,
+			"POST /ticket":      cfg.Booking,
+		},
+		trusted:  trusted,
+		identify: identify,
+		now:      time.Now,
+		buckets:  make(map[string]*tokenBucket),
+	}, nil
+}
+
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// Middleware rejects callers over the route's limit with 429 and sets the RateLimit-* headers.
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rule := r.Method + " " + r.URL.Path
+		limit, limited := rl.rules[rule]
+		if !limited {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, retryAfter, reset := rl.take(rule+"|"+rl.caller(r), limit)
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+			RespondError(w, r, ErrRateLimited)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// caller identifies who a request counts against.
+func (rl *RateLimiter) caller(r *http.Request) string {
+	if rl.identify != nil {
+		if identity, ok := rl.identify(r); ok {
+			return identity
+		}
+	}
+	return "ip:" + ClientIP(r, rl.trusted)
+}
+
+// take spends a token from the caller's bucket. It returns whether the request
+// is allowed, the tokens left, the wait for the next token and the wait until the bucket is full.
+func (rl *RateLimiter) take(key string, limit RateLimit) (bool, int, time.Duration, time.Duration) {
+	now := rl.now()
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.sweep(now)
+
+	bucket, exists := rl.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(limit.Burst), last: now}
+		rl.buckets[key] = bucket
+	}
+
+	// refill since the last request
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+elapsed*limit.RequestsPerSecond)
+	bucket.last = now
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	var retryAfter time.Duration
+	if !allowed {
+		retryAfter = secondsToDuration((1 - bucket.tokens) / limit.RequestsPerSecond)
+	}
+	reset := secondsToDuration((float64(limit.Burst) - bucket.tokens) / limit.RequestsPerSecond)
+
+	return allowed, int(bucket.tokens), retryAfter, reset
+}
+
+// sweep drops buckets that have been idle long enough to be full again. Callers hold mu.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+
+	for key, bucket := range rl.buckets {
+		rule, _, _ := strings.Cut(key, "|")
+		limit := rl.rules[rule]
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*limit.RequestsPerSecond >= float64(limit.Burst) {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// ClientIP returns the caller's IP. X-Forwarded-For is only honoured when the
+// connection comes from a trusted proxy, and then read right to left up to the
+// first address that isn't a trusted proxy.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil || !isTrustedProxy(remote, trusted) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	client := host
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrustedProxy(addr, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
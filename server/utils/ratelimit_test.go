@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimitConfig{
+		Availability: RateLimit{RequestsPerSecond: 1, Burst: 2},
+		Booking:      RateLimit{RequestsPerSecond: 0.5, Burst: 1},
+	}, func(r *http.Request) (string, bool) {
+		user := r.Header.Get("X-Test-User")
+		return "user:" + user, user != ""
+	})
+	if err != nil {
+		t.Fatalf("Failed to create rate limiter: %v", err)
+	}
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return now }
+
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(method, path, remoteAddr, user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	// burst of 2, then limited
+	for i := range 2 {
+		if w := send(http.MethodGet, "/availability", "1.2.3.4:1000", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+	w := send(http.MethodGet, "/availability", "1.2.3.4:1000", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Expected Retry-After '1', got '%s'", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected RateLimit-Remaining '0', got '%s'", got)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("Expected RateLimit-Limit '2', got '%s'", got)
+	}
+
+	// other IPs, users and routes have their own buckets
+	if w := send(http.MethodGet, "/availability", "5.6.7.8:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected another IP to pass, got %d", w.Code)
+	}
+	if w := send(http.MethodGet, "/availability", "1.2.3.4:1000", "user-1"); w.Code != http.StatusOK {
+		t.Errorf("Expected an authenticated user to pass, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/ticket", "1.2.3.4:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected booking route to pass, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/ticket", "1.2.3.4:1000", ""); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected second booking to be limited, got %d", w.Code)
+	} else if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After '2', got '%s'", got)
+	}
+
+	// unlimited routes pass through without headers
+	if w := send(http.MethodPost, "/quote", "1.2.3.4:1000", ""); w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("Expected unlimited route to pass untouched, got %d", w.Code)
+	}
+
+	// tokens refill over time
+	now = now.Add(time.Second)
+	if w := send(http.MethodGet, "/availability", "1.2.3.4:1000", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected request after refill to pass, got %d", w.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("Failed to parse trusted proxies: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		expectedIP   string
+	}{
+		{name: "direct client", remoteAddr: "1.2.3.4:1000", expectedIP: "1.2.3.4"},
+		{name: "untrusted proxy is ignored", remoteAddr: "1.2.3.4:1000", forwardedFor: "9.9.9.9", expectedIP: "1.2.3.4"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.5:1000", forwardedFor: "9.9.9.9", expectedIP: "9.9.9.9"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.5:1000", forwardedFor: "6.6.6.6, 9.9.9.9, 192.168.1.1", expectedIP: "9.9.9.9"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.5:1000", expectedIP: "10.0.0.5"},
+		{name: "garbage header", remoteAddr: "10.0.0.5:1000", forwardedFor: "not-an-ip", expectedIP: "10.0.0.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/availability", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if got := ClientIP(req, trusted); got != tt.expectedIP {
+				t.Errorf("Expected IP '%s', got '%s'", tt.expectedIP, got)
+			}
+		})
+	}
+
+	if _, err := parseTrustedProxies([]string{"nope"}); err == nil {
+		t.Error("Expected error for invalid trusted proxy")
+	}
+}
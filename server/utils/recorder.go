@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+)
+
+// ResponseRecorder passes a response through, keeping its status and size for
+// middleware that reports on requests.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status   int
+	bytes    int64
+	hijacked bool
+}
+
+func RecordResponse(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+func (w *ResponseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Hijack hands the connection to a WebSocket, which answers 101 itself.
+func (w *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buffered, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, buffered, err
+}
+
+// Unwrap lets http.ResponseController flush and set deadlines through the recorder.
+func (w *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status is the status the response was sent with: 101 once hijacked, 200 when nothing was written.
+func (w *ResponseRecorder) Status() int {
+	switch {
+	case w.hijacked:
+		return http.StatusSwitchingProtocols
+	case w.status == 0:
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Bytes is the size of the body written so far.
+func (w *ResponseRecorder) Bytes() int64 {
+	return w.bytes
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// UnmatchedRoute names the route of requests no route matched.
+const UnmatchedRoute = "unmatched"
+
+/*
+* RouteInfo names the route that served a request, for metrics, traces and logs.
+  - Pattern is the route's path pattern with its module prefix, e.g.
+    /booking/{id}; the version prefix is left out, so versions share it
+  - Method is the route's method, or the request's when the route has none;
+    nonstandard methods are "other"
+  - the outermost middleware adds it with TrackRoute and reads it once the
+    request is served: routes fill it in behind http.StripPrefix and other
+    request copies, which middleware can't see into
+*/
+type RouteInfo struct {
+	Method  string
+	Pattern string
+}
+
+type routeKey struct{}
+
+// TrackRoute returns r carrying a RouteInfo for its route to fill in, or r
+// and the RouteInfo it already carries.
+func TrackRoute(r *http.Request) (*http.Request, *RouteInfo) {
+	if route, ok := r.Context().Value(routeKey{}).(*RouteInfo); ok {
+		return r, route
+	}
+	route := &RouteInfo{Method: methodName(r.Method), Pattern: UnmatchedRoute}
+	return r.WithContext(context.WithValue(r.Context(), routeKey{}, route)), route
+}
+
+// Route names the route of the requests next serves: pattern is a mux pattern
+// like "GET /booking/{id}", written out with its module prefix. A route nested
+// in another renames it.
+func Route(pattern string, next http.Handler) http.Handler {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route, ok := r.Context().Value(routeKey{}).(*RouteInfo); ok {
+			route.Method, route.Pattern = method, path
+			if method == "" {
+				route.Method = methodName(r.Method)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// methodName keeps the standard methods; clients can send any other.
+func methodName(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "other"
+}
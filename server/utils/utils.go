@@ -1,31 +1,14 @@
 package utils
 
 import (
-	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/ignius299792458/techkraft-ch-svr/model"
 )
 
-func ValidateBookingRequest(req *model.BookingOrder) error {
-	if req.UserID == "" {
-		return NewValidationError("user_id is required")
-	}
-	if req.IdempotencyKey == "" {
-		return NewValidationError("idempotency_key is required")
-	}
-	if req.SeatNo == 0 {
-		return NewValidationError("seat_no must be greater than 0")
-	}
-	if !req.Tier.IsValidTier() {
-		return NewValidationError("invalid tier")
-	}
-	if !req.PaymentStatus.IsValidPaymentStatus() {
-		return NewValidationError("invalid payment status")
-	}
-	return nil
-}
-
 func CalculateAmount(tier model.Tier) uint64 {
 	switch tier {
 	case model.TierVIP:
@@ -39,19 +22,45 @@ func CalculateAmount(tier model.Tier) uint64 {
 	}
 }
 
-func RespondSuccess(w http.ResponseWriter, message string, booking *model.Booking) {
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(model.BookingResponse{
+func RespondSuccess(w http.ResponseWriter, r *http.Request, message string, booking *model.Booking) {
+	WriteJSON(w, r, http.StatusOK, model.BookingResponse{
 		Success: true,
 		Message: message,
 		Booking: booking,
 	})
 }
 
-func RespondError(w http.ResponseWriter, message string, statusCode int) {
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(model.BookingResponse{
-		Success: false,
-		Message: message,
+// RespondError writes err as an application/problem+json body. Errors carrying a
+// catalogue code set the status and code; anything else is an opaque 500.
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
+	var appErr *model.Error
+	if !errors.As(err, &appErr) {
+		slog.ErrorContext(r.Context(), "unhandled error", "path", r.URL.Path, "err", err)
+		appErr = model.NewError(model.CodeInternal, "internal server error")
+	}
+	detail := appErr.Message
+	if appErr.Code != model.CodeInternal {
+		// keeps the context of wrapped errors
+		detail = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	WriteJSON(w, r, appErr.Code.Status(), model.Problem{
+		Type:     appErr.Code.Type(),
+		Title:    appErr.Code.Title(),
+		Status:   appErr.Code.Status(),
+		Detail:   detail,
+		Instance: requestPath(r),
+		Code:     appErr.Code,
+		Errors:   appErr.Fields,
 	})
 }
+
+// requestPath is the path the client requested, before any http.StripPrefix.
+func requestPath(r *http.Request) string {
+	if r.RequestURI == "" {
+		return r.URL.Path
+	}
+	path, _, _ := strings.Cut(r.RequestURI, "?")
+	return path
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestRespondError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   model.ErrorCode
+		expectedDetail string
+		expectedFields int
+	}{
+		{
+			name:           "catalogue error",
+			err:            model.NewError(model.CodeSeatTaken, "seat already booked"),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   model.CodeSeatTaken,
+			expectedDetail: "seat already booked",
+		},
+		{
+			name:           "wrapped catalogue error",
+			err:            fmt.Errorf("%w: unexpected issuer", model.NewError(model.CodeUnauthorized, "invalid token claims")),
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   model.CodeUnauthorized,
+			expectedDetail: "invalid token claims: unexpected issuer",
+		},
+		{
+			name:           "validation error",
+			err:            NewValidationError("tier", model.CodeInvalidTier, "invalid tier"),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   model.CodeValidationFailed,
+			expectedDetail: "invalid tier",
+			expectedFields: 1,
+		},
+		{
+			name:           "unknown error is not leaked",
+			err:            errors.New("connection refused: db-1:5432"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   model.CodeInternal,
+			expectedDetail: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			RespondError(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", nil), tt.err)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Expected problem+json content type, got '%s'", got)
+			}
+
+			var problem ProblemV1
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if problem.Code != tt.expectedCode || problem.Type != tt.expectedCode.Type() || problem.Status != tt.expectedStatus {
+				t.Errorf("Expected code %s with matching type and status, got %+v", tt.expectedCode, problem)
+			}
+			if problem.Detail != tt.expectedDetail || problem.Message != tt.expectedDetail {
+				t.Errorf("Expected detail '%s', got '%s'", tt.expectedDetail, problem.Detail)
+			}
+			if problem.Instance != "/booking/ticket" {
+				t.Errorf("Expected instance '/booking/ticket', got '%s'", problem.Instance)
+			}
+			if len(problem.Errors) != tt.expectedFields {
+				t.Errorf("Expected %d field errors, got %d", tt.expectedFields, len(problem.Errors))
+			}
+		})
+	}
+}
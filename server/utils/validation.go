@@ -1,16 +1,173 @@
 package utils
 
-// ValidationError represents a validation error with a custom message
-type ValidationError struct {
-	message string
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// MaxRequestBodyBytes caps JSON request bodies; orders and quotes are far smaller.
+const MaxRequestBodyBytes = 16 << 10
+
+var (
+	ErrInvalidBody  = model.NewError(model.CodeInvalidRequest, "invalid request body")
+	ErrEmptyBody    = model.NewError(model.CodeInvalidRequest, "request body is empty")
+	ErrBodyTooLarge = model.NewError(model.CodeRequestTooLarge, fmt.Sprintf("request body is larger than %d bytes", MaxRequestBodyBytes))
+)
+
+// NewValidationError creates a VALIDATION_FAILED error for a single invalid field
+func NewValidationError(field string, code model.ErrorCode, message string) *model.Error {
+	var errs FieldErrors
+	errs.Add(field, code, message)
+	return errs.Err().(*model.Error)
+}
+
+// FieldErrors collects every invalid field of a request, so clients can fix them all at once.
+type FieldErrors []model.FieldError
+
+func (e *FieldErrors) Add(field string, code model.ErrorCode, message string) {
+	*e = append(*e, model.FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns a VALIDATION_FAILED error listing the fields, or nil if there are none.
+func (e FieldErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return &model.Error{
+		Code:    model.CodeValidationFailed,
+		Message: strings.Join(messages, "; "),
+		Fields:  e,
+	}
+}
+
+// DecodeJSON decodes a single JSON object from the request body into v. The
+// body is capped at MaxRequestBodyBytes and unknown fields are rejected.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxRequestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if decoder.More() {
+		return ErrInvalidBody
+	}
+	return nil
+}
+
+func decodeError(err error) error {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case errors.As(err, &tooLarge):
+		return ErrBodyTooLarge
+	case errors.As(err, &typeErr):
+		return NewValidationError(typeErr.Field, model.CodeInvalidType, fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type))
+	}
+
+	// encoding/json has no typed error for unknown fields
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		field = strings.Trim(field, `"`)
+		return NewValidationError(field, model.CodeUnknownField, fmt.Sprintf("unknown field %q", field))
+	}
+	return ErrInvalidBody
+}
+
+// ValidateBookingRequest checks every field of a booking order and upper-cases
+// its country, zip code and currency. Country is optional, as it always was;
+// a zip code is only checked against the country it is given with.
+func ValidateBookingRequest(req *model.BookingOrder) error {
+	var errs FieldErrors
+
+	if req.UserID == "" {
+		errs.Add("userId", model.CodeRequired, "user_id is required")
+	}
+	if req.IdempotencyKey == "" {
+		errs.Add("idempotencyKey", model.CodeRequired, "idempotency_key is required")
+	}
+	if !req.Tier.IsValidTier() {
+		errs.Add("tier", model.CodeInvalidTier, "invalid tier")
+	}
+	validateSeat(&errs, req.SeatNo, req.Tier)
+	if !req.PaymentStatus.IsValidPaymentStatus() {
+		errs.Add("paymentStatus", model.CodeInvalidRequest, "invalid payment status")
+	}
+
+	req.Country = normalizeCode(req.Country)
+	req.ZipCode = normalizeCode(req.ZipCode)
+	req.Currency = normalizeCode(req.Currency)
+
+	switch {
+	case req.Country == "":
+	case !IsValidCountry(req.Country):
+		errs.Add("country", model.CodeInvalidCountry, "country must be an ISO 3166-1 alpha-2 code")
+	case req.ZipCode == "":
+		if PostalCodeRequired(req.Country) {
+			errs.Add("zipCode", model.CodeRequired, "zip_code is required")
+		}
+	case !IsValidPostalCode(req.Country, req.ZipCode):
+		errs.Add("zipCode", model.CodeInvalidPostalCode, "zip_code is not valid for "+req.Country)
+	}
+	validateCurrency(&errs, req.Currency)
+
+	return errs.Err()
+}
+
+// ValidateQuoteRequest checks a quote request. Country and currency are optional.
+func ValidateQuoteRequest(req *model.QuoteRequest) error {
+	var errs FieldErrors
+
+	if !req.Tier.IsValidTier() {
+		errs.Add("tier", model.CodeInvalidTier, "invalid tier")
+	}
+	validateSeat(&errs, req.SeatNo, req.Tier)
+
+	req.Country = normalizeCode(req.Country)
+	req.Currency = normalizeCode(req.Currency)
+
+	if req.Country != "" && !IsValidCountry(req.Country) {
+		errs.Add("country", model.CodeInvalidCountry, "country must be an ISO 3166-1 alpha-2 code")
+	}
+	validateCurrency(&errs, req.Currency)
+
+	return errs.Err()
+}
+
+// validateSeat checks that the seat is in the tier's range, so a seat isn't sold
+// at another tier's price. An invalid tier is reported on its own.
+func validateSeat(errs *FieldErrors, seatNo uint32, tier model.Tier) {
+	if seatNo == 0 {
+		errs.Add("seatNo", model.CodeInvalidSeat, "seat_no must be greater than 0")
+		return
+	}
+	if !tier.IsValidTier() {
+		return
+	}
+	if seatTier, valid := model.TierForSeat(seatNo); !valid || seatTier != tier {
+		errs.Add("seatNo", model.CodeInvalidSeat, fmt.Sprintf("seat_no %d is not a %s seat", seatNo, tier))
+	}
 }
 
-// NewValidationError creates a new validation error with the given message
-func NewValidationError(message string) ValidationError {
-	return ValidationError{message: message}
+// an empty currency means USD
+func validateCurrency(errs *FieldErrors, currency string) {
+	if currency != "" && !IsValidCurrency(currency) {
+		errs.Add("currency", model.CodeInvalidCurrency, "currency must be an ISO 4217 code")
+	}
 }
 
-// Error implements the error interface
-func (e ValidationError) Error() string {
-	return e.message
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
 }
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestValidateBookingRequest(t *testing.T) {
+	valid := func() model.BookingOrder {
+		return model.BookingOrder{
+			UserID:         "user-1",
+			Tier:           model.TierVIP,
+			SeatNo:         1,
+			Country:        "us",
+			ZipCode:        "10001-1234",
+			Currency:       "usd",
+			IdempotencyKey: "key-1",
+			PaymentStatus:  model.PaymentStatusConfirmed,
+		}
+	}
+
+	tests := []struct {
+		name           string
+		modify         func(o *model.BookingOrder)
+		expectedFields map[string]model.ErrorCode
+	}{
+		{
+			name:   "valid order",
+			modify: func(o *model.BookingOrder) {},
+		},
+		{
+			name: "every invalid field is reported",
+			modify: func(o *model.BookingOrder) {
+				o.UserID = ""
+				o.IdempotencyKey = ""
+				o.SeatNo = 0
+				o.Tier = "BALCONY"
+				o.Currency = "DOLLARS"
+			},
+			expectedFields: map[string]model.ErrorCode{
+				"userId":         model.CodeRequired,
+				"idempotencyKey": model.CodeRequired,
+				"seatNo":         model.CodeInvalidSeat,
+				"tier":           model.CodeInvalidTier,
+				"currency":       model.CodeInvalidCurrency,
+			},
+		},
+		{
+			name:           "seat of another tier",
+			modify:         func(o *model.BookingOrder) { o.SeatNo = 75 },
+			expectedFields: map[string]model.ErrorCode{"seatNo": model.CodeInvalidSeat},
+		},
+		{
+			name:           "seat outside the layout",
+			modify:         func(o *model.BookingOrder) { o.SeatNo = 1000 },
+			expectedFields: map[string]model.ErrorCode{"seatNo": model.CodeInvalidSeat},
+		},
+		{
+			name:   "country is optional",
+			modify: func(o *model.BookingOrder) { o.Country = ""; o.ZipCode = "" },
+		},
+		{
+			name:           "alpha-3 country",
+			modify:         func(o *model.BookingOrder) { o.Country = "USA" },
+			expectedFields: map[string]model.ErrorCode{"country": model.CodeInvalidCountry},
+		},
+		{
+			name:           "zip code in the wrong format",
+			modify:         func(o *model.BookingOrder) { o.ZipCode = "SW1A 1AA" },
+			expectedFields: map[string]model.ErrorCode{"zipCode": model.CodeInvalidPostalCode},
+		},
+		{
+			name:           "missing zip code",
+			modify:         func(o *model.BookingOrder) { o.ZipCode = "" },
+			expectedFields: map[string]model.ErrorCode{"zipCode": model.CodeRequired},
+		},
+		{
+			name:   "UK postcode",
+			modify: func(o *model.BookingOrder) { o.Country = "GB"; o.ZipCode = "sw1a 1aa" },
+		},
+		{
+			name:   "country without postal codes",
+			modify: func(o *model.BookingOrder) { o.Country = "AE"; o.ZipCode = "" },
+		},
+		{
+			name:   "country without a known postal format",
+			modify: func(o *model.BookingOrder) { o.Country = "AR"; o.ZipCode = "C1002" },
+		},
+		{
+			name:   "currency defaults to USD",
+			modify: func(o *model.BookingOrder) { o.Currency = "" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := valid()
+			tt.modify(&order)
+			err := ValidateBookingRequest(&order)
+
+			if len(tt.expectedFields) == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				if order.Country != strings.ToUpper(order.Country) || order.Currency != strings.ToUpper(order.Currency) {
+					t.Errorf("Expected codes to be upper-cased, got %s/%s", order.Country, order.Currency)
+				}
+				return
+			}
+
+			var appErr *model.Error
+			if !errors.As(err, &appErr) || appErr.Code != model.CodeValidationFailed {
+				t.Fatalf("Expected VALIDATION_FAILED, got %v", err)
+			}
+			if len(appErr.Fields) != len(tt.expectedFields) {
+				t.Errorf("Expected %d field errors, got %+v", len(tt.expectedFields), appErr.Fields)
+			}
+			for _, fieldErr := range appErr.Fields {
+				if code, exists := tt.expectedFields[fieldErr.Field]; !exists || code != fieldErr.Code {
+					t.Errorf("Unexpected field error %+v", fieldErr)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedCode  model.ErrorCode
+		expectedField string
+	}{
+		{name: "valid body", body: `{"userId":"user-1","seatNo":1}`},
+		{name: "empty body", body: ``, expectedCode: model.CodeInvalidRequest},
+		{name: "malformed JSON", body: `{"userId":`, expectedCode: model.CodeInvalidRequest},
+		{name: "trailing data", body: `{"userId":"user-1"} {}`, expectedCode: model.CodeInvalidRequest},
+		{name: "unknown field", body: `{"userId":"user-1","complimentary":true}`, expectedCode: model.CodeValidationFailed, expectedField: "complimentary"},
+		{name: "wrong type", body: `{"seatNo":"one"}`, expectedCode: model.CodeValidationFailed, expectedField: "seatNo"},
+		{name: "too large", body: `{"userId":"` + strings.Repeat("a", MaxRequestBodyBytes) + `"}`, expectedCode: model.CodeRequestTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/booking/ticket", strings.NewReader(tt.body))
+			var order model.BookingOrder
+			err := DecodeJSON(httptest.NewRecorder(), r, &order)
+
+			if tt.expectedCode == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+
+			var appErr *model.Error
+			if !errors.As(err, &appErr) || appErr.Code != tt.expectedCode {
+				t.Fatalf("Expected code %s, got %v", tt.expectedCode, err)
+			}
+			if tt.expectedField != "" && (len(appErr.Fields) != 1 || appErr.Fields[0].Field != tt.expectedField) {
+				t.Errorf("Expected field error on '%s', got %+v", tt.expectedField, appErr.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+// APIVersion is the major version of the HTTP API a request was made against.
+type APIVersion int
+
+const (
+	APIVersion1 APIVersion = 1
+	APIVersion2 APIVersion = 2
+
+	LatestAPIVersion = APIVersion2
+)
+
+// APIVersions lists every served version, oldest first.
+var APIVersions = []APIVersion{APIVersion1, APIVersion2}
+
+func (v APIVersion) String() string {
+	return "v" + strconv.Itoa(int(v))
+}
+
+type apiVersionKey struct{}
+
+// WithAPIVersion tags requests with the API version they were routed to.
+func WithAPIVersion(version APIVersion) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+		})
+	}
+}
+
+// APIVersionFromContext returns the request's API version. Requests that
+// weren't routed through a version prefix get v1, the original API.
+func APIVersionFromContext(ctx context.Context) APIVersion {
+	if version, ok := ctx.Value(apiVersionKey{}).(APIVersion); ok {
+		return version
+	}
+	return APIVersion1
+}
+
+// ---- Response adapters ----
+
+// Handlers always build the latest response shape. An adapter registered for
+// version v turns a response of version v+1 into the shape v clients expect,
+// so older clients keep working as the models evolve.
+type responseAdapter struct {
+	to    reflect.Type
+	adapt func(any) any
+}
+
+var responseAdapters = make(map[APIVersion]map[reflect.Type]responseAdapter)
+
+// RegisterResponseAdapter registers how a From response is served to version clients.
+func RegisterResponseAdapter[From, To any](version APIVersion, adapt func(From) To) {
+	if responseAdapters[version] == nil {
+		responseAdapters[version] = make(map[reflect.Type]responseAdapter)
+	}
+	responseAdapters[version][reflect.TypeFor[From]()] = responseAdapter{
+		to:    reflect.TypeFor[To](),
+		adapt: func(v any) any { return adapt(v.(From)) },
+	}
+}
+
+// AdaptResponse converts a latest-version response to the shape of version.
+func AdaptResponse(version APIVersion, response any) any {
+	for v := LatestAPIVersion - 1; v >= version; v-- {
+		if adapter, exists := responseAdapters[v][reflect.TypeOf(response)]; exists {
+			response = adapter.adapt(response)
+		}
+	}
+	return response
+}
+
+// AdaptedType is the type AdaptResponse returns for a response of type t.
+func AdaptedType(version APIVersion, t reflect.Type) reflect.Type {
+	for v := LatestAPIVersion - 1; v >= version; v-- {
+		if adapter, exists := responseAdapters[v][t]; exists {
+			t = adapter.to
+		}
+	}
+	return t
+}
+
+// WriteJSON writes the response in the shape of the request's API version.
+func WriteJSON(w http.ResponseWriter, r *http.Request, status int, response any) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(AdaptResponse(APIVersionFromContext(r.Context()), response))
+}
+
+// EncodeJSON returns the body WriteJSON writes for response, for handlers that cache it.
+func EncodeJSON(r *http.Request, response any) ([]byte, error) {
+	body, err := json.Marshal(AdaptResponse(APIVersionFromContext(r.Context()), response))
+	if err != nil {
+		return nil, err
+	}
+	return append(body, '\n'), nil
+}
+
+// ProblemV1 is the v1 error body, which also carries the success and message
+// fields of the API's original error responses.
+type ProblemV1 struct {
+	model.Problem
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// The problem body is the only shape v1 and v2 differ in: success responses
+// are the same in both, so none has an adapter until a model changes.
+func init() {
+	RegisterResponseAdapter(APIVersion1, func(problem model.Problem) ProblemV1 {
+		return ProblemV1{Problem: problem, Success: false, Message: problem.Detail}
+	})
+}
+
+// ---- Deprecation ----
+
+// Deprecation announces that a route group is deprecated and when it goes away.
+type Deprecation struct {
+	Since     time.Time
+	Sunset    time.Time
+	Successor string // path prefix of the replacement routes, e.g. /v1
+}
+
+// Deprecated sets the Deprecation (RFC 9745), Sunset (RFC 8594) and
+// successor-version Link headers on every response.
+func Deprecated(deprecation Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+			if !deprecation.Sunset.IsZero() {
+				w.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if deprecation.Successor != "" {
+				w.Header().Set("Link", "<"+deprecation.Successor+r.URL.Path+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
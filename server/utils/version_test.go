@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ignius299792458/techkraft-ch-svr/model"
+)
+
+func TestRespondError_APIVersions(t *testing.T) {
+	tests := []struct {
+		name            string
+		wrap            func(http.Handler) http.Handler
+		expectedLegacy  bool
+		expectedVersion APIVersion
+	}{
+		{name: "unversioned", wrap: func(next http.Handler) http.Handler { return next }, expectedLegacy: true, expectedVersion: APIVersion1},
+		{name: "v1", wrap: WithAPIVersion(APIVersion1), expectedLegacy: true, expectedVersion: APIVersion1},
+		{name: "v2", wrap: WithAPIVersion(APIVersion2), expectedLegacy: false, expectedVersion: APIVersion2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var version APIVersion
+			handler := tt.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				version = APIVersionFromContext(r.Context())
+				RespondError(w, r, model.NewError(model.CodeSeatTaken, "seat already booked"))
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/booking/ticket", nil))
+
+			if version != tt.expectedVersion {
+				t.Errorf("Expected version %s, got %s", tt.expectedVersion, version)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			if body["code"] != string(model.CodeSeatTaken) {
+				t.Errorf("Expected code SEAT_TAKEN, got %v", body["code"])
+			}
+			_, hasMessage := body["message"]
+			_, hasSuccess := body["success"]
+			if hasMessage != tt.expectedLegacy || hasSuccess != tt.expectedLegacy {
+				t.Errorf("Expected legacy fields present=%v, got message=%v success=%v", tt.expectedLegacy, hasMessage, hasSuccess)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	deprecation := Deprecation{
+		Since:     time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		Sunset:    time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC),
+		Successor: "/v1",
+	}
+	handler := Deprecated(deprecation)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/booking/availability", nil))
+
+	expected := map[string]string{
+		"Deprecation": "@1792281600",
+		"Sunset":      "Sun, 18 Apr 2027 00:00:00 GMT",
+		"Link":        `</v1/booking/availability>; rel="successor-version"`,
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("Expected %s '%s', got '%s'", header, value, got)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+// Package websocket is a minimal RFC 6455 implementation: the opening
+// handshake, text messages, ping/pong and the closing handshake. Extensions
+// and subprotocols are not negotiated. Dial is a client for tests and tools.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// acceptGUID is appended to the client's key to prove the server speaks WebSocket (RFC 6455 §1.3).
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes (RFC 6455 §5.2)
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close status codes (RFC 6455 §7.4.1)
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseMessageTooBig   = 1009
+	CloseTryAgainLater   = 1013
+)
+
+// DefaultReadLimit caps incoming messages; clients of this API only send small commands.
+const DefaultReadLimit = 4 << 10
+
+var (
+	ErrNotWebSocket    = errors.New("websocket: not a websocket handshake")
+	ErrOriginForbidden = errors.New("websocket: origin not allowed")
+	ErrClosed          = errors.New("websocket: connection closed")
+)
+
+// CloseError is returned by ReadMessage once the peer closed the connection.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with %d %s", e.Code, e.Reason)
+}
+
+// Conn is a WebSocket connection. ReadMessage must be called from one
+// goroutine at a time; writes may come from any goroutine.
+type Conn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	readLimit int64
+	client    bool // clients mask what they send, servers must not
+
+	writeMu      sync.Mutex
+	writeTimeout time.Duration
+	closeSent    bool
+}
+
+// Accept completes the opening handshake and takes over the connection. On
+// failure it has already answered the request with an error status.
+//
+// Browsers send the page's Origin, which must be the server's own or one of
+// allowedOrigins ("*" allows any); clients that aren't browsers send none.
+func Accept(w http.ResponseWriter, r *http.Request, allowedOrigins []string) (*Conn, error) {
+	if r.Method != http.MethodGet ||
+		!headerHasToken(r.Header, "Connection", "upgrade") ||
+		!headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+		return nil, ErrNotWebSocket
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return nil, ErrNotWebSocket
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+	if !originAllowed(r, allowedOrigins) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return nil, ErrOriginForbidden
+	}
+
+	netConn, buffered, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, err
+	}
+	// the server's read and write timeouts don't apply to a long-lived socket
+	netConn.SetDeadline(time.Time{})
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n"
+	if _, err := io.WriteString(netConn, response); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	return &Conn{
+		conn:         netConn,
+		reader:       buffered.Reader,
+		readLimit:    DefaultReadLimit,
+		writeTimeout: 10 * time.Second,
+	}, nil
+}
+
+// AcceptKey is the Sec-WebSocket-Accept answer to a client's Sec-WebSocket-Key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func originAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin) {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(parsed.Host, r.Host)
+}
+
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetReadLimit caps the size of a message; larger ones close the connection.
+func (c *Conn) SetReadLimit(limit int64) {
+	c.readLimit = limit
+}
+
+// SetReadDeadline fails reads that don't complete by t; the zero time waits forever.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// ReadMessage returns the next text message. Pings are answered and pongs
+// skipped; after the peer's close frame it returns a *CloseError.
+func (c *Conn) ReadMessage() ([]byte, error) {
+	var message []byte
+	fragmented := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			if len(payload) == 1 || !validCloseCode(closeErr.Code) || !utf8.ValidString(closeErr.Reason) {
+				c.Close(CloseProtocolError, "invalid close frame")
+				return nil, ErrClosed
+			}
+			c.Close(closeErr.Code, "")
+			return nil, closeErr
+		case opBinary:
+			c.Close(CloseUnsupportedData, "text messages only")
+			return nil, ErrClosed
+		case opText:
+			if fragmented {
+				c.Close(CloseProtocolError, "expected continuation frame")
+				return nil, ErrClosed
+			}
+		case opContinuation:
+			if !fragmented {
+				c.Close(CloseProtocolError, "unexpected continuation frame")
+				return nil, ErrClosed
+			}
+		default:
+			c.Close(CloseProtocolError, "unknown opcode")
+			return nil, ErrClosed
+		}
+
+		if int64(len(message)+len(payload)) > c.readLimit {
+			c.Close(CloseMessageTooBig, "message too big")
+			return nil, ErrClosed
+		}
+		message = append(message, payload...)
+		if fin {
+			return message, nil
+		}
+		fragmented = true
+	}
+}
+
+// validCloseCode reports whether a peer may send the code in a close frame
+// (RFC 6455 §7.4): 1005, 1006 and 1015 only report a close locally, 1004
+// and the codes below 1000 or from 1016 to 2999 are reserved.
+func validCloseCode(code int) bool {
+	switch {
+	case code >= 3000 && code <= 4999:
+		return true
+	case code < 1000 || code > 1014:
+		return false
+	}
+	return code != 1004 && code != 1005 && code != 1006
+}
+
+// readFrame reads one frame and unmasks its payload (RFC 6455 §5.2).
+func (c *Conn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+
+	if header[0]&0x70 != 0 {
+		c.Close(CloseProtocolError, "reserved bits set")
+		return false, 0, nil, ErrClosed
+	}
+	// clients must mask every frame, servers none (RFC 6455 §5.1)
+	if masked == c.client {
+		c.Close(CloseProtocolError, "invalid masking")
+		return false, 0, nil, ErrClosed
+	}
+
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]) & (1<<63 - 1))
+	}
+
+	control := opcode&0x8 != 0
+	if control && (length > 125 || !fin) {
+		c.Close(CloseProtocolError, "invalid control frame")
+		return false, 0, nil, ErrClosed
+	}
+	if length > c.readLimit {
+		c.Close(CloseMessageTooBig, "message too big")
+		return false, 0, nil, ErrClosed
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		maskBytes(mask, payload)
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteText sends one text message.
+func (c *Conn) WriteText(message []byte) error {
+	return c.writeFrame(opText, message)
+}
+
+// Ping sends a ping; the peer's pong is consumed by ReadMessage.
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// writeFrame sends an unfragmented frame, masked when sent by a client.
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return ErrClosed
+	}
+	if opcode == opClose {
+		c.closeSent = true
+	}
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		maskBytes(mask, frame[start:])
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func maskBytes(mask [4]byte, payload []byte) {
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+}
+
+// Close sends a close frame with the status code and closes the connection.
+// Closing twice is harmless.
+func (c *Conn) Close(code int, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	payload = append(payload, reason[:min(len(reason), 123)]...)
+	err := c.writeFrame(opClose, payload)
+	c.conn.Close()
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// Dial opens a client connection to a ws:// or wss:// URL (http:// and
+// https:// are accepted too), sending header with the handshake.
+func Dial(rawURL string, header http.Header) (*Conn, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	secure := target.Scheme == "wss" || target.Scheme == "https"
+	host := target.Host
+	if target.Port() == "" {
+		host = net.JoinHostPort(target.Hostname(), map[bool]string{false: "80", true: "443"}[secure])
+	}
+
+	var netConn net.Conn
+	if secure {
+		netConn, err = tls.Dial("tcp", host, &tls.Config{ServerName: target.Hostname()})
+	} else {
+		netConn, err = net.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	target.Scheme = map[bool]string{false: "http", true: "https"}[secure]
+	req, err := http.NewRequest(http.MethodGet, target.String(), nil)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if err := req.Write(netConn); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		netConn.Close()
+		return nil, fmt.Errorf("%w: %s", ErrNotWebSocket, resp.Status)
+	}
+
+	return &Conn{
+		conn:         netConn,
+		reader:       reader,
+		readLimit:    1 << 20,
+		client:       true,
+		writeTimeout: 10 * time.Second,
+	}, nil
+}
@@ -0,0 +1,166 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// the example of RFC 6455 §1.3
+	if got := AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected s3pPLMBiTxaQ9kYGzzhZRbK+xOo=, got %s", got)
+	}
+}
+
+func echoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := Accept(w, r, []string{"https://tickets.example.com"})
+		if err != nil {
+			return
+		}
+		for {
+			message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			conn.WriteText(message)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEcho(t *testing.T) {
+	server := echoServer(t)
+	conn, err := Dial(strings.Replace(server.URL, "http", "ws", 1), nil)
+	if err != nil {
+		t.Fatalf("Expected the handshake to succeed, got %v", err)
+	}
+	defer conn.Close(CloseNormal, "")
+
+	tests := []struct {
+		name    string
+		message string
+	}{
+		{name: "short", message: `{"type":"hold"}`},
+		{name: "16-bit length", message: strings.Repeat("a", 300)},
+		{name: "empty", message: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := conn.Ping(); err != nil {
+				t.Fatalf("Expected the ping to be sent, got %v", err)
+			}
+			if err := conn.WriteText([]byte(tt.message)); err != nil {
+				t.Fatalf("Expected the message to be sent, got %v", err)
+			}
+			echoed, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("Expected the echo, got %v", err)
+			}
+			if string(echoed) != tt.message {
+				t.Errorf("Expected %q, got %q", tt.message, echoed)
+			}
+		})
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	server := echoServer(t)
+	conn, err := Dial(server.URL, nil)
+	if err != nil {
+		t.Fatalf("Expected the handshake to succeed, got %v", err)
+	}
+
+	conn.WriteText([]byte(strings.Repeat("a", DefaultReadLimit+1)))
+
+	_, err = conn.ReadMessage()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != CloseMessageTooBig {
+		t.Errorf("Expected close code %d, got %v", CloseMessageTooBig, err)
+	}
+}
+
+func TestAcceptRejectsPlainRequests(t *testing.T) {
+	server := echoServer(t)
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected a response, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("Expected status %d, got %d", http.StatusUpgradeRequired, resp.StatusCode)
+	}
+}
+
+func TestAcceptChecksOrigin(t *testing.T) {
+	server := echoServer(t)
+
+	tests := []struct {
+		name     string
+		origin   string
+		expected error
+	}{
+		{name: "no origin", origin: ""},
+		{name: "allowed origin", origin: "https://tickets.example.com"},
+		{name: "same origin", origin: server.URL},
+		{name: "foreign origin", origin: "https://evil.example.com", expected: ErrNotWebSocket},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			conn, err := Dial(server.URL, header)
+			if err == nil {
+				conn.Close(CloseNormal, "")
+			}
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCloseCodes(t *testing.T) {
+	server := echoServer(t)
+
+	tests := []struct {
+		name     string
+		code     int
+		expected int
+	}{
+		{name: "normal", code: CloseNormal, expected: CloseNormal},
+		{name: "private use", code: 4000, expected: 4000},
+		{name: "below 1000", code: 999, expected: CloseProtocolError},
+		{name: "no status received", code: 1005, expected: CloseProtocolError},
+		{name: "abnormal closure", code: 1006, expected: CloseProtocolError},
+		{name: "TLS handshake", code: 1015, expected: CloseProtocolError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := Dial(server.URL, nil)
+			if err != nil {
+				t.Fatalf("Expected the handshake to succeed, got %v", err)
+			}
+			// sends the close frame but keeps reading for the server's answer
+			conn.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, uint16(tt.code)))
+
+			_, err = conn.ReadMessage()
+			var closeErr *CloseError
+			if !errors.As(err, &closeErr) || closeErr.Code != tt.expected {
+				t.Errorf("Expected close code %d, got %v", tt.expected, err)
+			}
+			conn.conn.Close()
+		})
+	}
+}